@@ -20,14 +20,21 @@ import (
 	"github.com/yourusername/db_asst/internal/auth"
 	"github.com/yourusername/db_asst/internal/chat"
 	"github.com/yourusername/db_asst/internal/db"
+	"github.com/yourusername/db_asst/internal/examples"
 	"github.com/yourusername/db_asst/internal/executor"
+	"github.com/yourusername/db_asst/internal/feedback"
+	"github.com/yourusername/db_asst/internal/glossary"
+	"github.com/yourusername/db_asst/internal/i18n"
 	"github.com/yourusername/db_asst/internal/llm"
 	"github.com/yourusername/db_asst/internal/logger"
 	"github.com/yourusername/db_asst/internal/memory"
 	"github.com/yourusername/db_asst/internal/monitor"
 	"github.com/yourusername/db_asst/internal/progress"
+	"github.com/yourusername/db_asst/internal/prompts"
 	"github.com/yourusername/db_asst/internal/reports"
+	"github.com/yourusername/db_asst/internal/results"
 	"github.com/yourusername/db_asst/internal/templates"
+	"github.com/yourusername/db_asst/internal/usage"
 )
 
 func main() {
@@ -47,6 +54,7 @@ func main() {
 		os.Exit(1)
 	}
 	defer log.Sync()
+	i18n.SetDefault(cfg.DefaultLocale)
 
 	log.Info("Starting DB Assistant API Server",
 		zap.Int("port", cfg.ServerPort),
@@ -65,13 +73,25 @@ func main() {
 	// Initialize services
 	jwtManager := auth.NewJWTManager(cfg.JWTSecret)
 	sqlExecutor := executor.New(dbClient, cfg, log)
-	llmClient := llm.NewLLMClient(cfg, log)
 	appDB, appDriver, err := initAppDatabase(cfg, log)
 	if err != nil {
 		log.Fatal("Failed to init app database", zap.Error(err))
 	}
 	defer appDB.Close()
 
+	promptStore, err := prompts.NewStore(appDB, appDriver)
+	if err != nil {
+		log.Fatal("Failed to init prompt store", zap.Error(err))
+	}
+	promptSvc, err := prompts.NewService(promptStore)
+	if err != nil {
+		log.Fatal("Failed to load prompt templates", zap.Error(err))
+	}
+	llmClient, err := llm.NewLLMClient(cfg, promptSvc, log)
+	if err != nil {
+		log.Fatal("Failed to init LLM client", zap.Error(err))
+	}
+
 	userService, err := auth.NewUserService(appDB, appDriver)
 	if err != nil {
 		log.Fatal("Failed to init user service", zap.Error(err))
@@ -96,11 +116,48 @@ func main() {
 		log.Fatal("Failed to init template store", zap.Error(err))
 	}
 	templateSvc := templates.NewService(templateStore)
+	glossaryStore, err := glossary.NewStore(appDB, appDriver)
+	if err != nil {
+		log.Fatal("Failed to init glossary store", zap.Error(err))
+	}
+	glossarySvc := glossary.NewService(glossaryStore)
+	examplesStore, err := examples.NewStore(appDB, appDriver)
+	if err != nil {
+		log.Fatal("Failed to init examples store", zap.Error(err))
+	}
+	examplesSvc := examples.NewService(examplesStore)
+	feedbackStore, err := feedback.NewStore(appDB, appDriver)
+	if err != nil {
+		log.Fatal("Failed to init feedback store", zap.Error(err))
+	}
 	progressStore := progress.NewStore()
+	resultCache := results.NewCache(time.Duration(cfg.ResultCacheTTLMin)*time.Minute, cfg.ResultCacheMaxEntries)
 	monitorSvc, err := monitor.New(appDB, appDriver, cfg, log)
 	if err != nil {
 		log.Fatal("Failed to init monitor service", zap.Error(err))
 	}
+	llmClient.OnAttempt(func(attempt llm.Attempt) {
+		extra := map[string]interface{}{
+			"operation": attempt.Operation,
+			"provider":  attempt.Provider,
+			"model":     attempt.Model,
+			"attempt":   attempt.Number,
+			"fallback":  attempt.Fallback,
+		}
+		if attempt.StatusCode != 0 {
+			extra["status_code"] = attempt.StatusCode
+		}
+		if attempt.Err != nil {
+			extra["error"] = attempt.Err.Error()
+		}
+		monitorSvc.Record("llm_attempt", attempt.Duration, attempt.Err == nil, extra)
+	})
+	usageStore, err := usage.NewStore(appDB, appDriver)
+	if err != nil {
+		log.Fatal("Failed to init usage store", zap.Error(err))
+	}
+	usageSvc := usage.NewService(usageStore, cfg, log)
+	llmClient.SetMeter(usageSvc)
 
 	// Create Gin router
 	if cfg.Env == "production" {
@@ -110,7 +167,7 @@ func main() {
 	router := gin.Default()
 
 	// Setup API routes
-	api.SetupRoutes(router, dbClient, jwtManager, userService, sqlExecutor, llmClient, templateSvc, memoryStore, reportStore, chatStore, monitorSvc, progressStore, cfg, log)
+	api.SetupRoutes(router, dbClient, appDB, jwtManager, userService, sqlExecutor, llmClient, templateSvc, memoryStore, reportStore, chatStore, monitorSvc, usageSvc, glossarySvc, examplesSvc, feedbackStore, progressStore, resultCache, cfg, log)
 
 	// Start server in a goroutine
 	go func() {
@@ -134,21 +191,20 @@ func initAppDatabase(cfg *config.Config, log *zap.Logger) (*sql.DB, string, erro
 	if err != nil {
 		return nil, "", err
 	}
-	db, err := sql.Open(driver, dsn)
+	pool, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, "", err
 	}
-	if err := db.Ping(); err != nil {
+	if err := pool.Ping(); err != nil {
 		return nil, "", err
 	}
-	db.SetMaxOpenConns(20)
-	db.SetMaxIdleConns(5)
-	db.SetConnMaxLifetime(10 * time.Minute)
+	db.ApplyPoolConfig(pool, cfg.AppDBPool)
 
 	log.Info("App database connected",
-		zap.String("driver", driver))
+		zap.String("driver", driver),
+		zap.Int("max_open_conns", cfg.AppDBPool.MaxOpenConns))
 
-	return db, driver, nil
+	return pool, driver, nil
 }
 
 func ensureDefaultAdmin(cfg *config.Config, userService *auth.UserService, log *zap.Logger) error {
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// normalizeSQL makes formatting differences irrelevant for exact match:
+// case, whitespace and a trailing semicolon.
+func normalizeSQL(sql string) string {
+	sql = strings.TrimSpace(sql)
+	sql = strings.TrimSpace(strings.TrimSuffix(sql, ";"))
+	return strings.ToUpper(strings.Join(strings.Fields(sql), " "))
+}
+
+func exactMatch(expected, generated string) bool {
+	return expected != "" && normalizeSQL(expected) == normalizeSQL(generated)
+}
+
+// sameRows reports whether two results hold the same multiset of rows.
+// Column names and row order are ignored; values are compared as text with
+// numbers normalized so 10, 10.0 and "10" are equal.
+func sameRows(expected, actual [][]interface{}) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	a, b := rowKeys(expected), rowKeys(actual)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func rowKeys(rows [][]interface{}) []string {
+	keys := make([]string, len(rows))
+	for i, row := range rows {
+		values := make([]string, len(row))
+		for j, v := range row {
+			values[j] = normalizeValue(v)
+		}
+		keys[i] = strings.Join(values, "\x1f")
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func normalizeValue(v interface{}) string {
+	var text string
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		text = string(val)
+	case time.Time:
+		return val.Format(time.RFC3339)
+	case float64:
+		return formatNumber(val)
+	case float32:
+		return formatNumber(float64(val))
+	default:
+		text = fmt.Sprint(val)
+	}
+	text = strings.TrimSpace(text)
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return formatNumber(f)
+	}
+	return text
+}
+
+func formatNumber(f float64) string {
+	// Round away floating point noise from aggregates.
+	rounded := math.Round(f*1e6) / 1e6
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Case is one golden question. Either ExpectedSQL or ExpectedResult must be
+// set; when both are, ExpectedResult wins for execution comparison.
+type Case struct {
+	ID             string          `json:"id" yaml:"id"`
+	Question       string          `json:"question" yaml:"question"`
+	ExpectedSQL    string          `json:"expected_sql,omitempty" yaml:"expected_sql"`
+	ExpectedResult [][]interface{} `json:"expected_result,omitempty" yaml:"expected_result"`
+	Tables         string          `json:"tables,omitempty" yaml:"tables"` // optional table filter, comma-separated
+	Tags           []string        `json:"tags,omitempty" yaml:"tags"`
+}
+
+// loadDataset reads cases from a .yaml/.yml file (a list, or a map with a
+// "cases" list) or a .jsonl file (one case per line, # comments allowed).
+func loadDataset(path string) ([]Case, error) {
+	var cases []Case
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := yaml.Unmarshal(data, &cases); err != nil {
+			var wrapped struct {
+				Cases []Case `yaml:"cases"`
+			}
+			if err2 := yaml.Unmarshal(data, &wrapped); err2 != nil {
+				return nil, fmt.Errorf("parse %s: %w", path, err)
+			}
+			cases = wrapped.Cases
+		}
+	case ".jsonl":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for line := 1; scanner.Scan(); line++ {
+			text := strings.TrimSpace(scanner.Text())
+			if text == "" || strings.HasPrefix(text, "#") {
+				continue
+			}
+			var c Case
+			if err := json.Unmarshal([]byte(text), &c); err != nil {
+				return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+			}
+			cases = append(cases, c)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported dataset format %q (use .yaml, .yml or .jsonl)", filepath.Ext(path))
+	}
+
+	seen := make(map[string]bool, len(cases))
+	for i := range cases {
+		c := &cases[i]
+		c.Question = strings.TrimSpace(c.Question)
+		c.ExpectedSQL = strings.TrimSpace(c.ExpectedSQL)
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("case-%d", i+1)
+		}
+		if seen[c.ID] {
+			return nil, fmt.Errorf("duplicate case id %q", c.ID)
+		}
+		seen[c.ID] = true
+		if c.Question == "" {
+			return nil, fmt.Errorf("case %s: question is required", c.ID)
+		}
+		if c.ExpectedSQL == "" && c.ExpectedResult == nil {
+			return nil, fmt.Errorf("case %s: expected_sql or expected_result is required", c.ID)
+		}
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("%s contains no cases", path)
+	}
+	return cases, nil
+}
@@ -0,0 +1,286 @@
+// Command eval runs a golden NL-to-SQL dataset through the generation
+// pipeline against the configured LLM and database and writes an accuracy,
+// latency and token report as JSON and Markdown.
+//
+//	go run ./cmd/eval -dataset golden.yaml -out eval-report -baseline main.json -min-accuracy 0.8
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/sijms/go-ora/v2"
+
+	"github.com/yourusername/db_asst/config"
+	"github.com/yourusername/db_asst/internal/api"
+	"github.com/yourusername/db_asst/internal/db"
+	"github.com/yourusername/db_asst/internal/examples"
+	"github.com/yourusername/db_asst/internal/executor"
+	"github.com/yourusername/db_asst/internal/glossary"
+	"github.com/yourusername/db_asst/internal/llm"
+	"github.com/yourusername/db_asst/internal/logger"
+	"github.com/yourusername/db_asst/internal/models"
+	"github.com/yourusername/db_asst/internal/prompts"
+	"github.com/yourusername/db_asst/internal/templates"
+	"github.com/yourusername/db_asst/internal/usage"
+)
+
+const evalUser = "eval"
+
+func main() {
+	datasetPath := flag.String("dataset", "", "golden dataset (.yaml, .yml or .jsonl)")
+	out := flag.String("out", "eval-report", "output path prefix; writes <out>.json and <out>.md")
+	baselinePath := flag.String("baseline", "", "previous JSON report to compare against")
+	minAccuracy := flag.Float64("min-accuracy", 0, "exit with status 1 when accuracy is below this")
+	execute := flag.Bool("exec", true, "compare by executing expected and generated SQL")
+	maxRows := flag.Int("max-rows", 1000, "rows fetched per query for result comparison")
+	execTimeout := flag.Duration("exec-timeout", 30*time.Second, "timeout for each comparison query")
+	candidates := flag.Int("candidates", 0, "generate this many candidates per case and vote (0 disables)")
+	useAppDB := flag.Bool("use-app-db", false, "use prompt versions, templates, glossary and examples from the app database")
+	flag.Parse()
+	if *datasetPath == "" {
+		fmt.Fprintln(os.Stderr, "-dataset is required")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cases, err := loadDataset(*datasetPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Dataset error: %v\n", err)
+		os.Exit(2)
+	}
+	var baseline *Report
+	if *baselinePath != "" {
+		if baseline, err = loadReport(*baselinePath); err != nil {
+			fmt.Fprintf(os.Stderr, "Baseline error: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	cfg := config.LoadConfig()
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+		os.Exit(1)
+	}
+	log, err := logger.InitLogger(cfg.LogLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer log.Sync()
+
+	dbClient, err := db.Initialize(cfg, log)
+	if err != nil {
+		log.Fatal("Failed to initialize database", zap.Error(err))
+	}
+	defer dbClient.Close()
+	sqlExecutor := executor.New(dbClient, cfg, log)
+
+	var promptStore *prompts.Store
+	var templateSvc *templates.Service
+	var glossarySvc *glossary.Service
+	var examplesSvc *examples.Service
+	if *useAppDB {
+		appDB, appDriver, err := openAppDatabase(cfg)
+		if err != nil {
+			log.Fatal("Failed to init app database", zap.Error(err))
+		}
+		defer appDB.Close()
+		if promptStore, err = prompts.NewStore(appDB, appDriver); err != nil {
+			log.Fatal("Failed to init prompt store", zap.Error(err))
+		}
+		templateStore, err := templates.NewStore(appDB, appDriver)
+		if err != nil {
+			log.Fatal("Failed to init template store", zap.Error(err))
+		}
+		templateSvc = templates.NewService(templateStore)
+		glossaryStore, err := glossary.NewStore(appDB, appDriver)
+		if err != nil {
+			log.Fatal("Failed to init glossary store", zap.Error(err))
+		}
+		glossarySvc = glossary.NewService(glossaryStore)
+		examplesStore, err := examples.NewStore(appDB, appDriver)
+		if err != nil {
+			log.Fatal("Failed to init examples store", zap.Error(err))
+		}
+		examplesSvc = examples.NewService(examplesStore)
+	}
+	promptSvc, err := prompts.NewService(promptStore)
+	if err != nil {
+		log.Fatal("Failed to load prompt templates", zap.Error(err))
+	}
+	llmClient, err := llm.NewLLMClient(cfg, promptSvc, log)
+	if err != nil {
+		log.Fatal("Failed to init LLM client", zap.Error(err))
+	}
+	meter := newTokenMeter(usage.NewService(nil, cfg, log))
+	llmClient.SetMeter(meter)
+
+	handler := api.NewAPIHandler(dbClient, nil, nil, nil, sqlExecutor, llmClient, templateSvc, nil, nil, nil, nil, nil, glossarySvc, examplesSvc, nil, nil, nil, cfg, log)
+
+	caseTimeout := time.Duration(cfg.SQLGenerateTimeout) * time.Second
+	if caseTimeout <= 0 {
+		caseTimeout = 120 * time.Second
+	}
+
+	report := &Report{
+		Dataset:   *datasetPath,
+		Provider:  cfg.LLMProvider,
+		Model:     cfg.LLMModel,
+		StartedAt: time.Now(),
+	}
+	for i, c := range cases {
+		requestID := "eval-" + uuid.New().String()
+		identity := db.SessionIdentity{UserID: evalUser, Username: evalUser, RequestID: requestID, Action: "eval"}
+		ctx, cancel := context.WithTimeout(context.Background(), caseTimeout)
+		ctx = llm.WithCallScope(ctx, llm.CallScope{UserID: evalUser, Username: evalUser, RequestID: requestID})
+		ctx = db.WithSessionIdentity(ctx, identity)
+
+		result := CaseResult{ID: c.ID, Question: c.Question, Tags: c.Tags, ExpectedSQL: c.ExpectedSQL}
+		noClarify := false
+		req := &models.SQLGenerateRequest{
+			Query:              c.Question,
+			TableNames:         c.Tables,
+			RequestID:          requestID,
+			Candidates:         *candidates,
+			AllowClarification: &noClarify,
+		}
+		start := time.Now()
+		resp, err := handler.Generate(ctx, requestID, req)
+		result.LatencyMs = time.Since(start).Milliseconds()
+		cancel()
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+		case strings.TrimSpace(resp.SQL) == "":
+			result.Error = "no SQL generated"
+		default:
+			result.GeneratedSQL = resp.SQL
+			result.Source = resp.Source
+			result.PromptVersion = resp.PromptVersion
+			result.ExactMatch = exactMatch(c.ExpectedSQL, resp.SQL)
+		}
+		if *execute && result.GeneratedSQL != "" {
+			execCtx := db.WithSessionIdentity(context.Background(), identity)
+			match, truncated, execErr := compareExecution(execCtx, sqlExecutor, c, result.GeneratedSQL, *maxRows, *execTimeout)
+			result.ExecutionMatch = &match
+			result.Truncated = truncated
+			if execErr != nil {
+				result.Error = execErr.Error()
+			}
+		}
+		if result.ExecutionMatch != nil {
+			result.Correct = *result.ExecutionMatch
+		} else {
+			result.Correct = result.ExactMatch
+		}
+		tokens, cost := meter.take(requestID)
+		result.PromptTokens, result.CompletionTokens, result.CostUSD = tokens.PromptTokens, tokens.CompletionTokens, cost
+
+		status := "FAIL"
+		if result.Correct {
+			status = "ok"
+		}
+		fmt.Fprintf(os.Stderr, "[%d/%d] %-4s %s (%dms)\n", i+1, len(cases), status, c.ID, result.LatencyMs)
+		report.Cases = append(report.Cases, result)
+	}
+	report.DurationMs = time.Since(report.StartedAt).Milliseconds()
+	report.Summary = summarize(report.Cases)
+
+	if err := writeJSON(*out+".json", report); err != nil {
+		log.Fatal("Failed to write JSON report", zap.Error(err))
+	}
+	if err := os.WriteFile(*out+".md", []byte(markdown(report, baseline)), 0o644); err != nil {
+		log.Fatal("Failed to write Markdown report", zap.Error(err))
+	}
+	s := report.Summary
+	fmt.Printf("accuracy %.3f (%d/%d), exact %.3f, execution %.3f, p50 %dms, tokens %d\n",
+		s.Accuracy, s.Correct, s.Total, s.ExactMatchRate, s.ExecutionMatchRate, s.LatencyP50Ms, s.PromptTokens+s.CompletionTokens)
+	if s.Accuracy < *minAccuracy {
+		fmt.Fprintf(os.Stderr, "accuracy %.3f is below -min-accuracy %.3f\n", s.Accuracy, *minAccuracy)
+		os.Exit(1)
+	}
+}
+
+// compareExecution runs the generated SQL and compares its rows with the
+// expected result, or with the rows of the expected SQL. An error running
+// the generated SQL counts as a mismatch.
+func compareExecution(ctx context.Context, sqlExecutor *executor.SQLExecutor, c Case, generated string, maxRows int, timeout time.Duration) (bool, bool, error) {
+	expected := c.ExpectedResult
+	truncated := false
+	if expected == nil {
+		result, err := sqlExecutor.SampleSQL(ctx, c.ExpectedSQL, maxRows, timeout)
+		if err != nil {
+			return false, false, fmt.Errorf("expected SQL failed: %w", err)
+		}
+		expected = result.Rows
+		truncated = len(result.Rows) >= maxRows
+	}
+	actual, err := sqlExecutor.SampleSQL(ctx, generated, maxRows, timeout)
+	if err != nil {
+		return false, truncated, fmt.Errorf("generated SQL failed: %w", err)
+	}
+	truncated = truncated || len(actual.Rows) >= maxRows
+	return sameRows(expected, actual.Rows), truncated, nil
+}
+
+// tokenMeter collects usage per request ID; it never rejects a call.
+type tokenMeter struct {
+	pricing *usage.Service
+	mu      sync.Mutex
+	tokens  map[string]llm.Usage
+	cost    map[string]float64
+}
+
+func newTokenMeter(pricing *usage.Service) *tokenMeter {
+	return &tokenMeter{pricing: pricing, tokens: map[string]llm.Usage{}, cost: map[string]float64{}}
+}
+
+func (m *tokenMeter) Allow(context.Context, llm.CallScope) error { return nil }
+
+func (m *tokenMeter) Record(_ context.Context, rec llm.UsageRecord) {
+	cost, _ := m.pricing.Cost(rec.Model, rec.Usage)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u := m.tokens[rec.RequestID]
+	u.PromptTokens += rec.Usage.PromptTokens
+	u.CompletionTokens += rec.Usage.CompletionTokens
+	m.tokens[rec.RequestID] = u
+	m.cost[rec.RequestID] += cost
+}
+
+// take returns and forgets the usage recorded for requestID.
+func (m *tokenMeter) take(requestID string) (llm.Usage, float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u, cost := m.tokens[requestID], m.cost[requestID]
+	delete(m.tokens, requestID)
+	delete(m.cost, requestID)
+	return u, cost
+}
+
+func openAppDatabase(cfg *config.Config) (*sql.DB, string, error) {
+	driver, dsn, err := cfg.GetAppDBDSN()
+	if err != nil {
+		return nil, "", err
+	}
+	pool, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, "", err
+	}
+	if err := pool.Ping(); err != nil {
+		pool.Close()
+		return nil, "", err
+	}
+	return pool, driver, nil
+}
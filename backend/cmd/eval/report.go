@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CaseResult is the outcome of one case.
+type CaseResult struct {
+	ID               string   `json:"id"`
+	Question         string   `json:"question"`
+	Tags             []string `json:"tags,omitempty"`
+	ExpectedSQL      string   `json:"expected_sql,omitempty"`
+	GeneratedSQL     string   `json:"generated_sql"`
+	Source           string   `json:"source,omitempty"`
+	PromptVersion    string   `json:"prompt_version,omitempty"`
+	ExactMatch       bool     `json:"exact_match"`
+	ExecutionMatch   *bool    `json:"execution_match,omitempty"` // nil when results were not compared
+	Correct          bool     `json:"correct"`
+	Truncated        bool     `json:"truncated,omitempty"` // a result hit -max-rows
+	Error            string   `json:"error,omitempty"`
+	LatencyMs        int64    `json:"latency_ms"`
+	PromptTokens     int      `json:"prompt_tokens"`
+	CompletionTokens int      `json:"completion_tokens"`
+	CostUSD          float64  `json:"cost_usd"`
+}
+
+// Summary aggregates a run.
+type Summary struct {
+	Total              int     `json:"total"`
+	Correct            int     `json:"correct"`
+	Accuracy           float64 `json:"accuracy"`
+	ExactMatches       int     `json:"exact_matches"`
+	ExactMatchRate     float64 `json:"exact_match_rate"`
+	ExecutionCompared  int     `json:"execution_compared"`
+	ExecutionMatches   int     `json:"execution_matches"`
+	ExecutionMatchRate float64 `json:"execution_match_rate"`
+	Errors             int     `json:"errors"`
+	LatencyAvgMs       int64   `json:"latency_avg_ms"`
+	LatencyP50Ms       int64   `json:"latency_p50_ms"`
+	LatencyP95Ms       int64   `json:"latency_p95_ms"`
+	PromptTokens       int     `json:"prompt_tokens"`
+	CompletionTokens   int     `json:"completion_tokens"`
+	CostUSD            float64 `json:"cost_usd"`
+}
+
+// Report is the JSON written by the eval command and read back as a baseline.
+type Report struct {
+	Dataset    string       `json:"dataset"`
+	Provider   string       `json:"provider"`
+	Model      string       `json:"model"`
+	StartedAt  time.Time    `json:"started_at"`
+	DurationMs int64        `json:"duration_ms"`
+	Summary    Summary      `json:"summary"`
+	Cases      []CaseResult `json:"cases"`
+}
+
+func summarize(results []CaseResult) Summary {
+	s := Summary{Total: len(results)}
+	latencies := make([]int64, 0, len(results))
+	var latencySum int64
+	for _, r := range results {
+		if r.Correct {
+			s.Correct++
+		}
+		if r.ExactMatch {
+			s.ExactMatches++
+		}
+		if r.ExecutionMatch != nil {
+			s.ExecutionCompared++
+			if *r.ExecutionMatch {
+				s.ExecutionMatches++
+			}
+		}
+		if r.Error != "" {
+			s.Errors++
+		}
+		latencies = append(latencies, r.LatencyMs)
+		latencySum += r.LatencyMs
+		s.PromptTokens += r.PromptTokens
+		s.CompletionTokens += r.CompletionTokens
+		s.CostUSD += r.CostUSD
+	}
+	s.Accuracy = ratio(s.Correct, s.Total)
+	s.ExactMatchRate = ratio(s.ExactMatches, s.Total)
+	s.ExecutionMatchRate = ratio(s.ExecutionMatches, s.ExecutionCompared)
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		s.LatencyAvgMs = latencySum / int64(len(latencies))
+		s.LatencyP50Ms = percentile(latencies, 0.50)
+		s.LatencyP95Ms = percentile(latencies, 0.95)
+	}
+	s.CostUSD = math.Round(s.CostUSD*1e6) / 1e6
+	return s
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return math.Round(float64(n)/float64(d)*1000) / 1000
+}
+
+// percentile uses the nearest-rank method on sorted values.
+func percentile(sorted []int64, p float64) int64 {
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+func writeJSON(path string, report *Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+func loadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("parse baseline %s: %w", path, err)
+	}
+	return &report, nil
+}
+
+// markdown renders the report. With a baseline, summary metrics show their
+// change and cases whose correctness flipped are listed.
+func markdown(report *Report, baseline *Report) string {
+	var b strings.Builder
+	s := report.Summary
+	fmt.Fprintf(&b, "# NL-to-SQL evaluation\n\n")
+	fmt.Fprintf(&b, "- Dataset: `%s`\n- Model: `%s` (%s)\n- Started: %s\n- Duration: %.1fs\n\n",
+		report.Dataset, report.Model, report.Provider, report.StartedAt.Format(time.RFC3339), float64(report.DurationMs)/1000)
+
+	var bs *Summary
+	if baseline != nil {
+		bs = &baseline.Summary
+		fmt.Fprintf(&b, "Baseline: `%s` from %s\n\n", baseline.Model, baseline.StartedAt.Format(time.RFC3339))
+	}
+	b.WriteString("| Metric | Value |")
+	if bs != nil {
+		b.WriteString(" Baseline | Change |")
+	}
+	b.WriteString("\n|---|---|")
+	if bs != nil {
+		b.WriteString("---|---|")
+	}
+	b.WriteString("\n")
+	row := func(name string, value, base float64, format string) {
+		fmt.Fprintf(&b, "| %s | "+format+" |", name, value)
+		if bs != nil {
+			fmt.Fprintf(&b, " "+format+" | %+g |", base, math.Round((value-base)*1000)/1000)
+		}
+		b.WriteString("\n")
+	}
+	var zero Summary
+	if bs == nil {
+		bs = &zero
+	}
+	row("Accuracy", s.Accuracy, bs.Accuracy, "%.3f")
+	row("Exact match", s.ExactMatchRate, bs.ExactMatchRate, "%.3f")
+	row("Execution match", s.ExecutionMatchRate, bs.ExecutionMatchRate, "%.3f")
+	row("Errors", float64(s.Errors), float64(bs.Errors), "%.0f")
+	row("Latency avg (ms)", float64(s.LatencyAvgMs), float64(bs.LatencyAvgMs), "%.0f")
+	row("Latency p50 (ms)", float64(s.LatencyP50Ms), float64(bs.LatencyP50Ms), "%.0f")
+	row("Latency p95 (ms)", float64(s.LatencyP95Ms), float64(bs.LatencyP95Ms), "%.0f")
+	row("Prompt tokens", float64(s.PromptTokens), float64(bs.PromptTokens), "%.0f")
+	row("Completion tokens", float64(s.CompletionTokens), float64(bs.CompletionTokens), "%.0f")
+	row("Cost (USD)", s.CostUSD, bs.CostUSD, "%.4f")
+	fmt.Fprintf(&b, "\n%d of %d correct; %d of %d compared by execution.\n", s.Correct, s.Total, s.ExecutionMatches, s.ExecutionCompared)
+
+	if baseline != nil {
+		before := make(map[string]bool, len(baseline.Cases))
+		for _, c := range baseline.Cases {
+			before[c.ID] = c.Correct
+		}
+		var regressed, fixed []string
+		for _, c := range report.Cases {
+			was, ok := before[c.ID]
+			switch {
+			case !ok:
+			case was && !c.Correct:
+				regressed = append(regressed, c.ID)
+			case !was && c.Correct:
+				fixed = append(fixed, c.ID)
+			}
+		}
+		if len(regressed) > 0 {
+			fmt.Fprintf(&b, "\n**Regressed:** %s\n", strings.Join(regressed, ", "))
+		}
+		if len(fixed) > 0 {
+			fmt.Fprintf(&b, "\n**Fixed:** %s\n", strings.Join(fixed, ", "))
+		}
+	}
+
+	var failures []CaseResult
+	for _, c := range report.Cases {
+		if !c.Correct {
+			failures = append(failures, c)
+		}
+	}
+	if len(failures) > 0 {
+		b.WriteString("\n## Failures\n\n| Case | Exact | Execution | Latency (ms) | Error |\n|---|---|---|---|---|\n")
+		for _, c := range failures {
+			execution := "-"
+			if c.ExecutionMatch != nil {
+				execution = fmt.Sprint(*c.ExecutionMatch)
+			}
+			fmt.Fprintf(&b, "| %s | %t | %s | %d | %s |\n", c.ID, c.ExactMatch, execution, c.LatencyMs, markdownCell(c.Error))
+		}
+	}
+	return b.String()
+}
+
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	text = strings.Join(strings.Fields(text), " ")
+	if len([]rune(text)) > 120 {
+		text = string([]rune(text)[:120]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/yourusername/db_asst/internal/llm"
+)
+
+type messagesRequest struct {
+	Model    string          `json:"model"`
+	System   json.RawMessage `json:"system"`
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+	Stream      bool    `json:"stream"`
+	Temperature float64 `json:"temperature"`
+	MaxTokens   int     `json:"max_tokens"`
+	Tools       []struct {
+		Name        string                 `json:"name"`
+		Description string                 `json:"description"`
+		InputSchema map[string]interface{} `json:"input_schema"`
+	} `json:"tools"`
+	ToolChoice *struct {
+		Type string `json:"type"` // "auto", "any", "tool" or "none"
+		Name string `json:"name"`
+	} `json:"tool_choice"`
+}
+
+// contentBlock is one block of a Messages API message.
+type contentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"`
+}
+
+// completionRequest maps the wire request onto the provider's. Structured
+// output arrives as a single forced tool, so it maps onto ToolChoice too.
+func (req *messagesRequest) completionRequest() llm.CompletionRequest {
+	creq := llm.CompletionRequest{Temperature: req.Temperature, MaxTokens: req.MaxTokens}
+	if system := contentText(req.System); system != "" {
+		creq.Messages = append(creq.Messages, llm.Message{Role: "system", Content: system})
+	}
+	for _, m := range req.Messages {
+		creq.Messages = append(creq.Messages, anthropicMessages(m.Role, m.Content)...)
+	}
+	for _, tool := range req.Tools {
+		creq.Tools = append(creq.Tools, llm.Tool{Name: tool.Name, Description: tool.Description, Parameters: tool.InputSchema})
+	}
+	if req.ToolChoice != nil && req.ToolChoice.Type == "tool" {
+		creq.ToolChoice = req.ToolChoice.Name
+	}
+	return creq
+}
+
+// anthropicMessages splits one Messages API message into chat messages:
+// tool_use blocks become tool calls of the assistant message, and each
+// tool_result block a "tool" message ahead of the user's text.
+func anthropicMessages(role string, raw json.RawMessage) []llm.Message {
+	var blocks []contentBlock
+	if json.Unmarshal(raw, &blocks) != nil {
+		return []llm.Message{{Role: role, Content: contentText(raw)}}
+	}
+	var out []llm.Message
+	msg := llm.Message{Role: role}
+	var text []string
+	for _, b := range blocks {
+		switch b.Type {
+		case "tool_use":
+			msg.ToolCalls = append(msg.ToolCalls, llm.ToolCall{ID: b.ID, Name: b.Name, Arguments: string(b.Input)})
+		case "tool_result":
+			out = append(out, llm.Message{Role: "tool", ToolCallID: b.ToolUseID, Content: contentText(b.Content)})
+		default:
+			if b.Text != "" {
+				text = append(text, b.Text)
+			}
+		}
+	}
+	msg.Content = strings.Join(text, "\n")
+	if msg.Content != "" || len(msg.ToolCalls) > 0 || len(out) == 0 {
+		out = append(out, msg)
+	}
+	return out
+}
+
+// messages serves the Anthropic Messages API. Tool calls come back as
+// tool_use blocks; a forced tool, as structured output uses, gets the reply
+// as its input.
+func (s *server) messages(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req messagesRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeAnthropicError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	creq := req.completionRequest()
+	toolName := creq.ToolChoice
+	model := s.modelOf(req.Model)
+	id := fmt.Sprintf("msg_stub_%d", time.Now().UnixNano())
+
+	if !req.Stream {
+		resp, err := s.provider.Complete(r.Context(), creq)
+		if err != nil {
+			status, message := statusOf(err)
+			writeAnthropicError(w, status, errorType(status), message)
+			return
+		}
+		text := resp.Content
+		var uses []interface{}
+		for _, call := range resp.ToolCalls {
+			if !json.Valid([]byte(call.Arguments)) {
+				// A forced tool whose reply is not JSON answers in text.
+				text += call.Arguments
+				continue
+			}
+			uses = append(uses, map[string]interface{}{"type": "tool_use", "id": call.ID, "name": call.Name, "input": json.RawMessage(call.Arguments)})
+		}
+		var blocks []interface{}
+		if text != "" || len(uses) == 0 {
+			blocks = append(blocks, map[string]interface{}{"type": "text", "text": text})
+		}
+		blocks = append(blocks, uses...)
+		stopReason := "end_turn"
+		if len(uses) > 0 {
+			stopReason = "tool_use"
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"id":          id,
+			"type":        "message",
+			"role":        "assistant",
+			"model":       model,
+			"content":     blocks,
+			"stop_reason": stopReason,
+			"usage":       anthropicUsage(resp.Usage),
+		})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeAnthropicError(w, http.StatusInternalServerError, "api_error", "streaming unsupported")
+		return
+	}
+	started := false
+	send := func(event string, payload map[string]interface{}) {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusOK)
+		}
+		payload["type"] = event
+		data, _ := json.Marshal(payload)
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+		flusher.Flush()
+	}
+	inputTokens := 0
+	for _, m := range creq.Messages {
+		inputTokens += s.provider.CountTokens(m.Content)
+	}
+	block := map[string]interface{}{"type": "text", "text": ""}
+	deltaType, deltaField, stopReason := "text_delta", "text", "end_turn"
+	if toolName != "" {
+		block = map[string]interface{}{"type": "tool_use", "id": "toolu_stub", "name": toolName, "input": map[string]interface{}{}}
+		deltaType, deltaField, stopReason = "input_json_delta", "partial_json", "tool_use"
+	}
+	// The opening events go out with the first chunk so that a call failing
+	// up front still gets an HTTP error status.
+	open := func() {
+		send("message_start", map[string]interface{}{"message": map[string]interface{}{
+			"id": id, "type": "message", "role": "assistant", "model": model, "content": []interface{}{},
+			"usage": map[string]int{"input_tokens": inputTokens, "output_tokens": 0},
+		}})
+		send("content_block_start", map[string]interface{}{"index": 0, "content_block": block})
+	}
+	usage, err := s.provider.Stream(r.Context(), creq, func(chunk string) {
+		if !started {
+			open()
+		}
+		send("content_block_delta", map[string]interface{}{"index": 0, "delta": map[string]string{"type": deltaType, deltaField: chunk}})
+	})
+	if err != nil {
+		status, message := statusOf(err)
+		if !started {
+			writeAnthropicError(w, status, errorType(status), message)
+			return
+		}
+		send("error", map[string]interface{}{"error": map[string]string{"type": errorType(status), "message": message}})
+		return
+	}
+	if !started {
+		open()
+	}
+	send("content_block_stop", map[string]interface{}{"index": 0})
+	send("message_delta", map[string]interface{}{
+		"delta": map[string]string{"stop_reason": stopReason},
+		"usage": map[string]int{"output_tokens": usage.CompletionTokens},
+	})
+	send("message_stop", map[string]interface{}{})
+}
+
+// contentText flattens a string or a list of content blocks to its text.
+func contentText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		return text
+	}
+	var blocks []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if json.Unmarshal(raw, &blocks) != nil {
+		return ""
+	}
+	parts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Text != "" {
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// errorType is the Anthropic error type for an HTTP status.
+func errorType(status int) string {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case status == 529 || status == http.StatusServiceUnavailable:
+		return "overloaded_error"
+	case status >= 400 && status < 500:
+		return "invalid_request_error"
+	default:
+		return "api_error"
+	}
+}
+
+func anthropicUsage(u llm.Usage) map[string]int {
+	return map[string]int{"input_tokens": u.PromptTokens, "output_tokens": u.CompletionTokens}
+}
+
+func writeAnthropicError(w http.ResponseWriter, status int, errType, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"type":  "error",
+		"error": map[string]string{"type": errType, "message": message},
+	})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+// interaction is one recorded upstream exchange.
+type interaction struct {
+	Key         string          `json:"key"`
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	Request     json.RawMessage `json:"request,omitempty"`
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type"`
+	Body        string          `json:"body"`
+	RecordedAt  time.Time       `json:"recorded_at"`
+}
+
+// cassette stores interactions keyed by method, endpoint and request body.
+type cassette struct {
+	path  string
+	mu    sync.Mutex
+	items map[string]*interaction
+	order []string
+}
+
+func loadCassette(path string, mustExist bool) (*cassette, error) {
+	c := &cassette{path: path, items: make(map[string]*interaction)}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) && !mustExist {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Interactions []*interaction `json:"interactions"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	for _, it := range file.Interactions {
+		if _, ok := c.items[it.Key]; !ok {
+			c.order = append(c.order, it.Key)
+		}
+		c.items[it.Key] = it
+	}
+	return c, nil
+}
+
+func (c *cassette) get(key string) *interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.items[key]
+}
+
+// put stores it, replacing an earlier recording of the same request, and
+// rewrites the cassette file.
+func (c *cassette) put(it *interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[it.Key]; !ok {
+		c.order = append(c.order, it.Key)
+	}
+	c.items[it.Key] = it
+	list := make([]*interaction, len(c.order))
+	for i, key := range c.order {
+		list[i] = c.items[key]
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{"interactions": list}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, append(data, '\n'), 0o644)
+}
+
+// requestKey identifies a request independent of JSON key order and
+// formatting. Bodies that are not JSON are hashed as is.
+func requestKey(method, endpoint string, body []byte) string {
+	canonical := body
+	var v interface{}
+	if len(body) > 0 && json.Unmarshal(body, &v) == nil {
+		canonical, _ = json.Marshal(v) // maps marshal with sorted keys
+	}
+	sum := sha256.Sum256(append([]byte(method+" "+endpoint+"\n"), canonical...))
+	return hex.EncodeToString(sum[:12])
+}
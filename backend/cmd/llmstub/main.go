@@ -0,0 +1,255 @@
+// Command llmstub is a local stand-in for OpenAI-compatible and Anthropic
+// APIs, so the real HTTP providers can be exercised without network access.
+// It serves /chat/completions (with and without streaming), /messages,
+// /embeddings and /models, with or without a /v1 prefix. Tool definitions,
+// tool choice, tool calls and tool results are mapped onto the provider in
+// both wire formats.
+//
+// Modes:
+//
+//	fixtures  answer from LLM_MOCK_FIXTURES-style scripted replies (default)
+//	record    proxy to -upstream and save every exchange to -cassette
+//	replay    answer from -cassette; misses fall back to fixtures unless -strict
+//
+// Point the server at it with LLM_BASE_URL=http://localhost:8089/v1.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/llm"
+	"github.com/yourusername/db_asst/internal/logger"
+)
+
+// forwardedHeaders are passed to the upstream in record mode.
+var forwardedHeaders = []string{"Authorization", "X-Api-Key", "Api-Key", "Anthropic-Version", "Anthropic-Beta", "Content-Type", "Accept"}
+
+type server struct {
+	provider llm.Provider
+	cassette *cassette
+	mode     string
+	strict   bool
+	upstream string
+	model    string
+	client   *http.Client
+	log      *zap.Logger
+}
+
+func main() {
+	addr := flag.String("addr", ":8089", "listen address")
+	mode := flag.String("mode", "fixtures", "fixtures, record or replay")
+	fixtures := flag.String("fixtures", os.Getenv("LLM_MOCK_FIXTURES"), "scripted replies file (YAML or JSON)")
+	cassettePath := flag.String("cassette", "llmstub-cassette.json", "recorded exchanges for record/replay")
+	upstream := flag.String("upstream", "", "real API base URL for record mode, e.g. https://api.openai.com/v1")
+	strict := flag.Bool("strict", false, "in replay mode, fail requests that were not recorded")
+	model := flag.String("model", "llmstub", "model name reported when the request names none")
+	logLevel := flag.String("log-level", "info", "log level")
+	flag.Parse()
+
+	log, err := logger.InitLogger(*logLevel)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize logger: %v\n", err)
+		os.Exit(1)
+	}
+	defer log.Sync()
+
+	s := &server{mode: *mode, strict: *strict, upstream: strings.TrimRight(*upstream, "/"), model: *model, client: &http.Client{}, log: log}
+	switch s.mode {
+	case "fixtures":
+	case "record":
+		if s.upstream == "" {
+			log.Fatal("-upstream is required in record mode")
+		}
+		if s.cassette, err = loadCassette(*cassettePath, false); err != nil {
+			log.Fatal("Failed to load cassette", zap.Error(err))
+		}
+	case "replay":
+		if s.cassette, err = loadCassette(*cassettePath, true); err != nil {
+			log.Fatal("Failed to load cassette", zap.Error(err))
+		}
+	default:
+		log.Fatal("Unknown mode", zap.String("mode", s.mode))
+	}
+	if s.provider, err = llm.NewProvider("mock", llm.ProviderConfig{Model: *model, Fixtures: *fixtures}); err != nil {
+		log.Fatal("Failed to load fixtures", zap.Error(err))
+	}
+
+	log.Info("LLM stub listening", zap.String("address", *addr), zap.String("mode", s.mode))
+	if err := http.ListenAndServe(*addr, s); err != nil {
+		log.Fatal("Server error", zap.Error(err))
+	}
+}
+
+// endpointOf maps a request path to the API endpoint it addresses,
+// ignoring any prefix such as /v1.
+func endpointOf(path string) string {
+	path = strings.TrimRight(path, "/")
+	for _, endpoint := range []string{"/chat/completions", "/messages", "/embeddings", "/models"} {
+		if strings.HasSuffix(path, endpoint) {
+			return endpoint
+		}
+	}
+	return ""
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	endpoint := endpointOf(r.URL.Path)
+	if endpoint == "" {
+		writeOpenAIError(w, http.StatusNotFound, "not_found", "unknown endpoint "+r.URL.Path)
+		return
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	key := requestKey(r.Method, endpoint, body)
+	source := "fixtures"
+	defer func() {
+		s.log.Info("Request served",
+			zap.String("method", r.Method),
+			zap.String("endpoint", endpoint),
+			zap.String("source", source),
+			zap.String("key", key),
+			zap.Duration("duration", time.Since(start)))
+	}()
+
+	switch s.mode {
+	case "record":
+		source = "upstream"
+		s.record(w, r, endpoint, key, body)
+		return
+	case "replay":
+		if it := s.cassette.get(key); it != nil {
+			source = "cassette"
+			replay(w, it)
+			return
+		}
+		if s.strict {
+			source = "miss"
+			writeOpenAIError(w, http.StatusNotFound, "not_found", "no recording for this request (key "+key+")")
+			return
+		}
+	}
+
+	switch endpoint {
+	case "/chat/completions":
+		s.chatCompletions(w, r, body)
+	case "/messages":
+		s.messages(w, r, body)
+	case "/embeddings":
+		s.embeddings(w, r, body)
+	case "/models":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"object": "list",
+			"data":   []map[string]interface{}{{"id": s.model, "object": "model", "type": "model"}},
+		})
+	}
+}
+
+// record forwards the request upstream, streams the reply back as it
+// arrives and saves it. API keys travel in headers and are not recorded.
+func (s *server) record(w http.ResponseWriter, r *http.Request, endpoint, key string, body []byte) {
+	url := s.upstream + endpoint
+	if r.URL.RawQuery != "" {
+		url += "?" + r.URL.RawQuery
+	}
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, url, bytes.NewReader(body))
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadGateway, "upstream_error", err.Error())
+		return
+	}
+	for _, name := range forwardedHeaders {
+		if value := r.Header.Get(name); value != "" {
+			req.Header.Set(name, value)
+		}
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		writeOpenAIError(w, http.StatusBadGateway, "upstream_error", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+
+	contentType := resp.Header.Get("Content-Type")
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(resp.StatusCode)
+	flusher, _ := w.(http.Flusher)
+	var recorded bytes.Buffer
+	buf := make([]byte, 4096)
+	for {
+		n, readErr := resp.Body.Read(buf)
+		if n > 0 {
+			recorded.Write(buf[:n])
+			_, _ = w.Write(buf[:n])
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if readErr != nil {
+			if !errors.Is(readErr, io.EOF) {
+				s.log.Warn("Upstream read failed; not recording", zap.Error(readErr))
+				return
+			}
+			break
+		}
+	}
+
+	it := &interaction{
+		Key:         key,
+		Method:      r.Method,
+		Path:        endpoint,
+		Status:      resp.StatusCode,
+		ContentType: contentType,
+		Body:        recorded.String(),
+		RecordedAt:  time.Now(),
+	}
+	if len(body) > 0 && json.Valid(body) {
+		it.Request = body
+	}
+	if err := s.cassette.put(it); err != nil {
+		s.log.Error("Failed to save cassette", zap.Error(err))
+	}
+}
+
+// replay writes a recorded reply. Event streams are flushed line by line.
+func replay(w http.ResponseWriter, it *interaction) {
+	w.Header().Set("Content-Type", it.ContentType)
+	w.WriteHeader(it.Status)
+	flusher, ok := w.(http.Flusher)
+	if !ok || !strings.HasPrefix(it.ContentType, "text/event-stream") {
+		_, _ = io.WriteString(w, it.Body)
+		return
+	}
+	scanner := bufio.NewScanner(strings.NewReader(it.Body))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		_, _ = io.WriteString(w, scanner.Text()+"\n")
+		if scanner.Text() == "" {
+			flusher.Flush()
+		}
+	}
+	flusher.Flush()
+}
+
+// statusOf maps a scripted provider error to the HTTP status to return.
+func statusOf(err error) (int, string) {
+	var apiErr *llm.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode, apiErr.Body
+	}
+	return http.StatusInternalServerError, err.Error()
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/config"
+	"github.com/yourusername/db_asst/internal/llm"
+	"github.com/yourusername/db_asst/internal/models"
+	"github.com/yourusername/db_asst/internal/prompts"
+)
+
+// newStubClients starts the stub in fixtures mode and returns an LLM client
+// per wire format pointed at it.
+func newStubClients(t *testing.T) map[string]*llm.LLMClient {
+	t.Helper()
+	provider, err := llm.NewProvider("mock", llm.ProviderConfig{Model: "llmstub", Fixtures: "../../testdata/mock-fixtures.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stub := httptest.NewServer(&server{mode: "fixtures", provider: provider, model: "llmstub", client: &http.Client{}, log: zap.NewNop()})
+	t.Cleanup(stub.Close)
+	promptSvc, err := prompts.NewService(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients := make(map[string]*llm.LLMClient)
+	for _, name := range []string{"openai", "anthropic"} {
+		t.Setenv("LLM_PROVIDER", name)
+		t.Setenv("LLM_BASE_URL", stub.URL+"/v1")
+		t.Setenv("LLM_API_KEY", "test")
+		t.Setenv("LLM_MODEL", "gpt-4o")
+		t.Setenv("LLM_RETRY_BASE_MS", "1")
+		t.Setenv("LLM_RETRY_MAX_MS", "5")
+		client, err := llm.NewLLMClient(config.LoadConfig(), promptSvc, zap.NewNop())
+		if err != nil {
+			t.Fatal(err)
+		}
+		clients[name] = client
+	}
+	return clients
+}
+
+// TestFixturesThroughProviders points the real HTTP providers at the stub in
+// fixtures mode and generates SQL, plain and streamed.
+func TestFixturesThroughProviders(t *testing.T) {
+	clients := newStubClients(t)
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			resp, err := client.GenerateSQL(ctx, &models.SQLGenerateRequest{Query: "How many employees in each department?"}, llm.GenerationContext{})
+			if err != nil {
+				t.Fatalf("GenerateSQL: %v", err)
+			}
+			if !strings.Contains(resp.SQL, "GROUP BY d.DEPARTMENT_NAME") {
+				t.Errorf("SQL = %q", resp.SQL)
+			}
+
+			var streamed strings.Builder
+			resp, err = client.GenerateSQLStream(ctx, &models.SQLGenerateRequest{Query: "Top 5 products by revenue"}, llm.GenerationContext{}, func(chunk string) {
+				streamed.WriteString(chunk)
+			})
+			if err != nil {
+				t.Fatalf("GenerateSQLStream: %v", err)
+			}
+			if !strings.HasSuffix(resp.SQL, "FETCH FIRST 5 ROWS ONLY") || streamed.String() != resp.SQL {
+				t.Errorf("SQL = %q, streamed %q", resp.SQL, streamed.String())
+			}
+		})
+	}
+}
+
+// TestAgentThroughProviders runs the SQL agent over both wire formats: a
+// scripted tool call, its result sent back, and a forced submit_sql.
+func TestAgentThroughProviders(t *testing.T) {
+	clients := newStubClients(t)
+	columns := llm.AgentTool{
+		Tool: llm.Tool{Name: "get_table_columns", Parameters: map[string]interface{}{"type": "object"}},
+		Run: func(ctx context.Context, arguments string) (string, error) {
+			if !strings.Contains(arguments, "EMPLOYEES") {
+				return "", fmt.Errorf("unexpected arguments %s", arguments)
+			}
+			return "EMPLOYEE_ID NUMBER, SALARY NUMBER", nil
+		},
+	}
+	req := &models.SQLGenerateRequest{Query: "What is the average salary?"}
+	want := "SELECT AVG(SALARY) AS AVG_SALARY FROM EMPLOYEES"
+
+	for name, client := range clients {
+		t.Run(name, func(t *testing.T) {
+			for _, maxSteps := range []int{4, 1} {
+				var steps []models.AgentStep
+				resp, err := client.GenerateSQLAgent(context.Background(), req, llm.GenerationContext{}, []llm.AgentTool{columns}, maxSteps, func(step models.AgentStep) {
+					steps = append(steps, step)
+				})
+				if err != nil {
+					t.Fatalf("GenerateSQLAgent(%d steps): %v", maxSteps, err)
+				}
+				if len(steps) != 1 || steps[0].Tool != "get_table_columns" || steps[0].Error != "" {
+					t.Errorf("%d steps: steps = %+v, want one successful get_table_columns call", maxSteps, steps)
+				}
+				if resp.SQL != want {
+					t.Errorf("%d steps: SQL = %q, want %q", maxSteps, resp.SQL, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/yourusername/db_asst/internal/llm"
+)
+
+type chatCompletionRequest struct {
+	Model               string        `json:"model"`
+	Messages            []chatMessage `json:"messages"`
+	Stream              bool          `json:"stream"`
+	Temperature         float64       `json:"temperature"`
+	MaxTokens           int           `json:"max_tokens"`
+	MaxCompletionTokens int           `json:"max_completion_tokens"` // sent instead of max_tokens for reasoning models
+	ResponseFormat      *struct {
+		Type       string `json:"type"`
+		JSONSchema *struct {
+			Name string `json:"name"`
+		} `json:"json_schema"`
+	} `json:"response_format"`
+	Tools []struct {
+		Function struct {
+			Name        string                 `json:"name"`
+			Description string                 `json:"description"`
+			Parameters  map[string]interface{} `json:"parameters"`
+		} `json:"function"`
+	} `json:"tools"`
+	// ToolChoice is "auto", "none", "required" or a named function.
+	ToolChoice json.RawMessage `json:"tool_choice"`
+}
+
+// chatMessage is a chat completions message, which may carry tool calls or
+// answer one.
+type chatMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content"`
+	ToolCalls  []chatToolCall  `json:"tool_calls"`
+	ToolCallID string          `json:"tool_call_id"`
+}
+
+type chatToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// completionRequest maps the wire request onto the provider's.
+func (req *chatCompletionRequest) completionRequest() llm.CompletionRequest {
+	creq := llm.CompletionRequest{Temperature: req.Temperature, MaxTokens: req.MaxTokens}
+	if creq.MaxTokens == 0 {
+		creq.MaxTokens = req.MaxCompletionTokens
+	}
+	for _, m := range req.Messages {
+		msg := llm.Message{Role: m.Role, Content: contentText(m.Content), ToolCallID: m.ToolCallID}
+		for _, call := range m.ToolCalls {
+			msg.ToolCalls = append(msg.ToolCalls, llm.ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments})
+		}
+		creq.Messages = append(creq.Messages, msg)
+	}
+	if rf := req.ResponseFormat; rf != nil && rf.Type != "" && rf.Type != "text" {
+		creq.Schema = &llm.JSONSchema{Name: "response"}
+		if rf.JSONSchema != nil && rf.JSONSchema.Name != "" {
+			creq.Schema.Name = rf.JSONSchema.Name
+		}
+	}
+	for _, tool := range req.Tools {
+		creq.Tools = append(creq.Tools, llm.Tool{Name: tool.Function.Name, Description: tool.Function.Description, Parameters: tool.Function.Parameters})
+	}
+	var choice struct {
+		Function struct {
+			Name string `json:"name"`
+		} `json:"function"`
+	}
+	if json.Unmarshal(req.ToolChoice, &choice) == nil {
+		creq.ToolChoice = choice.Function.Name
+	}
+	return creq
+}
+
+// chatToolCalls is the wire form of the provider's tool calls.
+func chatToolCalls(calls []llm.ToolCall) []chatToolCall {
+	out := make([]chatToolCall, len(calls))
+	for i, call := range calls {
+		out[i].ID = call.ID
+		out[i].Type = "function"
+		out[i].Function.Name = call.Name
+		out[i].Function.Arguments = call.Arguments
+	}
+	return out
+}
+
+func (s *server) chatCompletions(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req chatCompletionRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	creq := req.completionRequest()
+	model := s.modelOf(req.Model)
+	id := fmt.Sprintf("chatcmpl-stub-%d", time.Now().UnixNano())
+	created := time.Now().Unix()
+
+	if !req.Stream {
+		resp, err := s.provider.Complete(r.Context(), creq)
+		if err != nil {
+			status, message := statusOf(err)
+			writeOpenAIError(w, status, "api_error", message)
+			return
+		}
+		message := map[string]interface{}{"role": "assistant", "content": resp.Content}
+		finishReason := "stop"
+		if len(resp.ToolCalls) > 0 {
+			message["tool_calls"] = chatToolCalls(resp.ToolCalls)
+			if resp.Content == "" {
+				message["content"] = nil
+			}
+			finishReason = "tool_calls"
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"id":      id,
+			"object":  "chat.completion",
+			"created": created,
+			"model":   model,
+			"choices": []map[string]interface{}{{
+				"index":         0,
+				"message":       message,
+				"finish_reason": finishReason,
+			}},
+			"usage": openAIUsage(resp.Usage),
+		})
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeOpenAIError(w, http.StatusInternalServerError, "api_error", "streaming unsupported")
+		return
+	}
+	started := false
+	send := func(payload interface{}) {
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusOK)
+		}
+		data, _ := json.Marshal(payload)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		flusher.Flush()
+	}
+	chunk := func(choices []map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{"id": id, "object": "chat.completion.chunk", "created": created, "model": model, "choices": choices}
+	}
+	usage, err := s.provider.Stream(r.Context(), creq, func(content string) {
+		send(chunk([]map[string]interface{}{{"index": 0, "delta": map[string]string{"content": content}}}))
+	})
+	if err != nil {
+		if !started {
+			status, message := statusOf(err)
+			writeOpenAIError(w, status, "api_error", message)
+			return
+		}
+		// OpenAI has no error event; a failure mid-stream drops the connection.
+		panic(http.ErrAbortHandler)
+	}
+	send(chunk([]map[string]interface{}{{"index": 0, "delta": map[string]string{}, "finish_reason": "stop"}}))
+	final := chunk([]map[string]interface{}{})
+	final["usage"] = openAIUsage(usage)
+	send(final)
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	flusher.Flush()
+}
+
+func (s *server) embeddings(w http.ResponseWriter, r *http.Request, body []byte) {
+	var req struct {
+		Model string          `json:"model"`
+		Input json.RawMessage `json:"input"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+	var inputs []string
+	if err := json.Unmarshal(req.Input, &inputs); err != nil {
+		var single string
+		if err := json.Unmarshal(req.Input, &single); err != nil {
+			writeOpenAIError(w, http.StatusBadRequest, "invalid_request_error", "input must be a string or an array of strings")
+			return
+		}
+		inputs = []string{single}
+	}
+	vectors, err := s.provider.Embed(r.Context(), inputs)
+	if err != nil {
+		status, message := statusOf(err)
+		writeOpenAIError(w, status, "api_error", message)
+		return
+	}
+	data := make([]map[string]interface{}, len(vectors))
+	tokens := 0
+	for i, vec := range vectors {
+		data[i] = map[string]interface{}{"object": "embedding", "index": i, "embedding": vec}
+		tokens += s.provider.CountTokens(inputs[i])
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"object": "list",
+		"data":   data,
+		"model":  s.modelOf(req.Model),
+		"usage":  map[string]int{"prompt_tokens": tokens, "total_tokens": tokens},
+	})
+}
+
+func (s *server) modelOf(requested string) string {
+	if requested != "" {
+		return requested
+	}
+	return s.model
+}
+
+func openAIUsage(u llm.Usage) map[string]int {
+	return map[string]int{
+		"prompt_tokens":     u.PromptTokens,
+		"completion_tokens": u.CompletionTokens,
+		"total_tokens":      u.PromptTokens + u.CompletionTokens,
+	}
+}
+
+func writeOpenAIError(w http.ResponseWriter, status int, errType, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error": map[string]string{"message": message, "type": errType},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
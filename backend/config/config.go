@@ -2,17 +2,35 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// ModelPrice is the USD price per million prompt (input) and completion
+// (output) tokens.
+type ModelPrice struct {
+	InputPerMillion  float64
+	OutputPerMillion float64
+}
+
+// PoolConfig holds database/sql connection pool settings for one datasource.
+type PoolConfig struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
 type Config struct {
 	// Server
-	ServerPort int
-	Env        string
+	ServerPort    int
+	Env           string
+	DefaultLocale string // messages for requests without a profile locale or Accept-Language match
 
 	// Oracle Database
 	OracleUser     string
@@ -22,6 +40,21 @@ type Config struct {
 	OracleSID      string
 	OracleSchema   string
 
+	// DatasourceName selects datasource-specific prompt templates
+	DatasourceName string
+
+	// Oracle session identity (DB-side auditing)
+	OracleSessionTagging bool
+	OracleSessionModule  string
+	OracleProxyAuth      bool
+	OracleProxyUsers     map[string]string // app username -> Oracle proxy client user
+	OracleProxyDefault   string
+
+	// Connection pools
+	OraclePool      PoolConfig
+	OracleProxyPool PoolConfig // each Oracle proxy client gets its own pool
+	AppDBPool       PoolConfig
+
 	// App persistence database (memory, reports)
 	AppDBDriver       string
 	AppMySQLHost      string
@@ -44,11 +77,34 @@ type Config struct {
 	DefaultAdminEmail    string
 
 	// LLM Configuration
-	LLMProvider string // "openai", "claude", "custom"
-	LLMAPIKey   string
-	LLMModel    string
-	LLMBaseURL  string // For custom/proxy services
-	LLMTimeout  int    // seconds
+	LLMProvider       string // registered provider name: "openai", "deepseek", "custom", "claude", "azure", "ollama", "mock"
+	LLMAPIKey         string
+	LLMModel          string // model name, or deployment name for Azure OpenAI
+	LLMBaseURL        string // For custom/proxy services; Azure resource endpoint; Ollama host
+	LLMAPIVersion     string // Azure OpenAI api-version
+	LLMEmbeddingModel string
+	LLMTimeout        int    // seconds
+	LLMJSONMode       bool   // request native JSON/structured output for SQL generation
+	LLMMockFixtures   string // scripted replies for the "mock" provider
+
+	// LLM resilience: retries per backend, circuit breaker, optional fallback backend
+	LLMMaxRetries         int
+	LLMRetryBaseMs        int
+	LLMRetryMaxMs         int
+	LLMBreakerThreshold   int // consecutive failures before the circuit opens; 0 disables
+	LLMBreakerCooldownSec int
+	LLMFallbackProvider   string // empty reuses LLMProvider when LLMFallbackModel is set
+	LLMFallbackModel      string
+	LLMFallbackAPIKey     string
+	LLMFallbackBaseURL    string
+
+	// LLM cost accounting and budgets (USD; 0 disables a budget)
+	LLMPrices            map[string]ModelPrice // model (or model prefix) -> price per 1M tokens
+	UserTeams            map[string]string     // app username -> team
+	BudgetUserDailyUSD   float64
+	BudgetUserMonthlyUSD float64
+	BudgetTeamDailyUSD   float64
+	BudgetTeamMonthlyUSD float64
 
 	// SQL generation
 	SQLGenerateTimeout int // seconds
@@ -56,6 +112,47 @@ type Config struct {
 	SQLMaxPageSize     int
 	SensitiveColumns   []string
 
+	// Execute-and-repair loop for generated SQL
+	SQLAutoRepair       bool   // default for requests that do not set auto_repair
+	SQLRepairMaxRounds  int    // DebugSQL rounds after the first failed check
+	SQLRepairDryRun     string // "limit" (fetch one row) or "explain"
+	SQLRepairTimeoutSec int    // per dry run, also used for candidate sampling
+
+	// Multi-candidate generation with execution voting
+	SQLCandidateTemperatures []float64 // sampling temperatures, cycled when more candidates are requested
+	SQLCandidateMax          int
+	SQLCandidateSampleRows   int // rows fetched per candidate to compare results
+
+	// SQLFewShotExamples is how many similar verified examples go into the prompt; 0 disables
+	SQLFewShotExamples int
+
+	// SQLClarify lets generation return a clarifying question for underspecified requests
+	SQLClarify bool
+
+	// Agent mode: the LLM explores the schema with tools instead of receiving it up front
+	SQLAgent              bool // default for requests that do not set agent
+	SQLAgentMaxSteps      int  // model turns before the agent must submit
+	SQLAgentSampleRows    int  // row cap for sample_rows
+	SQLAgentQueryMaxRows  int  // row cap for run_readonly_query
+	SQLAgentSearchResults int  // tables returned by search_tables
+
+	// Ask endpoint
+	SQLSummaryMaxRows int // result rows passed to the LLM for summarization
+
+	// Chart recommendation for query results
+	ChartRecommend bool // attach a chart to ask results
+	ChartLLMRefine bool // let the LLM refine the heuristic choice from the question
+	ChartMaxPoints int  // rows inlined into the Vega-Lite spec
+
+	// Result cache for server-side transforms (group-by, pivot, sort, filter)
+	ResultCacheTTLMin     int // minutes an execution stays transformable
+	ResultCacheMaxEntries int // executions kept in memory; 0 disables the cache
+	ResultCacheMaxRows    int // rows buffered per execution, refetched when a page is not the whole result
+
+	// Conversation memory: an LLM-maintained rolling summary replaces older turns
+	MemorySummary         bool // summarize when the raw history exceeds the memory budget
+	MemorySummaryMaxChars int  // length the summary is asked to stay under, and cut to
+
 	// Schema filtering
 	SchemaExcludeTables   []string
 	SchemaExcludePrefixes []string
@@ -68,6 +165,13 @@ type Config struct {
 	// Audit & Logging
 	LogLevel string
 
+	// Health checks
+	HealthCriticalChecks   []string // dependency names that fail readiness: oracle, app_db, schema_catalog, llm
+	HealthCheckLLM         bool
+	HealthCheckTimeoutSec  int
+	HealthCatalogMaxAgeSec int
+	MetricsToken           string // bearer token required by /metrics; empty leaves it open
+
 	// Email alerts
 	EmailSMTPHost           string
 	EmailSMTPPort           int
@@ -91,8 +195,9 @@ func LoadConfig() *Config {
 
 	return &Config{
 		// Server
-		ServerPort: getEnvInt("SERVER_PORT", 8080),
-		Env:        getEnv("ENVIRONMENT", "development"),
+		ServerPort:    getEnvInt("SERVER_PORT", 8080),
+		Env:           getEnv("ENVIRONMENT", "development"),
+		DefaultLocale: getEnv("DEFAULT_LOCALE", "zh-CN"),
 
 		// Oracle Database - User needs to fill these
 		OracleUser:     oracleUser,
@@ -101,6 +206,19 @@ func LoadConfig() *Config {
 		OraclePort:     oraclePort,
 		OracleSID:      oracleSID,
 		OracleSchema:   strings.TrimSpace(getEnv("ORACLE_SCHEMA", "")),
+		DatasourceName: strings.TrimSpace(getEnv("DATASOURCE_NAME", "")),
+
+		// Session tagging / proxy authentication
+		OracleSessionTagging: getEnvBool("ORACLE_SESSION_TAGGING", true),
+		OracleSessionModule:  strings.TrimSpace(getEnv("ORACLE_SESSION_MODULE", "db_asst")),
+		OracleProxyAuth:      getEnvBool("ORACLE_PROXY_AUTH", false),
+		OracleProxyUsers:     getEnvMap("ORACLE_PROXY_USERS"),
+		OracleProxyDefault:   strings.TrimSpace(getEnv("ORACLE_PROXY_DEFAULT_CLIENT", "")),
+
+		// Connection pools
+		OraclePool:      getEnvPool("ORACLE_POOL", PoolConfig{MaxOpenConns: 25, MaxIdleConns: 5, ConnMaxLifetime: 5 * time.Minute}),
+		OracleProxyPool: getEnvPool("ORACLE_PROXY_POOL", PoolConfig{MaxOpenConns: 2, MaxIdleConns: 1, ConnMaxLifetime: 5 * time.Minute, ConnMaxIdleTime: 5 * time.Minute}),
+		AppDBPool:       getEnvPool("APP_DB_POOL", PoolConfig{MaxOpenConns: 20, MaxIdleConns: 5, ConnMaxLifetime: 10 * time.Minute}),
 
 		// Persistence database config
 		AppDBDriver:       strings.ToLower(strings.TrimSpace(getEnv("APP_DB_DRIVER", getEnv("PERSIST_DB_DRIVER", "mysql")))),
@@ -122,17 +240,59 @@ func LoadConfig() *Config {
 		DefaultAdminEmail:    strings.TrimSpace(getEnv("ADMIN_EMAIL", "")),
 
 		// LLM Configuration
-		LLMProvider:           strings.ToLower(strings.TrimSpace(getEnv("LLM_PROVIDER", "openai"))),
-		LLMAPIKey:             getEnv("LLM_API_KEY", ""),
-		LLMModel:              getEnv("LLM_MODEL", "gpt-3.5-turbo"),
-		LLMBaseURL:            getEnv("LLM_BASE_URL", "https://api.openai.com/v1"), // Can override for proxy
-		LLMTimeout:            getEnvInt("LLM_TIMEOUT", 120),
-		SQLGenerateTimeout:    getEnvInt("SQL_GENERATE_TIMEOUT", 120),
-		SQLDefaultPageSize:    getEnvInt("SQL_DEFAULT_PAGE_SIZE", 50),
-		SQLMaxPageSize:        getEnvInt("SQL_MAX_PAGE_SIZE", 200),
-		SensitiveColumns:      splitAndTrim(getEnv("SENSITIVE_COLUMNS", "")),
-		SchemaExcludeTables:   splitAndTrim(getEnv("SCHEMA_EXCLUDE_TABLES", "")),
-		SchemaExcludePrefixes: getEnvListWithDefault("SCHEMA_EXCLUDE_PREFIXES", []string{"sys_", "jeecg_", "act_", "qrtz_", "onl_", "log_"}),
+		LLMProvider:              strings.ToLower(strings.TrimSpace(getEnv("LLM_PROVIDER", "openai"))),
+		LLMAPIKey:                getEnv("LLM_API_KEY", ""),
+		LLMModel:                 getEnv("LLM_MODEL", "gpt-3.5-turbo"),
+		LLMBaseURL:               getEnv("LLM_BASE_URL", ""), // Empty uses the provider default; override for proxy
+		LLMAPIVersion:            getEnv("LLM_API_VERSION", ""),
+		LLMEmbeddingModel:        getEnv("LLM_EMBEDDING_MODEL", ""),
+		LLMTimeout:               getEnvInt("LLM_TIMEOUT", 120),
+		LLMJSONMode:              getEnvBool("LLM_JSON_MODE", true),
+		LLMMockFixtures:          getEnv("LLM_MOCK_FIXTURES", ""),
+		LLMMaxRetries:            getEnvInt("LLM_MAX_RETRIES", 2),
+		LLMRetryBaseMs:           getEnvInt("LLM_RETRY_BASE_MS", 500),
+		LLMRetryMaxMs:            getEnvInt("LLM_RETRY_MAX_MS", 8000),
+		LLMBreakerThreshold:      getEnvInt("LLM_BREAKER_THRESHOLD", 5),
+		LLMBreakerCooldownSec:    getEnvInt("LLM_BREAKER_COOLDOWN_SEC", 30),
+		LLMFallbackProvider:      strings.ToLower(strings.TrimSpace(getEnv("LLM_FALLBACK_PROVIDER", ""))),
+		LLMFallbackModel:         strings.TrimSpace(getEnv("LLM_FALLBACK_MODEL", "")),
+		LLMFallbackAPIKey:        getEnv("LLM_FALLBACK_API_KEY", ""),
+		LLMFallbackBaseURL:       getEnv("LLM_FALLBACK_BASE_URL", ""),
+		LLMPrices:                getEnvPrices("LLM_PRICES"),
+		UserTeams:                getEnvMap("USER_TEAMS"),
+		BudgetUserDailyUSD:       getEnvFloat("LLM_BUDGET_USER_DAILY_USD", 0),
+		BudgetUserMonthlyUSD:     getEnvFloat("LLM_BUDGET_USER_MONTHLY_USD", 0),
+		BudgetTeamDailyUSD:       getEnvFloat("LLM_BUDGET_TEAM_DAILY_USD", 0),
+		BudgetTeamMonthlyUSD:     getEnvFloat("LLM_BUDGET_TEAM_MONTHLY_USD", 0),
+		SQLGenerateTimeout:       getEnvInt("SQL_GENERATE_TIMEOUT", 120),
+		SQLDefaultPageSize:       getEnvInt("SQL_DEFAULT_PAGE_SIZE", 50),
+		SQLMaxPageSize:           getEnvInt("SQL_MAX_PAGE_SIZE", 200),
+		SensitiveColumns:         splitAndTrim(getEnv("SENSITIVE_COLUMNS", "")),
+		SQLAutoRepair:            getEnvBool("SQL_AUTO_REPAIR", false),
+		SQLRepairMaxRounds:       getEnvInt("SQL_REPAIR_MAX_ROUNDS", 2),
+		SQLRepairDryRun:          strings.ToLower(strings.TrimSpace(getEnv("SQL_REPAIR_DRY_RUN", "limit"))),
+		SQLRepairTimeoutSec:      getEnvInt("SQL_REPAIR_TIMEOUT_SEC", 10),
+		SQLCandidateTemperatures: getEnvFloats("SQL_CANDIDATE_TEMPERATURES", []float64{0.2, 0.7, 1.0}),
+		SQLCandidateMax:          getEnvInt("SQL_CANDIDATE_MAX", 5),
+		SQLCandidateSampleRows:   getEnvInt("SQL_CANDIDATE_SAMPLE_ROWS", 50),
+		SQLSummaryMaxRows:        getEnvInt("SQL_SUMMARY_MAX_ROWS", 30),
+		SQLClarify:               getEnvBool("SQL_CLARIFY", true),
+		SQLFewShotExamples:       getEnvInt("SQL_FEW_SHOT_EXAMPLES", 3),
+		SQLAgent:                 getEnvBool("SQL_AGENT", false),
+		SQLAgentMaxSteps:         getEnvInt("SQL_AGENT_MAX_STEPS", 8),
+		SQLAgentSampleRows:       getEnvInt("SQL_AGENT_SAMPLE_ROWS", 5),
+		SQLAgentQueryMaxRows:     getEnvInt("SQL_AGENT_QUERY_MAX_ROWS", 20),
+		SQLAgentSearchResults:    getEnvInt("SQL_AGENT_SEARCH_RESULTS", 20),
+		ChartRecommend:           getEnvBool("CHART_RECOMMEND", true),
+		ChartLLMRefine:           getEnvBool("CHART_LLM_REFINE", false),
+		ChartMaxPoints:           getEnvInt("CHART_MAX_POINTS", 1000),
+		ResultCacheTTLMin:        getEnvInt("RESULT_CACHE_TTL_MIN", 30),
+		ResultCacheMaxEntries:    getEnvInt("RESULT_CACHE_MAX_ENTRIES", 100),
+		ResultCacheMaxRows:       getEnvInt("RESULT_CACHE_MAX_ROWS", 10000),
+		MemorySummary:            getEnvBool("MEMORY_SUMMARY", true),
+		MemorySummaryMaxChars:    getEnvInt("MEMORY_SUMMARY_MAX_CHARS", 2000),
+		SchemaExcludeTables:      splitAndTrim(getEnv("SCHEMA_EXCLUDE_TABLES", "")),
+		SchemaExcludePrefixes:    getEnvListWithDefault("SCHEMA_EXCLUDE_PREFIXES", []string{"sys_", "jeecg_", "act_", "qrtz_", "onl_", "log_"}),
 
 		// Redis
 		RedisHost:     getEnv("REDIS_HOST", "localhost"),
@@ -142,6 +302,13 @@ func LoadConfig() *Config {
 		// Logging
 		LogLevel: getEnv("LOG_LEVEL", "info"),
 
+		// Health checks
+		HealthCriticalChecks:   getEnvListWithDefault("HEALTH_CRITICAL_CHECKS", []string{"oracle", "app_db"}),
+		HealthCheckLLM:         getEnvBool("HEALTH_CHECK_LLM", false),
+		HealthCheckTimeoutSec:  getEnvInt("HEALTH_CHECK_TIMEOUT_SEC", 5),
+		HealthCatalogMaxAgeSec: getEnvInt("HEALTH_CATALOG_MAX_AGE_SEC", 86400),
+		MetricsToken:           getEnv("METRICS_TOKEN", ""),
+
 		// Email
 		EmailSMTPHost:           getEnv("EMAIL_SMTP_HOST", ""),
 		EmailSMTPPort:           getEnvInt("EMAIL_SMTP_PORT", 587),
@@ -167,7 +334,7 @@ func (c *Config) Validate() error {
 	if c.OracleSID == "" {
 		return fmt.Errorf("ORACLE_SID is required")
 	}
-	if c.LLMAPIKey == "" {
+	if c.LLMAPIKey == "" && c.LLMProvider != "ollama" && c.LLMProvider != "mock" {
 		return fmt.Errorf("LLM_API_KEY is required")
 	}
 	if _, _, err := c.GetAppDBDSN(); err != nil {
@@ -187,6 +354,26 @@ func (c *Config) GetOracleConnStr() string {
 	)
 }
 
+// GetOracleProxyConnStr returns a connection string that authenticates as the
+// configured Oracle user and connects through it on behalf of clientUser
+// (ALTER USER clientUser GRANT CONNECT THROUGH oracleUser).
+func (c *Config) GetOracleProxyConnStr(clientUser string) string {
+	return fmt.Sprintf("%s&PROXY+CLIENT+NAME=%s", c.GetOracleConnStr(), url.QueryEscape(clientUser))
+}
+
+// ResolveOracleProxyClient maps an application username to the Oracle proxy
+// client user, falling back to ORACLE_PROXY_DEFAULT_CLIENT. An empty result means
+// the shared account should be used.
+func (c *Config) ResolveOracleProxyClient(username string) string {
+	if !c.OracleProxyAuth {
+		return ""
+	}
+	if client, ok := c.OracleProxyUsers[strings.ToLower(strings.TrimSpace(username))]; ok {
+		return client
+	}
+	return c.OracleProxyDefault
+}
+
 func (c *Config) GetOracleSchema() string {
 	if c.OracleSchema != "" {
 		return strings.ToUpper(c.OracleSchema)
@@ -194,6 +381,14 @@ func (c *Config) GetOracleSchema() string {
 	return strings.ToUpper(c.OracleUser)
 }
 
+// GetDatasourceName returns DATASOURCE_NAME, defaulting to the Oracle schema
+func (c *Config) GetDatasourceName() string {
+	if c.DatasourceName != "" {
+		return c.DatasourceName
+	}
+	return strings.ToLower(c.GetOracleSchema())
+}
+
 func (c *Config) GetAppDBDSN() (string, string, error) {
 	driver := strings.ToLower(strings.TrimSpace(c.AppDBDriver))
 	if driver == "" {
@@ -244,6 +439,91 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	valueStr := getEnv(key, "")
+	if value, err := strconv.ParseFloat(strings.TrimSpace(valueStr), 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvFloats parses a comma-separated list of numbers, ignoring invalid entries.
+func getEnvFloats(key string, defaultValue []float64) []float64 {
+	var result []float64
+	for _, item := range splitAndTrim(getEnv(key, "")) {
+		if value, err := strconv.ParseFloat(item, 64); err == nil {
+			result = append(result, value)
+		}
+	}
+	if len(result) == 0 {
+		return defaultValue
+	}
+	return result
+}
+
+// getEnvPrices parses "model:input/output,model2:input/output" where prices
+// are USD per million prompt/completion tokens. The price follows the last
+// colon so Ollama tags such as "llama3:8b" work as model names.
+func getEnvPrices(key string) map[string]ModelPrice {
+	result := make(map[string]ModelPrice)
+	for _, pair := range splitAndTrim(getEnv(key, "")) {
+		idx := strings.LastIndex(pair, ":")
+		if idx <= 0 {
+			continue
+		}
+		model := strings.ToLower(strings.TrimSpace(pair[:idx]))
+		parts := strings.SplitN(pair[idx+1:], "/", 2)
+		input, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err != nil {
+			continue
+		}
+		output := input
+		if len(parts) == 2 {
+			if output, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err != nil {
+				continue
+			}
+		}
+		result[model] = ModelPrice{InputPerMillion: input, OutputPerMillion: output}
+	}
+	return result
+}
+
+// getEnvPool reads <prefix>_MAX_OPEN, <prefix>_MAX_IDLE, <prefix>_MAX_LIFETIME_SEC
+// and <prefix>_MAX_IDLE_TIME_SEC, keeping defaults for unset values.
+func getEnvPool(prefix string, defaults PoolConfig) PoolConfig {
+	return PoolConfig{
+		MaxOpenConns:    getEnvInt(prefix+"_MAX_OPEN", defaults.MaxOpenConns),
+		MaxIdleConns:    getEnvInt(prefix+"_MAX_IDLE", defaults.MaxIdleConns),
+		ConnMaxLifetime: time.Duration(getEnvInt(prefix+"_MAX_LIFETIME_SEC", int(defaults.ConnMaxLifetime.Seconds()))) * time.Second,
+		ConnMaxIdleTime: time.Duration(getEnvInt(prefix+"_MAX_IDLE_TIME_SEC", int(defaults.ConnMaxIdleTime.Seconds()))) * time.Second,
+	}
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	valueStr := strings.TrimSpace(getEnv(key, ""))
+	if value, err := strconv.ParseBool(valueStr); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvMap parses "key:value,key2:value2" pairs; keys are lower-cased.
+func getEnvMap(key string) map[string]string {
+	result := make(map[string]string)
+	for _, pair := range splitAndTrim(getEnv(key, "")) {
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		k := strings.ToLower(strings.TrimSpace(parts[0]))
+		v := strings.TrimSpace(parts[1])
+		if k != "" && v != "" {
+			result[k] = v
+		}
+	}
+	return result
+}
+
 func getEnvListWithDefault(key string, defaultValue []string) []string {
 	raw := getEnv(key, "")
 	if strings.TrimSpace(raw) == "" {
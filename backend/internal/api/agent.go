@@ -0,0 +1,317 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/db"
+	"github.com/yourusername/db_asst/internal/llm"
+	"github.com/yourusername/db_asst/internal/models"
+	"github.com/yourusername/db_asst/internal/sqlparse"
+)
+
+// tableNamePattern accepts plain and schema-qualified Oracle identifiers, so
+// table names from the model can be put into SQL text.
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_$#]*(\.[A-Za-z][A-Za-z0-9_$#]*)?$`)
+
+// agentEnabled reports whether req is generated in agent mode: requested (or
+// on by SQL_AGENT) and supported by the configured LLM provider.
+func (h *APIHandler) agentEnabled(req *models.SQLGenerateRequest) bool {
+	if h.llmClient == nil || h.dbClient == nil || h.sqlExecutor == nil {
+		return false
+	}
+	enabled := h.cfg != nil && h.cfg.SQLAgent
+	if req.Agent != nil {
+		enabled = *req.Agent
+	}
+	if enabled && !h.llmClient.SupportsTools() {
+		h.logger.Debug("Agent mode requested but the LLM provider cannot call tools",
+			zap.String("provider", h.llmClient.Provider().Name()))
+		return false
+	}
+	return enabled
+}
+
+// generationSchema builds the schema context for the prompt. In agent mode
+// only the tables the user selected are loaded; the agent finds the rest.
+func (h *APIHandler) generationSchema(ctx context.Context, tableNames string, agent bool) string {
+	if agent && strings.TrimSpace(tableNames) == "" {
+		return ""
+	}
+	schemaContext, err := h.getDatabaseSchemaContext(ctx, tableNames)
+	if err != nil {
+		h.logger.Warn("Failed to get schema context", zap.Error(err))
+		schemaContext = "Error retrieving schema"
+	}
+	return schemaContext
+}
+
+// generateWithAgent runs agent-mode generation, reporting each tool call as
+// a progress event and to onStep (may be nil). It also returns the schema
+// context for repair and guidance: the selected tables plus every table
+// whose columns the agent looked up.
+func (h *APIHandler) generateWithAgent(ctx context.Context, identity db.SessionIdentity, req *models.SQLGenerateRequest, gc llm.GenerationContext, progress func(stage, message string), onStep func(models.AgentStep)) (*models.SQLGenerateResponse, string, error) {
+	maxSteps := 8
+	if h.cfg != nil && h.cfg.SQLAgentMaxSteps > 0 {
+		maxSteps = h.cfg.SQLAgentMaxSteps
+	}
+	box := newAgentToolbox(h, identity)
+	progress("agent_start", tr(ctx, "LLM is exploring the database schema"))
+	resp, err := h.llmClient.GenerateSQLAgent(ctx, req, gc, box.tools(), maxSteps, func(step models.AgentStep) {
+		message := tr(ctx, "Step %d: %s %s", step.Step, step.Tool, compactArguments(step.Arguments))
+		if step.Error != "" {
+			message = tr(ctx, "Step %d: %s %s (failed: %s)", step.Step, step.Tool, compactArguments(step.Arguments), step.Error)
+		}
+		progress("tool_call", message)
+		if onStep != nil {
+			onStep(step)
+		}
+	})
+	schemaContext := strings.TrimSpace(gc.Schema + "\n" + box.schemaContext())
+	return resp, schemaContext, err
+}
+
+// agentToolbox implements the agent's tools for one request against the
+// business database. Tool calls of one request run sequentially.
+type agentToolbox struct {
+	h        *APIHandler
+	identity db.SessionIdentity
+	// described keeps the column listings returned, in order, for repair.
+	described []string
+	seen      map[string]bool
+}
+
+func newAgentToolbox(h *APIHandler, identity db.SessionIdentity) *agentToolbox {
+	identity.Action = "agent_tool"
+	return &agentToolbox{h: h, identity: identity, seen: make(map[string]bool)}
+}
+
+func (t *agentToolbox) tools() []llm.AgentTool {
+	sampleRows, queryRows := t.limits()
+	object := func(properties map[string]interface{}, required ...string) map[string]interface{} {
+		return map[string]interface{}{"type": "object", "properties": properties, "required": required}
+	}
+	str := func(description string) map[string]interface{} {
+		return map[string]interface{}{"type": "string", "description": description}
+	}
+	return []llm.AgentTool{
+		{
+			Tool: llm.Tool{
+				Name:        "search_tables",
+				Description: "Find tables whose name or comment contains any of the keywords. Returns table names with comments.",
+				Parameters: object(map[string]interface{}{
+					"keywords": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Words to look for, e.g. [\"ORDER\", \"订单\"]. Empty lists all tables.",
+					},
+				}, "keywords"),
+			},
+			Run: t.searchTables,
+		},
+		{
+			Tool: llm.Tool{
+				Name:        "get_table_columns",
+				Description: "List a table's columns with data types, primary key and index flags and comments.",
+				Parameters:  object(map[string]interface{}{"table": str("Table name")}, "table"),
+			},
+			Run: t.getTableColumns,
+		},
+		{
+			Tool: llm.Tool{
+				Name:        "sample_rows",
+				Description: fmt.Sprintf("Show up to %d rows of a table. Sensitive columns are masked.", sampleRows),
+				Parameters:  object(map[string]interface{}{"table": str("Table name")}, "table"),
+			},
+			Run: t.sampleRows,
+		},
+		{
+			Tool: llm.Tool{
+				Name:        "run_readonly_query",
+				Description: fmt.Sprintf("Run one Oracle SELECT and show up to %d rows, e.g. to check a join or the distinct values of a column. Sensitive columns are masked.", queryRows),
+				Parameters:  object(map[string]interface{}{"sql": str("A single SELECT statement")}, "sql"),
+			},
+			Run: t.runQuery,
+		},
+	}
+}
+
+func (t *agentToolbox) limits() (sampleRows, queryRows int) {
+	sampleRows, queryRows = 5, 20
+	if cfg := t.h.cfg; cfg != nil {
+		if cfg.SQLAgentSampleRows > 0 {
+			sampleRows = cfg.SQLAgentSampleRows
+		}
+		if cfg.SQLAgentQueryMaxRows > 0 {
+			queryRows = cfg.SQLAgentQueryMaxRows
+		}
+	}
+	return sampleRows, queryRows
+}
+
+func (t *agentToolbox) queryTimeout() time.Duration {
+	if t.h.cfg != nil && t.h.cfg.SQLRepairTimeoutSec > 0 {
+		return time.Duration(t.h.cfg.SQLRepairTimeoutSec) * time.Second
+	}
+	return 10 * time.Second
+}
+
+func (t *agentToolbox) searchTables(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		Keywords []string `json:"keywords"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	limit := 20
+	if t.h.cfg != nil && t.h.cfg.SQLAgentSearchResults > 0 {
+		limit = t.h.cfg.SQLAgentSearchResults
+	}
+	// Excluded tables are dropped afterwards, so ask for extra.
+	tables, err := t.h.dbClient.SearchTables(ctx, args.Keywords, limit*3)
+	if err != nil {
+		return "", err
+	}
+	var builder strings.Builder
+	shown := 0
+	for _, table := range tables {
+		if t.h.isExcludedTable(table.TableName) {
+			continue
+		}
+		if shown == limit {
+			builder.WriteString("... more tables match; use more specific keywords\n")
+			break
+		}
+		builder.WriteString(table.TableName)
+		if table.Comment != "" {
+			builder.WriteString(" - " + table.Comment)
+		}
+		builder.WriteString("\n")
+		shown++
+	}
+	if shown == 0 {
+		return "No tables match. Try other keywords, in English or Chinese.", nil
+	}
+	return builder.String(), nil
+}
+
+func (t *agentToolbox) getTableColumns(ctx context.Context, arguments string) (string, error) {
+	table, err := t.tableArgument(arguments)
+	if err != nil {
+		return "", err
+	}
+	schema, err := t.h.dbClient.GetTableSchema(ctx, table)
+	if err != nil {
+		return "", err
+	}
+	if len(schema.Columns) == 0 {
+		return "", fmt.Errorf("table %s not found or has no visible columns", table)
+	}
+	var builder strings.Builder
+	builder.WriteString("Table: " + schema.TableName + "\n")
+	if schema.Comment != "" {
+		builder.WriteString("Comment: " + schema.Comment + "\n")
+	}
+	builder.WriteString("Columns:\n")
+	for _, col := range schema.Columns {
+		builder.WriteString("  - " + col.ColumnName + " (" + col.DataType + ")")
+		if col.IsPrimaryKey {
+			builder.WriteString(" PK")
+		} else if col.IsIndex {
+			builder.WriteString(" indexed")
+		}
+		if col.Comment != "" {
+			builder.WriteString(" - " + col.Comment)
+		}
+		builder.WriteString("\n")
+	}
+	if !t.seen[schema.TableName] {
+		t.seen[schema.TableName] = true
+		t.described = append(t.described, builder.String())
+	}
+	return builder.String(), nil
+}
+
+func (t *agentToolbox) sampleRows(ctx context.Context, arguments string) (string, error) {
+	table, err := t.tableArgument(arguments)
+	if err != nil {
+		return "", err
+	}
+	sampleRows, _ := t.limits()
+	return t.preview(ctx, "SELECT * FROM "+table, sampleRows)
+}
+
+func (t *agentToolbox) runQuery(ctx context.Context, arguments string) (string, error) {
+	var args struct {
+		SQL string `json:"sql"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(args.SQL) == "" {
+		return "", fmt.Errorf("sql is required")
+	}
+	// The other tools only take table names the exclusion list is checked
+	// against; here the tables are whatever the query reads.
+	for _, ref := range sqlparse.Parse(args.SQL).Tables {
+		name := strings.ReplaceAll(ref.Name, `"`, "")
+		if t.h.isExcludedTable(name) || t.h.isExcludedTable(name[strings.LastIndex(name, ".")+1:]) {
+			return "", fmt.Errorf("table %s is not available", name)
+		}
+	}
+	_, queryRows := t.limits()
+	return t.preview(ctx, args.SQL, queryRows)
+}
+
+func (t *agentToolbox) preview(ctx context.Context, sql string, limit int) (string, error) {
+	dbCtx := db.WithSessionIdentity(ctx, t.identity)
+	result, err := t.h.sqlExecutor.PreviewSQL(dbCtx, sql, limit, t.queryTimeout())
+	if err != nil {
+		return "", err
+	}
+	table, shown := llm.FormatResultTable(result, limit)
+	if result.HasMore || len(result.Rows) > shown {
+		table += fmt.Sprintf("\n(first %d rows only)", shown)
+	}
+	return table, nil
+}
+
+func (t *agentToolbox) tableArgument(arguments string) (string, error) {
+	var args struct {
+		Table string `json:"table"`
+	}
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	table := strings.ToUpper(strings.TrimSpace(args.Table))
+	if !tableNamePattern.MatchString(table) {
+		return "", fmt.Errorf("invalid table name %q", args.Table)
+	}
+	if t.h.isExcludedTable(table) {
+		return "", fmt.Errorf("table %s is not available", table)
+	}
+	return table, nil
+}
+
+// schemaContext joins the column listings the agent fetched.
+func (t *agentToolbox) schemaContext() string {
+	if len(t.described) == 0 {
+		return ""
+	}
+	return "Tables inspected by the agent:\n\n" + strings.Join(t.described, "\n")
+}
+
+// compactArguments shortens tool arguments for progress messages.
+func compactArguments(arguments string) string {
+	arguments = strings.Join(strings.Fields(arguments), " ")
+	if runes := []rune(arguments); len(runes) > 120 {
+		arguments = string(runes[:120]) + "…"
+	}
+	return arguments
+}
@@ -0,0 +1,26 @@
+package api
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRunQueryRejectsExcludedTables(t *testing.T) {
+	h := &APIHandler{excludeTables: map[string]struct{}{"SECRETS": {}}, excludePrefixes: []string{"AUDIT_"}}
+	box := &agentToolbox{h: h}
+	for _, sql := range []string{
+		"SELECT * FROM secrets",
+		"select s.* from hr.secrets s",
+		`SELECT * FROM "HR"."SECRETS"`,
+		"select (select max(v) from secrets) from dual",
+		"select x from t where id in (select id from audit_log)",
+		"with c as (select * from secrets) select * from c",
+		"select a.x from a join secrets b on a.id = b.id",
+	} {
+		if _, err := box.runQuery(context.Background(), `{"sql":`+strconv.Quote(sql)+`}`); err == nil || !strings.Contains(err.Error(), "is not available") {
+			t.Errorf("%s: err = %v, want the table rejected", sql, err)
+		}
+	}
+}
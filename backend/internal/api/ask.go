@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/db"
+	"github.com/yourusername/db_asst/internal/i18n"
+	"github.com/yourusername/db_asst/internal/llm"
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+// askExecTimeout bounds execution plus summarization once SQL is generated.
+const askExecTimeout = 2 * time.Minute
+
+// AskQuestion generates SQL for a question, executes it with masking and
+// answers in natural language based on the result.
+func (h *APIHandler) AskQuestion(c *gin.Context) {
+	var req models.AskRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: err.Error(),
+		})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	sessionID := strings.TrimSpace(req.SessionID)
+	if sessionID == "" {
+		sessionID = userID
+	}
+	requestID := strings.TrimSpace(req.RequestID)
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	start := time.Now()
+	success := false
+	metricExtra := map[string]interface{}{"session_id": sessionID, "request_id": requestID}
+	defer func() {
+		h.recordMetric("ask_rest", start, success, metricExtra)
+	}()
+
+	h.saveChatMessage(userID, sessionID, "user", req.Query)
+	locale := requestLocale(c)
+	h.updateProgress(requestID, "received", i18n.T(locale, "Question received"))
+	resp, err := h.ask(i18n.WithLocale(context.Background(), locale), userID, c.GetString("username"), sessionID, requestID, &req, askHooks{
+		progress: func(stage, message string) { h.updateProgress(requestID, stage, message) },
+	})
+	if err != nil && resp == nil {
+		metricExtra["error"] = err.Error()
+		h.failProgress(locale, requestID, err.Error())
+		status := http.StatusInternalServerError
+		if errors.Is(err, llm.ErrBudgetExceeded) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, models.ErrorResponse{
+			Code:    status,
+			Message: localize(c, "Failed to answer question"),
+			Details: err.Error(),
+		})
+		return
+	}
+
+	message := i18n.T(locale, "Question answered")
+	if err != nil {
+		// The rows are still useful when only the summary failed.
+		metricExtra["error"] = err.Error()
+		message = i18n.T(locale, "Query executed, summary unavailable")
+	}
+	success = err == nil && (resp.Result == nil || resp.Result.Success)
+	h.completeProgress(requestID, i18n.T(locale, "Answer complete"))
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: message,
+		Data:    resp,
+	})
+}
+
+// askHooks lets the WebSocket stream each step of ask; every hook may be nil.
+type askHooks struct {
+	progress func(stage, message string)
+	sql      func(gen *models.SQLGenerateResponse)
+	rows     func(result *models.SQLExecuteResponse)
+	chunk    func(chunk string)
+}
+
+// ask runs generate, execute and summarize. A nil response means generation
+// failed; a response with an error means the summary failed after the rows
+// were fetched.
+func (h *APIHandler) ask(parent context.Context, userID, username, sessionID, requestID string, req *models.AskRequest, hooks askHooks) (*models.AskResponse, error) {
+	if hooks.progress == nil {
+		hooks.progress = func(string, string) {}
+	}
+	parent = withLLMScope(parent, userID, username, sessionID, requestID)
+	genCtx, cancel := context.WithTimeout(parent, h.generateTimeout)
+	defer cancel()
+
+	generated, err := h.runGeneration(genCtx, userID, username, sessionID, requestID, &req.SQLGenerateRequest, generationHooks{progress: hooks.progress})
+	if err != nil {
+		return nil, err
+	}
+	gen := generated.resp
+	resp := &models.AskResponse{RequestID: requestID, Generation: gen}
+	if !generated.hasSQL() {
+		resp.Summary = generated.reply
+		return resp, nil
+	}
+	if hooks.sql != nil {
+		hooks.sql(gen)
+	}
+
+	ctx, cancelExec := context.WithTimeout(context.WithoutCancel(parent), askExecTimeout)
+	defer cancelExec()
+	hooks.progress("execute", tr(parent, "Executing SQL"))
+	execCtx := db.WithSessionIdentity(ctx, db.SessionIdentity{UserID: userID, Username: username, RequestID: requestID, Action: "ask_execute"})
+	result, err := h.sqlExecutor.ExecuteSQL(execCtx, models.SQLExecuteRequest{SQL: gen.SQL, PageSize: req.PageSize, RequestID: requestID})
+	if result == nil {
+		result = &models.SQLExecuteResponse{Success: false}
+		if err != nil {
+			result.Error = err.Error()
+		}
+	}
+	h.logAuditTrail(userID, "ASK_SQL", gen.SQL, result.Success, result.Error)
+	resp.Result = result
+	if result.Success {
+		result.ExecutionID = h.cacheResult(execCtx, userID, gen.SQL, result)
+	}
+	if result.Success && h.cfg != nil && h.cfg.ChartRecommend {
+		refine := h.cfg.ChartLLMRefine
+		if refine {
+			hooks.progress("chart", tr(parent, "Recommending a chart"))
+		}
+		result.Chart = h.recommendChart(ctx, req.Query, gen.SQL, result, refine)
+	}
+	if hooks.rows != nil {
+		hooks.rows(result)
+	}
+	if !result.Success {
+		resp.Summary = tr(parent, "SQL execution failed: %s", result.Error)
+		h.saveChatMessage(userID, sessionID, "assistant", formatSQLChatMessage(i18n.FromContext(parent), gen)+"\n\n"+resp.Summary)
+		return resp, nil
+	}
+
+	hooks.progress("summarize", tr(parent, "Summarizing the result"))
+	maxRows := 30
+	if h.cfg != nil && h.cfg.SQLSummaryMaxRows > 0 {
+		maxRows = h.cfg.SQLSummaryMaxRows
+	}
+	summary, version, err := h.llmClient.SummarizeResult(ctx, req.Query, gen.SQL, result, maxRows, hooks.chunk)
+	resp.Summary = summary
+	resp.PromptVersion = version
+	if err != nil {
+		h.logger.Warn("Failed to summarize result", zap.String("request_id", requestID), zap.Error(err))
+		return resp, err
+	}
+	h.saveChatMessage(userID, sessionID, "assistant", summary+"\n\n"+formatSQLChatMessage(i18n.FromContext(parent), gen))
+	return resp, nil
+}
+
+func (h *APIHandler) handleWebSocketAsk(conn *websocket.Conn, locale, userID, username string, req *models.AskRequest) {
+	start := time.Now()
+	success := false
+	metricExtra := map[string]interface{}{"session_id": req.SessionID, "request_id": req.RequestID}
+	defer func() {
+		h.recordMetric("ask_ws", start, success, metricExtra)
+	}()
+
+	h.writeWSProgress(conn, "received", i18n.T(locale, "Question received"))
+	h.saveChatMessage(userID, req.SessionID, "user", req.Query)
+	resp, err := h.ask(i18n.WithLocale(context.Background(), locale), userID, username, req.SessionID, req.RequestID, req, askHooks{
+		progress: func(stage, message string) { h.writeWSProgress(conn, stage, message) },
+		sql: func(gen *models.SQLGenerateResponse) {
+			_ = conn.WriteJSON(wsMessage{Type: "sql", SQL: gen.SQL, Reasoning: gen.Reasoning, Result: gen})
+		},
+		rows: func(result *models.SQLExecuteResponse) {
+			_ = conn.WriteJSON(wsMessage{Type: "rows", Rows: result})
+		},
+		chunk: func(chunk string) {
+			_ = conn.WriteJSON(wsMessage{Type: "summary_chunk", Chunk: chunk})
+		},
+	})
+	if err != nil {
+		metricExtra["error"] = err.Error()
+		if resp == nil {
+			h.writeWSError(conn, err.Error())
+			return
+		}
+	}
+	success = err == nil && (resp.Result == nil || resp.Result.Success)
+	_ = conn.WriteJSON(wsMessage{
+		Type:      "complete",
+		SQL:       resp.Generation.SQL,
+		Reasoning: resp.Generation.Reasoning,
+		Summary:   resp.Summary,
+		Error:     errorString(err),
+		Result:    resp.Generation,
+	})
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
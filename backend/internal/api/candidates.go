@@ -0,0 +1,219 @@
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/db_asst/internal/db"
+	"github.com/yourusername/db_asst/internal/llm"
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+// candidateCount returns how many generations the request asks to vote on,
+// 0 when multi-candidate generation is off.
+func (h *APIHandler) candidateCount(req *models.SQLGenerateRequest) int {
+	if req.Candidates <= 1 || h.sqlExecutor == nil || h.llmClient == nil {
+		return 0
+	}
+	limit := 5
+	if h.cfg != nil && h.cfg.SQLCandidateMax > 1 {
+		limit = h.cfg.SQLCandidateMax
+	}
+	if req.Candidates > limit {
+		return limit
+	}
+	return req.Candidates
+}
+
+type sampledCandidate struct {
+	resp      *models.SQLGenerateResponse
+	candidate models.SQLCandidate
+}
+
+// generateWithVoting samples n generations at different temperatures, runs
+// each with a small row limit and groups them by result signature. The
+// representative of the largest group is returned with the other groups'
+// representatives (and failed candidates) as alternates. Only when every
+// generation fails is an error returned.
+func (h *APIHandler) generateWithVoting(ctx context.Context, identity db.SessionIdentity, req *models.SQLGenerateRequest, gc llm.GenerationContext, n int, progress func(stage, message string)) (*models.SQLGenerateResponse, error) {
+	temperatures := []float64{0.2, 0.7, 1.0}
+	sampleRows := 50
+	timeout := 10 * time.Second
+	if h.cfg != nil {
+		if len(h.cfg.SQLCandidateTemperatures) > 0 {
+			temperatures = h.cfg.SQLCandidateTemperatures
+		}
+		if h.cfg.SQLCandidateSampleRows > 0 {
+			sampleRows = h.cfg.SQLCandidateSampleRows
+		}
+		if h.cfg.SQLRepairTimeoutSec > 0 {
+			timeout = time.Duration(h.cfg.SQLRepairTimeoutSec) * time.Second
+		}
+	}
+	temps := make([]float64, n)
+	for i := range temps {
+		temps[i] = temperatures[i%len(temperatures)]
+	}
+
+	progress("llm_call", tr(ctx, "LLM is generating %d candidate SQL statements", n))
+	results, errs := h.llmClient.GenerateSQLCandidates(ctx, req, gc, temps)
+
+	identity.Action = "candidate_sample"
+	dbCtx := db.WithSessionIdentity(ctx, identity)
+	progress("candidate_vote", tr(ctx, "Sampling candidates and voting"))
+
+	type sample struct {
+		signature string
+		rowCount  int
+		err       string
+	}
+	samples := make(map[string]sample) // identical SQL is executed once
+	var sampled []sampledCandidate
+	var firstErr error
+	for i, resp := range results {
+		if resp == nil {
+			if firstErr == nil {
+				firstErr = errs[i]
+			}
+			continue
+		}
+		cand := models.SQLCandidate{
+			SQL:         resp.SQL,
+			Explanation: resp.Explanation,
+			Confidence:  resp.Confidence,
+			Temperature: temps[i],
+		}
+		if h.needsGuidance(resp.SQL) {
+			cand.Error = "no SQL generated"
+		} else {
+			key := strings.Join(strings.Fields(strings.ToUpper(resp.SQL)), " ")
+			s, ok := samples[key]
+			if !ok {
+				result, err := h.sampleCandidate(dbCtx, resp.SQL, sampleRows, timeout)
+				if err != nil {
+					s.err = err.Error()
+				} else {
+					s.signature = resultSignature(result)
+					s.rowCount = len(result.Rows)
+				}
+				samples[key] = s
+			}
+			cand.Signature, cand.RowCount, cand.Error = s.signature, s.rowCount, s.err
+		}
+		sampled = append(sampled, sampledCandidate{resp: resp, candidate: cand})
+	}
+	if len(sampled) == 0 {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("no SQL candidates generated")
+		}
+		return nil, firstErr
+	}
+
+	// Empty results are not agreement: wrong queries easily return no rows
+	// together. Each empty candidate votes only for itself.
+	votes := make(map[string]int)
+	for _, s := range sampled {
+		if agrees(s.candidate) {
+			votes[s.candidate.Signature]++
+		}
+	}
+	for i := range sampled {
+		switch c := &sampled[i].candidate; {
+		case agrees(*c):
+			c.Votes = votes[c.Signature]
+		case c.Signature != "":
+			c.Votes = 1
+		}
+	}
+	// Most votes first, then non-empty results, then higher confidence; the
+	// sort is stable so lower temperatures win remaining ties.
+	sort.SliceStable(sampled, func(i, j int) bool {
+		a, b := sampled[i].candidate, sampled[j].candidate
+		if a.Votes != b.Votes {
+			return a.Votes > b.Votes
+		}
+		if agrees(a) != agrees(b) {
+			return agrees(a)
+		}
+		return confidenceOf(a) > confidenceOf(b)
+	})
+
+	winner := sampled[0]
+	resp := winner.resp
+	resp.Votes = winner.candidate.Votes
+	resp.Validated = winner.candidate.Signature != ""
+	seen := map[string]bool{winner.candidate.SQL: true}
+	if agrees(winner.candidate) {
+		seen[winner.candidate.Signature] = true
+	}
+	for _, s := range sampled[1:] {
+		if seen[s.candidate.SQL] || (agrees(s.candidate) && seen[s.candidate.Signature]) {
+			continue
+		}
+		seen[s.candidate.SQL] = true
+		if agrees(s.candidate) {
+			seen[s.candidate.Signature] = true
+		}
+		resp.Alternates = append(resp.Alternates, s.candidate)
+	}
+	if resp.Validated {
+		resp.Reasoning = strings.TrimSpace(resp.Reasoning + "\n" + tr(ctx, "(%d of %d candidates agreed)", resp.Votes, n))
+	}
+	return resp, nil
+}
+
+// agrees reports whether c's result can count as agreement with others:
+// it ran and returned rows.
+func agrees(c models.SQLCandidate) bool {
+	return c.Signature != "" && c.RowCount > 0
+}
+
+// sampleCandidate fetches up to limit rows of sql. When the result has more
+// rows, it is fetched again ordered by every column so that equivalent
+// queries without ORDER BY sample the same rows. If the ordered query fails
+// (e.g. LOB columns cannot be sorted) the unordered sample is kept.
+func (h *APIHandler) sampleCandidate(ctx context.Context, sql string, limit int, timeout time.Duration) (*models.SQLExecuteResponse, error) {
+	result, err := h.sqlExecutor.SampleSQL(ctx, sql, limit, timeout)
+	if err != nil || !result.HasMore || len(result.Columns) == 0 {
+		return result, err
+	}
+	positions := make([]string, len(result.Columns))
+	for i := range positions {
+		positions[i] = strconv.Itoa(i + 1)
+	}
+	ordered := fmt.Sprintf("SELECT * FROM (%s) ORDER BY %s",
+		strings.TrimSuffix(strings.TrimSpace(sql), ";"), strings.Join(positions, ", "))
+	if sorted, err := h.sqlExecutor.SampleSQL(ctx, ordered, limit, timeout); err == nil {
+		return sorted, nil
+	}
+	return result, nil
+}
+
+func confidenceOf(c models.SQLCandidate) float64 {
+	if c.Confidence == nil {
+		return 0
+	}
+	return *c.Confidence
+}
+
+// resultSignature hashes the sampled rows independent of column aliases and
+// row order, so equivalent queries written differently compare equal.
+func resultSignature(result *models.SQLExecuteResponse) string {
+	rows := make([]string, len(result.Rows))
+	for i, row := range result.Rows {
+		values := make([]string, len(row))
+		for j, v := range row {
+			values[j] = fmt.Sprint(v)
+		}
+		rows[i] = strings.Join(values, "\x1f")
+	}
+	sort.Strings(rows)
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d\x1e%s", len(result.Columns), strings.Join(rows, "\x1e"))))
+	return hex.EncodeToString(sum[:8])
+}
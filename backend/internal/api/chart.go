@@ -0,0 +1,125 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/charts"
+	"github.com/yourusername/db_asst/internal/llm"
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+// RecommendChart recommends a visualization for a result sent inline
+// (columns and rows) or produced by running sql.
+func (h *APIHandler) RecommendChart(c *gin.Context) {
+	start := time.Now()
+	success := false
+	extra := map[string]interface{}{}
+	defer func() {
+		h.recordMetric("chart_recommend", start, success, extra)
+	}()
+
+	var req models.ChartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		extra["error"] = err.Error()
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: err.Error(),
+		})
+		return
+	}
+	if len(req.Columns) == 0 && strings.TrimSpace(req.SQL) == "" {
+		extra["error"] = "no result"
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: "send columns and rows, or sql to run",
+		})
+		return
+	}
+
+	requestID := uuid.New().String()
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	ctx = withLLMScope(ctx, c.GetString("user_id"), c.GetString("username"), "", requestID)
+
+	result := &models.SQLExecuteResponse{Success: true, Columns: req.Columns, Rows: req.Rows}
+	if len(req.Columns) == 0 {
+		execCtx := withSessionIdentity(ctx, c, requestID, "chart")
+		var err error
+		result, err = h.sqlExecutor.ExecuteSQL(execCtx, models.SQLExecuteRequest{SQL: req.SQL, PageSize: h.chartMaxPoints(), RequestID: requestID})
+		if err == nil && !result.Success {
+			err = errors.New(result.Error)
+		}
+		h.logAuditTrail(c.GetString("user_id"), "CHART_SQL", req.SQL, err == nil, errorString(err))
+		if err != nil {
+			extra["error"] = err.Error()
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: localize(c, "Failed to execute SQL"),
+				Details: err.Error(),
+			})
+			return
+		}
+	}
+
+	refine := h.cfg != nil && h.cfg.ChartLLMRefine
+	if req.Refine != nil {
+		refine = *req.Refine
+	}
+	chart := h.recommendChart(ctx, req.Question, req.SQL, result, refine)
+	extra["chart"] = chart.Type
+	extra["source"] = chart.Source
+	success = true
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Chart recommended"),
+		Data:    chart,
+	})
+}
+
+// recommendChart suggests a chart for result from its columns and, when
+// refine is set, lets the LLM adjust the choice to the question. An LLM
+// choice that does not fit the result is dropped in favour of the heuristic.
+func (h *APIHandler) recommendChart(ctx context.Context, question, sql string, result *models.SQLExecuteResponse, refine bool) *models.ChartRecommendation {
+	rec := charts.Suggest(result)
+	if refine && h.llmClient != nil && len(result.Rows) > 0 {
+		refined, err := h.llmClient.RecommendChart(ctx, question, sql, result, rec)
+		if err == nil {
+			err = charts.Validate(refined, rec.Columns)
+		}
+		if err != nil {
+			if errors.Is(err, llm.ErrBudgetExceeded) {
+				h.logger.Debug("Chart refinement skipped", zap.Error(err))
+			} else {
+				h.logger.Warn("LLM chart recommendation rejected, using heuristic", zap.Error(err))
+			}
+		} else {
+			refined.Columns = rec.Columns
+			if refined.Type == charts.Table {
+				refined.X, refined.Y, refined.Series = "", nil, ""
+			}
+			rec = refined
+		}
+	}
+	if rec.Source == "heuristic" && rec.Type != charts.Table && strings.TrimSpace(question) != "" {
+		rec.Title = strings.TrimSpace(question)
+	}
+	charts.Render(rec, result, h.chartMaxPoints())
+	return rec
+}
+
+func (h *APIHandler) chartMaxPoints() int {
+	if h.cfg != nil && h.cfg.ChartMaxPoints > 0 {
+		return h.cfg.ChartMaxPoints
+	}
+	return 1000
+}
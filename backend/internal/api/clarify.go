@@ -0,0 +1,82 @@
+package api
+
+import (
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/memory"
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+// Memory sources for the clarification round trip.
+const (
+	memorySourceClarification = "clarification"
+	memorySourceClarifyAnswer = "clarification_answer"
+)
+
+// prepareClarification resolves whether the model may ask a clarifying
+// question for req. When req answers a previous question, the answer is
+// stored in memory, the question is recovered from memory if the client did
+// not send it back, and asking again is disabled so the turn produces SQL.
+func (h *APIHandler) prepareClarification(userID, sessionID string, req *models.SQLGenerateRequest) {
+	allow := h.cfg == nil || h.cfg.SQLClarify
+	if req.AllowClarification != nil {
+		allow = *req.AllowClarification
+	}
+
+	answer := strings.TrimSpace(req.ClarificationAnswer)
+	if answer != "" {
+		allow = false
+		if strings.TrimSpace(req.ClarificationQuestion) == "" && h.memoryStore != nil {
+			recent := h.memoryStore.GetRecent(userID, sessionID, 4)
+			for i := len(recent) - 1; i >= 0; i-- {
+				if recent[i].Source == memorySourceClarification {
+					req.ClarificationQuestion = recent[i].Reasoning
+					break
+				}
+			}
+		}
+		h.appendMemoryEntry(userID, sessionID, memory.Entry{
+			Query:     answer,
+			Reasoning: req.ClarificationQuestion,
+			Source:    memorySourceClarifyAnswer,
+		})
+	}
+	req.AllowClarification = &allow
+}
+
+// recordClarification remembers the question asked so the next turn can
+// pair it with the user's answer.
+func (h *APIHandler) recordClarification(userID, sessionID, query string, resp *models.SQLGenerateResponse) {
+	if resp == nil || resp.Clarification == nil {
+		return
+	}
+	h.appendMemoryEntry(userID, sessionID, memory.Entry{
+		Query:     query,
+		Reasoning: resp.Clarification.Question,
+		Source:    memorySourceClarification,
+	})
+	h.saveChatMessage(userID, sessionID, "assistant", formatClarificationMessage(resp.Clarification))
+}
+
+// appendMemoryEntry returns the new entry's ID, empty when it was not stored.
+func (h *APIHandler) appendMemoryEntry(userID, sessionID string, entry memory.Entry) string {
+	if h.memoryStore == nil {
+		return ""
+	}
+	id, err := h.memoryStore.Append(userID, sessionID, entry)
+	if err != nil {
+		h.logger.Warn("Failed to persist memory entry", zap.Error(err))
+		return ""
+	}
+	return id
+}
+
+func formatClarificationMessage(c *models.Clarification) string {
+	message := c.Question
+	for _, option := range c.Options {
+		message += "\n- " + option
+	}
+	return message
+}
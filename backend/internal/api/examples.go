@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/examples"
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+// promptKnowledge is the curated context retrieved for a question.
+type promptKnowledge struct {
+	glossary    string
+	definitions []models.Definition
+	examples    string
+	exampleIDs  []string
+}
+
+// loadKnowledge matches glossary terms and few-shot examples for query and
+// reports what was found through progress.
+func (h *APIHandler) loadKnowledge(ctx context.Context, query string, progress func(stage, message string)) promptKnowledge {
+	var k promptKnowledge
+	k.glossary, k.definitions = h.matchGlossary(query)
+	if len(k.definitions) > 0 {
+		progress("glossary_matched", tr(ctx, "Matched %d glossary terms", len(k.definitions)))
+	}
+	k.examples, k.exampleIDs = h.matchExamples(query)
+	if len(k.exampleIDs) > 0 {
+		progress("examples_matched", tr(ctx, "Matched %d verified examples", len(k.exampleIDs)))
+	}
+	return k
+}
+
+// matchExamples returns the prompt section and IDs of the verified examples
+// most similar to query. Lookup failures only drop the examples.
+func (h *APIHandler) matchExamples(query string) (string, []string) {
+	if h.examplesSvc == nil || h.cfg == nil || h.cfg.SQLFewShotExamples <= 0 {
+		return "", nil
+	}
+	matches, err := h.examplesSvc.Similar(query, h.cfg.SQLFewShotExamples)
+	if err != nil {
+		h.logger.Warn("Failed to match examples", zap.Error(err))
+		return "", nil
+	}
+	if len(matches) == 0 {
+		return "", nil
+	}
+	ids := make([]string, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ID
+	}
+	return examples.FormatForPrompt(matches), ids
+}
+
+// ListExamples returns all verified examples.
+func (h *APIHandler) ListExamples(c *gin.Context) {
+	if h.examplesSvc == nil {
+		c.JSON(http.StatusOK, models.SuccessResponse{
+			Code:    http.StatusOK,
+			Message: localize(c, "Examples disabled"),
+			Data:    []examples.Example{},
+		})
+		return
+	}
+	list, err := h.examplesSvc.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: localize(c, "Failed to list examples"),
+			Details: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Examples retrieved"),
+		Data:    list,
+	})
+}
+
+// PromoteExample turns one of the caller's saved reports or remembered
+// queries into a verified example.
+func (h *APIHandler) PromoteExample(c *gin.Context) {
+	if h.examplesSvc == nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Examples disabled"),
+		})
+		return
+	}
+	start := time.Now()
+	success := false
+	extra := map[string]interface{}{}
+	defer func() {
+		h.recordMetric("example_promote", start, success, extra)
+	}()
+
+	var req models.PromoteExampleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		extra["error"] = err.Error()
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: err.Error(),
+		})
+		return
+	}
+	extra["source"] = req.Source
+	extra["source_id"] = req.ID
+
+	userID := c.GetString("user_id")
+	ex := &examples.Example{
+		SourceType: req.Source,
+		SourceID:   req.ID,
+		CreatedBy:  userID,
+	}
+	var question string
+	switch req.Source {
+	case examples.SourceReport:
+		report, ok := h.reportStore.GetByID(userID, req.ID)
+		if !ok {
+			extra["error"] = "report not found"
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: localize(c, "Report not found"),
+			})
+			return
+		}
+		ex.SQL = report.SQL
+		question = firstNonEmpty(report.Title, report.Description)
+	case examples.SourceMemory:
+		if h.memoryStore == nil {
+			extra["error"] = "memory disabled"
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: localize(c, "Memory disabled"),
+			})
+			return
+		}
+		entry, ok := h.memoryStore.Get(userID, req.ID)
+		if !ok || strings.TrimSpace(entry.SQL) == "" {
+			extra["error"] = "memory entry not found"
+			c.JSON(http.StatusNotFound, models.ErrorResponse{
+				Code:    http.StatusNotFound,
+				Message: localize(c, "Memory entry not found"),
+			})
+			return
+		}
+		ex.SQL = entry.SQL
+		question = entry.Query
+	default:
+		extra["error"] = "unsupported source"
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: fmt.Sprintf("source must be %q or %q", examples.SourceReport, examples.SourceMemory),
+		})
+		return
+	}
+	ex.Question = firstNonEmpty(req.Question, question)
+
+	if h.sqlExecutor != nil {
+		if err := h.sqlExecutor.ValidateSQL(ex.SQL); err != nil {
+			extra["error"] = err.Error()
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: localize(c, "SQL validation failed"),
+				Details: err.Error(),
+			})
+			return
+		}
+	}
+	if err := h.examplesSvc.Promote(ex); err != nil {
+		extra["error"] = err.Error()
+		h.logger.Error("Failed to promote example", zap.Error(err))
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Failed to promote example"),
+			Details: err.Error(),
+		})
+		return
+	}
+	extra["example_id"] = ex.ID
+	success = true
+	c.JSON(http.StatusCreated, models.SuccessResponse{
+		Code:    http.StatusCreated,
+		Message: localize(c, "Example promoted"),
+		Data:    ex,
+	})
+}
+
+// DeleteExample removes an example. Only its creator or an admin may delete it.
+func (h *APIHandler) DeleteExample(c *gin.Context) {
+	if h.examplesSvc == nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Examples disabled"),
+		})
+		return
+	}
+	id := c.Param("id")
+	ex, err := h.examplesSvc.Get(id)
+	if err == nil && ex.CreatedBy != c.GetString("user_id") && !isAdmin(c) {
+		c.JSON(http.StatusForbidden, models.ErrorResponse{
+			Code:    http.StatusForbidden,
+			Message: localize(c, "Only the creator or an admin can delete this example"),
+		})
+		return
+	}
+	if err == nil {
+		err = h.examplesSvc.Delete(id)
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, examples.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.ErrorResponse{
+			Code:    status,
+			Message: localize(c, "Failed to delete example"),
+			Details: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Example deleted"),
+	})
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/i18n"
+	"github.com/yourusername/db_asst/internal/llm"
+	"github.com/yourusername/db_asst/internal/models"
+	"github.com/yourusername/db_asst/internal/sqlparse"
+)
+
+const (
+	// maxExplainSQLRunes bounds the SQL accepted for explanation.
+	maxExplainSQLRunes = 20000
+	// maxExplainTables bounds the catalog lookups for one explanation.
+	maxExplainTables = 20
+)
+
+// ExplainSQL translates SQL into a plain-language, step-by-step explanation.
+// Referenced tables and columns are annotated with their catalog comments
+// and risky patterns found by static analysis are passed on as warnings.
+func (h *APIHandler) ExplainSQL(c *gin.Context) {
+	start := time.Now()
+	success := false
+	extra := map[string]interface{}{}
+	defer func() {
+		h.recordMetric("sql_explain", start, success, extra)
+	}()
+
+	var req models.SQLExplainRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		extra["error"] = err.Error()
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: err.Error(),
+		})
+		return
+	}
+	if len([]rune(req.SQL)) > maxExplainSQLRunes {
+		extra["error"] = "sql too long"
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: "sql is too long to explain",
+		})
+		return
+	}
+	if strings.TrimSpace(req.Language) == "" {
+		req.Language = i18n.LanguageName(requestLocale(c))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	ctx = withLLMScope(ctx, c.GetString("user_id"), c.GetString("username"), "", uuid.New().String())
+
+	stmt := sqlparse.Parse(req.SQL)
+	extra["statement_type"] = stmt.Type
+	extra["tables"] = len(stmt.Tables)
+	extra["findings"] = len(stmt.Findings)
+	tables := h.explainTables(ctx, stmt)
+
+	var findings strings.Builder
+	for _, f := range stmt.Findings {
+		findings.WriteString("- [" + f.Code + "] " + f.Message + "\n")
+	}
+	resp, err := h.llmClient.ExplainSQL(ctx, &req, explainCatalog(tables), findings.String())
+	if err != nil {
+		extra["error"] = err.Error()
+		status := http.StatusInternalServerError
+		if errors.Is(err, llm.ErrBudgetExceeded) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, models.ErrorResponse{
+			Code:    status,
+			Message: localize(c, "Failed to explain SQL"),
+			Details: err.Error(),
+		})
+		return
+	}
+	resp.StatementType = stmt.Type
+	resp.Tables = tables
+	resp.Findings = make([]models.SQLFinding, 0, len(stmt.Findings))
+	for _, f := range stmt.Findings {
+		resp.Findings = append(resp.Findings, models.SQLFinding{Code: f.Code, Message: f.Message})
+	}
+	success = true
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "SQL explained"),
+		Data:    resp,
+	})
+}
+
+// explainTables looks up the statement's tables in the catalog and keeps the
+// columns it refers to. Excluded tables are reported as not found.
+func (h *APIHandler) explainTables(ctx context.Context, stmt *sqlparse.Statement) []models.ExplainedTable {
+	tables := make([]models.ExplainedTable, 0, len(stmt.Tables))
+	index := make(map[string]int)
+	// refs maps table names and aliases, unquoted, to positions in tables.
+	refs := make(map[string]int)
+	var schemas []*models.TableSchema
+	for _, ref := range stmt.Tables {
+		name := catalogName(ref.Name)
+		pos, ok := index[name]
+		if !ok {
+			pos = len(tables)
+			index[name] = pos
+			table := models.ExplainedTable{Name: name, Columns: []models.ColumnInfo{}}
+			var schema *models.TableSchema
+			if h.dbClient != nil && !h.isExcludedTable(name) && len(index) <= maxExplainTables {
+				s, err := h.dbClient.GetTableSchema(ctx, name)
+				if err != nil {
+					h.logger.Warn("Failed to load table for explanation", zap.String("table", name), zap.Error(err))
+				} else if len(s.Columns) > 0 {
+					schema = s
+					table.Found = true
+					table.Comment = s.Comment
+				}
+			}
+			tables = append(tables, table)
+			schemas = append(schemas, schema)
+		}
+		refs[name] = pos
+		if ref.Alias != "" {
+			alias := catalogName(ref.Alias)
+			refs[alias] = pos
+			tables[pos].Aliases = appendUnique(tables[pos].Aliases, alias)
+		}
+	}
+
+	added := make(map[string]bool)
+	add := func(pos int, column string) {
+		schema := schemas[pos]
+		if schema == nil {
+			return
+		}
+		for _, col := range schema.Columns {
+			key := tables[pos].Name + "." + col.ColumnName
+			if col.ColumnName == column && !added[key] {
+				added[key] = true
+				tables[pos].Columns = append(tables[pos].Columns, col)
+			}
+		}
+	}
+	for _, col := range stmt.Columns {
+		name := catalogName(col.Name)
+		if col.Qualifier != "" {
+			if pos, ok := refs[catalogName(col.Qualifier)]; ok {
+				add(pos, name)
+			}
+			continue
+		}
+		for pos := range tables {
+			add(pos, name)
+		}
+	}
+	return tables
+}
+
+// explainCatalog renders the looked-up tables for the prompt.
+func explainCatalog(tables []models.ExplainedTable) string {
+	var builder strings.Builder
+	for _, table := range tables {
+		builder.WriteString("Table: " + table.Name)
+		if len(table.Aliases) > 0 {
+			builder.WriteString(" (alias " + strings.Join(table.Aliases, ", ") + ")")
+		}
+		if !table.Found {
+			builder.WriteString(" - not found in the catalog\n")
+			continue
+		}
+		if table.Comment != "" {
+			builder.WriteString(" - " + table.Comment)
+		}
+		builder.WriteString("\n")
+		for _, col := range table.Columns {
+			builder.WriteString("  - " + col.ColumnName + " (" + col.DataType + ")")
+			if col.Nullable {
+				builder.WriteString(" nullable")
+			}
+			if col.Comment != "" {
+				builder.WriteString(" - " + col.Comment)
+			}
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
+
+// catalogName turns a parsed identifier into its catalog form: the last
+// dotted part, without quotes.
+func catalogName(name string) string {
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		name = name[idx+1:]
+	}
+	return strings.Trim(name, `"`)
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -6,11 +6,13 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/yourusername/db_asst/internal/i18n"
 )
 
-func buildTableDocument(title string, notes []string, columns []string, rows [][]string) string {
+func buildTableDocument(locale, title string, notes []string, columns []string, rows [][]string) string {
 	var builder strings.Builder
-	builder.WriteString(`<!DOCTYPE html><html><head><meta charset="UTF-8"><style>
+	builder.WriteString(`<!DOCTYPE html><html lang="` + html.EscapeString(locale) + `"><head><meta charset="UTF-8"><style>
 body{font-family:"Microsoft YaHei",Arial,sans-serif;margin:24px;color:#222;}
 h2{margin-bottom:4px;}
 .meta{margin:2px 0;color:#555;font-size:13px;}
@@ -36,7 +38,7 @@ tbody tr:nth-child(even){background-color:#fbfbfb;}
 	builder.WriteString("</tr></thead><tbody>")
 	if len(rows) == 0 {
 		colspan := strconv.Itoa(max(1, len(columns)))
-		builder.WriteString(fmt.Sprintf("<tr><td class=\"empty\" colspan=\"%s\">暂无数据</td></tr>", colspan))
+		builder.WriteString(fmt.Sprintf("<tr><td class=\"empty\" colspan=\"%s\">%s</td></tr>", colspan, html.EscapeString(i18n.T(locale, "No data"))))
 	} else {
 		for _, row := range rows {
 			builder.WriteString("<tr>")
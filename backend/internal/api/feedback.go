@@ -0,0 +1,174 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/feedback"
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+// recordGeneration keeps what produced resp so feedback on its request ID
+// can be attributed to the template, prompt version, tables and model.
+func (h *APIHandler) recordGeneration(userID, sessionID, query string, resp *models.SQLGenerateResponse, memoryID string) {
+	if h.feedbackStore == nil || resp.RequestID == "" {
+		return
+	}
+	rec := &feedback.Record{
+		RequestID:     resp.RequestID,
+		UserID:        userID,
+		SessionID:     sessionID,
+		Query:         query,
+		SQL:           resp.SQL,
+		Source:        resp.Source,
+		TemplateID:    resp.TemplateID,
+		PromptVersion: resp.PromptVersion,
+		Tables:        resp.TablesUsed,
+		MemoryID:      memoryID,
+	}
+	if resp.Source == "llm" {
+		rec.Model = resp.Model
+	}
+	if err := h.feedbackStore.RecordGeneration(rec); err != nil {
+		h.logger.Warn("Failed to record generation for feedback", zap.Error(err))
+	}
+}
+
+// SubmitFeedback stores a thumbs-up or thumbs-down, with optional corrected
+// SQL and comment, on one of the caller's generations.
+func (h *APIHandler) SubmitFeedback(c *gin.Context) {
+	if h.feedbackStore == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: localize(c, "Feedback unavailable"),
+		})
+		return
+	}
+	start := time.Now()
+	success := false
+	extra := map[string]interface{}{}
+	defer func() {
+		h.recordMetric("feedback", start, success, extra)
+	}()
+
+	var req models.FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		extra["error"] = err.Error()
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: err.Error(),
+		})
+		return
+	}
+	extra["request_id"] = req.RequestID
+	extra["rating"] = req.Rating
+
+	var rating int
+	switch strings.ToLower(strings.TrimSpace(req.Rating)) {
+	case "up":
+		rating = feedback.RatingUp
+	case "down":
+		rating = feedback.RatingDown
+	default:
+		extra["error"] = "invalid rating"
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: `rating must be "up" or "down"`,
+		})
+		return
+	}
+	corrected := strings.TrimSpace(req.CorrectedSQL)
+	if corrected != "" && h.sqlExecutor != nil {
+		if err := h.sqlExecutor.ValidateSQL(corrected); err != nil {
+			extra["error"] = err.Error()
+			c.JSON(http.StatusBadRequest, models.ErrorResponse{
+				Code:    http.StatusBadRequest,
+				Message: localize(c, "Corrected SQL validation failed"),
+				Details: err.Error(),
+			})
+			return
+		}
+	}
+
+	userID := c.GetString("user_id")
+	if err := h.feedbackStore.Rate(userID, req.RequestID, rating, corrected, strings.TrimSpace(req.Comment)); err != nil {
+		extra["error"] = err.Error()
+		status := http.StatusInternalServerError
+		if errors.Is(err, feedback.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.ErrorResponse{
+			Code:    status,
+			Message: localize(c, "Failed to save feedback"),
+			Details: err.Error(),
+		})
+		return
+	}
+	rec, err := h.feedbackStore.Get(userID, req.RequestID)
+	if err != nil {
+		h.logger.Warn("Failed to reload feedback", zap.Error(err))
+	}
+	success = true
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Feedback saved"),
+		Data:    rec,
+	})
+}
+
+// AdminFeedbackReport aggregates accuracy by template, prompt version, table
+// and model for feedback given in [from, to). Defaults to the current month.
+func (h *APIHandler) AdminFeedbackReport(c *gin.Context) {
+	if h.feedbackStore == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: localize(c, "Feedback unavailable"),
+		})
+		return
+	}
+	now := time.Now()
+	to := now
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	if fromStr := c.Query("from"); fromStr != "" {
+		if parsed, err := time.Parse(time.RFC3339, fromStr); err == nil {
+			from = parsed
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		if parsed, err := time.Parse(time.RFC3339, toStr); err == nil {
+			to = parsed
+		}
+	}
+	records, err := h.feedbackStore.ListRated(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: localize(c, "Failed to load feedback report"),
+			Details: err.Error(),
+		})
+		return
+	}
+	var negative []feedback.Record
+	for _, rec := range records {
+		if rec.Rating < 0 && len(negative) < 20 {
+			negative = append(negative, rec)
+		}
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Feedback report retrieved"),
+		Data: gin.H{
+			"from":            from,
+			"to":              to,
+			"report":          feedback.BuildReport(records),
+			"recent_negative": negative,
+		},
+	})
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/yourusername/db_asst/internal/db"
+	"github.com/yourusername/db_asst/internal/llm"
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+// generationHooks lets a transport follow the generation pipeline; every
+// hook may be nil.
+type generationHooks struct {
+	progress func(stage, message string)
+	// chunk streams the SQL of a single generation. Agent and candidate
+	// generations finish before their SQL is known, so nothing is streamed.
+	chunk     func(chunk string)
+	agentStep func(step models.AgentStep)
+	repair    func(attempt models.RepairAttempt)
+	// extra, when set, receives details for the transport's metric.
+	extra map[string]interface{}
+}
+
+// generationOutcome says how a generation ended.
+type generationOutcome int
+
+const (
+	generatedSQL           generationOutcome = iota // SQL from the LLM
+	generatedTemplate                               // SQL from a report template
+	generatedClarification                          // a question back to the user
+	generatedGuidance                               // generation failed; guidance instead of SQL
+	generatedNoSQL                                  // the model answered without SQL; guidance when available
+)
+
+// generation is the result of runGeneration.
+type generation struct {
+	resp    *models.SQLGenerateResponse
+	outcome generationOutcome
+	// reply is the assistant's answer for outcomes without SQL. It has
+	// already been saved to the chat.
+	reply string
+	// err is the generation error that guidance replaced.
+	err error
+}
+
+// hasSQL reports whether resp carries SQL, which is then already in memory.
+func (g *generation) hasSQL() bool {
+	return g.outcome == generatedSQL || g.outcome == generatedTemplate
+}
+
+// runGeneration is the generation pipeline behind every transport: pending
+// clarification answers, template match, schema, memory and knowledge
+// context, agent, single or multi-candidate generation, clarification,
+// guidance when no SQL comes back, the optional repair loop and memory.
+// Chat messages are saved for replies without SQL; the caller saves the
+// one for SQL, which for ask includes the answer. An error is returned only
+// when generation failed and no guidance could be given either.
+func (h *APIHandler) runGeneration(ctx context.Context, userID, username, sessionID, requestID string, req *models.SQLGenerateRequest, hooks generationHooks) (*generation, error) {
+	progress := hooks.progress
+	if progress == nil {
+		progress = func(string, string) {}
+	}
+	extra := hooks.extra
+	if extra == nil {
+		extra = make(map[string]interface{})
+	}
+	h.prepareClarification(userID, sessionID, req)
+
+	if h.templateSvc != nil {
+		if tpl := h.templateSvc.Match(req.Query); tpl != nil {
+			progress("template_matched", tr(ctx, "Matched template: %s", tpl.Name))
+			resp := &models.SQLGenerateResponse{
+				SQL:        tpl.SQL,
+				Reasoning:  tr(ctx, "Matched built-in report template: %s", tpl.Name),
+				Source:     "template",
+				TemplateID: tpl.ID,
+				RequestID:  requestID,
+			}
+			extra["template_id"] = tpl.ID
+			h.appendMemory(userID, sessionID, req.Query, resp)
+			return &generation{resp: resp, outcome: generatedTemplate}, nil
+		}
+	}
+
+	agent := h.agentEnabled(req)
+	progress("prepare_context", tr(ctx, "Loading database metadata"))
+	schemaContext := h.generationSchema(ctx, req.TableNames, agent)
+	memoryContext, memoryTurns := h.loadMemory(ctx, userID, sessionID, progress)
+	knowledge := h.loadKnowledge(ctx, req.Query, progress)
+	gc := llm.GenerationContext{Schema: schemaContext, Memory: memoryContext, Glossary: knowledge.glossary, Examples: knowledge.examples}
+
+	identity := db.SessionIdentity{UserID: userID, Username: username, RequestID: requestID}
+	var resp *models.SQLGenerateResponse
+	var err error
+	if agent {
+		resp, schemaContext, err = h.generateWithAgent(ctx, identity, req, gc, progress, hooks.agentStep)
+		extra["agent"] = true
+	} else if n := h.candidateCount(req); n > 0 {
+		resp, err = h.generateWithVoting(ctx, identity, req, gc, n, progress)
+		extra["candidates"] = n
+	} else {
+		progress("llm_call", tr(ctx, "LLM is generating SQL"))
+		if hooks.chunk != nil {
+			resp, err = h.llmClient.GenerateSQLStream(ctx, req, gc, hooks.chunk)
+		} else {
+			resp, err = h.llmClient.GenerateSQL(ctx, req, gc)
+		}
+	}
+	if err != nil {
+		extra["error"] = err.Error()
+		if errors.Is(err, llm.ErrBudgetExceeded) {
+			return nil, err
+		}
+		if guidance := h.generateGuidanceResponse(ctx, *req, schemaContext, err.Error(), requestID); guidance != nil {
+			h.saveChatMessage(userID, sessionID, "assistant", guidance.Reasoning)
+			return &generation{resp: guidance, outcome: generatedGuidance, reply: guidance.Reasoning, err: err}, nil
+		}
+		h.saveChatMessage(userID, sessionID, "assistant", tr(ctx, "Generation failed: %s", err.Error()))
+		return nil, err
+	}
+	resp.Source = "llm"
+	resp.UsedMemory = memoryTurns > 0
+	resp.RequestID = requestID
+	resp.DefinitionsUsed = knowledge.definitions
+	resp.ExamplesUsed = knowledge.exampleIDs
+	extra["prompt_version"] = resp.PromptVersion
+
+	if resp.Clarification != nil {
+		extra["clarification"] = resp.Clarification.Type
+		h.recordClarification(userID, sessionID, req.Query, resp)
+		return &generation{resp: resp, outcome: generatedClarification, reply: formatClarificationMessage(resp.Clarification)}, nil
+	}
+	if h.needsGuidance(resp.SQL) {
+		out := &generation{resp: resp, outcome: generatedNoSQL, reply: resp.ClarifyingQuestion}
+		if guidance := h.generateGuidanceResponse(ctx, *req, schemaContext, guidanceIssue(resp), requestID); guidance != nil {
+			out.resp = guidance
+			out.reply = firstNonEmpty(out.reply, guidance.Reasoning)
+		}
+		out.reply = firstNonEmpty(out.reply, resp.Explanation)
+		h.saveChatMessage(userID, sessionID, "assistant", out.reply)
+		return out, nil
+	}
+
+	if rounds, ok := h.repairRounds(req); ok && !resp.Validated {
+		h.repairSQL(ctx, identity, resp, schemaContext, rounds, progress, hooks.repair)
+		extra["repair_rounds"] = len(resp.Attempts) - 1
+		extra["validated"] = resp.Validated
+	}
+	h.appendMemory(userID, sessionID, req.Query, resp)
+	return &generation{resp: resp, outcome: generatedSQL}, nil
+}
+
+// Generate runs the generation pipeline outside of HTTP, for the offline
+// evaluation command. Without a user there is no memory or chat. When
+// generation fails the error is returned even if guidance was given.
+func (h *APIHandler) Generate(ctx context.Context, requestID string, req *models.SQLGenerateRequest) (*models.SQLGenerateResponse, error) {
+	gen, err := h.runGeneration(ctx, "", "", "", requestID, req, generationHooks{})
+	if err != nil {
+		return nil, err
+	}
+	return gen.resp, gen.err
+}
@@ -0,0 +1,174 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/glossary"
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+// matchGlossary returns the prompt section and definitions for the glossary
+// terms found in query. Lookup failures only drop the glossary.
+func (h *APIHandler) matchGlossary(query string) (string, []models.Definition) {
+	if h.glossarySvc == nil {
+		return "", nil
+	}
+	terms, err := h.glossarySvc.Match(query)
+	if err != nil {
+		h.logger.Warn("Failed to match glossary terms", zap.Error(err))
+		return "", nil
+	}
+	if len(terms) == 0 {
+		return "", nil
+	}
+	definitions := make([]models.Definition, len(terms))
+	for i, t := range terms {
+		definitions[i] = models.Definition{
+			ID:            t.ID,
+			Term:          t.Term,
+			Definition:    t.Definition,
+			SQLExpression: t.SQLExpression,
+		}
+	}
+	return glossary.FormatForPrompt(terms), definitions
+}
+
+// ListGlossary returns all glossary terms.
+func (h *APIHandler) ListGlossary(c *gin.Context) {
+	if h.glossarySvc == nil {
+		c.JSON(http.StatusOK, models.SuccessResponse{
+			Code:    http.StatusOK,
+			Message: localize(c, "Glossary disabled"),
+			Data:    []glossary.Term{},
+		})
+		return
+	}
+	terms, err := h.glossarySvc.List()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: localize(c, "Failed to list glossary"),
+			Details: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Glossary retrieved"),
+		Data:    terms,
+	})
+}
+
+// AdminCreateGlossaryTerm adds a glossary term.
+func (h *APIHandler) AdminCreateGlossaryTerm(c *gin.Context) {
+	h.saveGlossaryTerm(c, "")
+}
+
+// AdminUpdateGlossaryTerm replaces a glossary term.
+func (h *APIHandler) AdminUpdateGlossaryTerm(c *gin.Context) {
+	h.saveGlossaryTerm(c, c.Param("id"))
+}
+
+func (h *APIHandler) saveGlossaryTerm(c *gin.Context, id string) {
+	if h.glossarySvc == nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Glossary disabled"),
+		})
+		return
+	}
+	start := time.Now()
+	success := false
+	extra := map[string]interface{}{"term_id": id}
+	defer func() {
+		h.recordMetric("glossary_save", start, success, extra)
+	}()
+
+	var req models.GlossaryTermRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		extra["error"] = err.Error()
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid glossary payload"),
+			Details: err.Error(),
+		})
+		return
+	}
+
+	term := &glossary.Term{CreatedBy: c.GetString("user_id")}
+	if id != "" {
+		existing, err := h.glossarySvc.Get(id)
+		if err != nil {
+			extra["error"] = err.Error()
+			status := http.StatusInternalServerError
+			if errors.Is(err, glossary.ErrNotFound) {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, models.ErrorResponse{
+				Code:    status,
+				Message: localize(c, "Glossary term not found"),
+				Details: err.Error(),
+			})
+			return
+		}
+		term = existing
+	}
+	term.Term = req.Term
+	term.Synonyms = req.Synonyms
+	term.Definition = req.Definition
+	term.SQLExpression = req.SQLExpression
+	term.Tables = req.Tables
+	if err := h.glossarySvc.Save(term); err != nil {
+		extra["error"] = err.Error()
+		h.logger.Error("Failed to save glossary term", zap.Error(err))
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Failed to save glossary term"),
+			Details: err.Error(),
+		})
+		return
+	}
+	extra["term_id"] = term.ID
+	success = true
+	status := http.StatusOK
+	if id == "" {
+		status = http.StatusCreated
+	}
+	c.JSON(status, models.SuccessResponse{
+		Code:    status,
+		Message: localize(c, "Glossary term saved"),
+		Data:    term,
+	})
+}
+
+// AdminDeleteGlossaryTerm removes a glossary term.
+func (h *APIHandler) AdminDeleteGlossaryTerm(c *gin.Context) {
+	if h.glossarySvc == nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Glossary disabled"),
+		})
+		return
+	}
+	if err := h.glossarySvc.Delete(c.Param("id")); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, glossary.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, models.ErrorResponse{
+			Code:    status,
+			Message: localize(c, "Failed to delete glossary term"),
+			Details: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Glossary term deleted"),
+	})
+}
@@ -2,11 +2,14 @@ package api
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -18,18 +21,26 @@ import (
 	"github.com/yourusername/db_asst/internal/auth"
 	"github.com/yourusername/db_asst/internal/chat"
 	"github.com/yourusername/db_asst/internal/db"
+	"github.com/yourusername/db_asst/internal/examples"
 	"github.com/yourusername/db_asst/internal/executor"
+	"github.com/yourusername/db_asst/internal/feedback"
+	"github.com/yourusername/db_asst/internal/glossary"
+	"github.com/yourusername/db_asst/internal/health"
+	"github.com/yourusername/db_asst/internal/i18n"
 	"github.com/yourusername/db_asst/internal/llm"
 	"github.com/yourusername/db_asst/internal/memory"
 	"github.com/yourusername/db_asst/internal/models"
 	"github.com/yourusername/db_asst/internal/monitor"
 	"github.com/yourusername/db_asst/internal/progress"
 	"github.com/yourusername/db_asst/internal/reports"
+	"github.com/yourusername/db_asst/internal/results"
 	"github.com/yourusername/db_asst/internal/templates"
+	"github.com/yourusername/db_asst/internal/usage"
 )
 
 type APIHandler struct {
 	dbClient        *db.OracleClient
+	appDB           *sql.DB
 	jwtManager      *auth.JWTManager
 	userService     *auth.UserService
 	sqlExecutor     *executor.SQLExecutor
@@ -40,11 +51,20 @@ type APIHandler struct {
 	reportStore     *reports.Store
 	chatStore       *chat.Store
 	monitor         *monitor.Monitor
+	usageSvc        *usage.Service
+	glossarySvc     *glossary.Service
+	examplesSvc     *examples.Service
+	feedbackStore   *feedback.Store
 	progressStore   *progress.Store
+	resultCache     *results.Cache
 	generateTimeout time.Duration
 	cfg             *config.Config
 	excludeTables   map[string]struct{}
 	excludePrefixes []string
+	healthChecker   *health.Checker
+	startedAt       time.Time
+	// summaryBackoffs maps user/session to its summaryBackoff.
+	summaryBackoffs sync.Map
 }
 
 var wsUpgrader = websocket.Upgrader{
@@ -64,11 +84,21 @@ type wsMessage struct {
 	TemplateID string `json:"template_id,omitempty"`
 	UsedMemory bool   `json:"used_memory,omitempty"`
 	Error      string `json:"error,omitempty"`
+	// Attempt is set on "repair_attempt" messages.
+	Attempt *models.RepairAttempt `json:"attempt,omitempty"`
+	// Step is set on "agent_step" messages.
+	Step *models.AgentStep `json:"step,omitempty"`
+	// Result carries the full response on "complete" messages.
+	Result *models.SQLGenerateResponse `json:"result,omitempty"`
+	// Rows and Summary are sent in "ask" mode.
+	Rows    *models.SQLExecuteResponse `json:"rows,omitempty"`
+	Summary string                     `json:"summary,omitempty"`
 }
 
 // NewAPIHandler creates a new API handler
 func NewAPIHandler(
 	dbClient *db.OracleClient,
+	appDB *sql.DB,
 	jwtManager *auth.JWTManager,
 	userService *auth.UserService,
 	sqlExecutor *executor.SQLExecutor,
@@ -78,7 +108,12 @@ func NewAPIHandler(
 	reportStore *reports.Store,
 	chatStore *chat.Store,
 	monitor *monitor.Monitor,
+	usageSvc *usage.Service,
+	glossarySvc *glossary.Service,
+	examplesSvc *examples.Service,
+	feedbackStore *feedback.Store,
 	progressStore *progress.Store,
+	resultCache *results.Cache,
 	cfg *config.Config,
 	logger *zap.Logger,
 ) *APIHandler {
@@ -86,8 +121,9 @@ func NewAPIHandler(
 	if timeout <= 0 {
 		timeout = 120
 	}
-	return &APIHandler{
+	h := &APIHandler{
 		dbClient:        dbClient,
+		appDB:           appDB,
 		jwtManager:      jwtManager,
 		userService:     userService,
 		sqlExecutor:     sqlExecutor,
@@ -97,20 +133,60 @@ func NewAPIHandler(
 		reportStore:     reportStore,
 		chatStore:       chatStore,
 		monitor:         monitor,
+		usageSvc:        usageSvc,
+		glossarySvc:     glossarySvc,
+		examplesSvc:     examplesSvc,
+		feedbackStore:   feedbackStore,
 		progressStore:   progressStore,
+		resultCache:     resultCache,
 		generateTimeout: timeout * time.Second,
 		cfg:             cfg,
 		logger:          logger,
 		excludeTables:   buildExcludeTableMap(cfg),
 		excludePrefixes: buildExcludePrefixes(cfg),
+		startedAt:       time.Now(),
 	}
+	h.healthChecker = h.newHealthChecker()
+	return h
 }
 
 // Health checks if the server is running
 func (h *APIHandler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Server is healthy",
+		Message: localize(c, "Server is healthy"),
+	})
+}
+
+// Liveness reports that the process is running; it never checks dependencies
+func (h *APIHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Server is alive"),
+		Data: gin.H{
+			"status":     health.StatusUp,
+			"uptime_sec": int64(time.Since(h.startedAt).Seconds()),
+		},
+	})
+}
+
+// Readiness checks Oracle, the app database, schema catalog freshness and
+// optionally the LLM provider; it returns 503 when a critical dependency is down
+func (h *APIHandler) Readiness(c *gin.Context) {
+	report := h.healthChecker.Run(c.Request.Context())
+	code := http.StatusOK
+	message := "Server is ready"
+	switch report.Status {
+	case health.StatusDown:
+		code = http.StatusServiceUnavailable
+		message = "Server is not ready"
+	case health.StatusDegraded:
+		message = "Server is ready (degraded)"
+	}
+	c.JSON(code, models.SuccessResponse{
+		Code:    code,
+		Message: localize(c, message),
+		Data:    report,
 	})
 }
 
@@ -120,7 +196,7 @@ func (h *APIHandler) Register(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Invalid request",
+			Message: localize(c, "Invalid request"),
 			Details: err.Error(),
 		})
 		return
@@ -130,7 +206,7 @@ func (h *APIHandler) Register(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Registration failed",
+			Message: localize(c, "Registration failed"),
 			Details: err.Error(),
 		})
 		return
@@ -138,7 +214,7 @@ func (h *APIHandler) Register(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, models.SuccessResponse{
 		Code:    http.StatusCreated,
-		Message: "User registered successfully",
+		Message: localize(c, "User registered successfully"),
 		Data:    user,
 	})
 }
@@ -149,7 +225,7 @@ func (h *APIHandler) Login(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Invalid request",
+			Message: localize(c, "Invalid request"),
 			Details: err.Error(),
 		})
 		return
@@ -159,7 +235,7 @@ func (h *APIHandler) Login(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 			Code:    http.StatusUnauthorized,
-			Message: "Invalid credentials",
+			Message: localize(c, "Invalid credentials"),
 		})
 		return
 	}
@@ -168,14 +244,14 @@ func (h *APIHandler) Login(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "Token generation failed",
+			Message: localize(c, "Token generation failed"),
 		})
 		return
 	}
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Login successful",
+		Message: localize(c, "Login successful"),
 		Data: models.LoginResponse{
 			Token:     token,
 			ExpiresIn: expiresIn,
@@ -190,7 +266,7 @@ func (h *APIHandler) GenerateSQL(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Invalid request",
+			Message: localize(c, "Invalid request"),
 			Details: err.Error(),
 		})
 		return
@@ -200,7 +276,7 @@ func (h *APIHandler) GenerateSQL(c *gin.Context) {
 	if !exists {
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 			Code:    http.StatusUnauthorized,
-			Message: "Unauthorized",
+			Message: localize(c, "Unauthorized"),
 		})
 		return
 	}
@@ -221,103 +297,55 @@ func (h *APIHandler) GenerateSQL(c *gin.Context) {
 	if requestID == "" {
 		requestID = uuid.New().String()
 	}
-	h.updateProgress(requestID, "received", "已收到生成请求")
-
-	// Step1: 尝试命中模版
-	if h.templateSvc != nil {
-		if tpl := h.templateSvc.Match(req.Query); tpl != nil {
-			h.updateProgress(requestID, "template_matched", fmt.Sprintf("命中模版：%s", tpl.Name))
-			resp := &models.SQLGenerateResponse{
-				SQL:        tpl.SQL,
-				Reasoning:  fmt.Sprintf("命中内置报表模版：%s", tpl.Name),
-				Source:     "template",
-				TemplateID: tpl.ID,
-				UsedMemory: false,
-				RequestID:  requestID,
-			}
-			h.appendMemory(userID, sessionID, req.Query, resp)
-			metricExtra["template_id"] = tpl.ID
-			success = true
-			h.completeProgress(requestID, "生成完成（模版）")
-			h.saveChatMessage(userID, sessionID, "assistant", formatSQLChatMessage(resp))
-			c.JSON(http.StatusOK, models.SuccessResponse{
-				Code:    http.StatusOK,
-				Message: "SQL generated from template",
-				Data:    resp,
-			})
-			return
-		}
-	}
+	locale := requestLocale(c)
+	h.updateProgress(requestID, "received", i18n.T(locale, "Generation request received"))
 
-	h.updateProgress(requestID, "prepare_context", "正在加载数据库元数据")
 	ctx, cancel := context.WithTimeout(context.Background(), h.generateTimeout)
 	defer cancel()
+	ctx = withLLMScope(ctx, userID, c.GetString("username"), sessionID, requestID)
+	ctx = i18n.WithLocale(ctx, locale)
 
-	// Get database schema context
-	schemaContext, err := h.getDatabaseSchemaContext(ctx, req.TableNames)
-	if err != nil {
-		h.logger.Warn("Failed to get schema context", zap.Error(err))
-		schemaContext = "Error retrieving schema"
-	}
-
-	var memEntries []memory.Entry
-	if h.memoryStore != nil {
-		// 多取一些历史以便上下文更完整，但后续会自动压缩
-		memEntries = h.memoryStore.GetRecent(userID, sessionID, 12)
-	}
-	memoryContext := buildMemoryContext(memEntries)
-	if len(memEntries) > 0 {
-		h.updateProgress(requestID, "memory_loaded", fmt.Sprintf("命中 %d 条历史记忆", len(memEntries)))
-	}
-
-	// Call LLM to generate SQL
-	h.updateProgress(requestID, "llm_call", "LLM 正在生成 SQL")
-	resp, err := h.llmClient.GenerateSQL(ctx, &req, schemaContext, memoryContext)
+	gen, err := h.runGeneration(ctx, userID, c.GetString("username"), sessionID, requestID, &req, generationHooks{
+		progress: func(stage, message string) { h.updateProgress(requestID, stage, message) },
+		extra:    metricExtra,
+	})
 	if err != nil {
-		if guidance := h.generateGuidanceResponse(req, schemaContext, err.Error(), requestID); guidance != nil {
-			h.saveChatMessage(userID, sessionID, "assistant", guidance.Reasoning)
-			c.JSON(http.StatusOK, models.SuccessResponse{
-				Code:    http.StatusOK,
-				Message: "SQL generation guidance",
-				Data:    guidance,
-			})
-			return
+		h.failProgress(locale, requestID, err.Error())
+		status, message := http.StatusInternalServerError, localize(c, "Failed to generate SQL")
+		if errors.Is(err, llm.ErrBudgetExceeded) {
+			status, message = http.StatusTooManyRequests, localize(c, "LLM budget exceeded")
 		}
-		h.saveChatMessage(userID, sessionID, "assistant", fmt.Sprintf("生成失败：%s", err.Error()))
-		h.failProgress(requestID, err.Error())
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to generate SQL",
+		c.JSON(status, models.ErrorResponse{
+			Code:    status,
+			Message: message,
 			Details: err.Error(),
 		})
 		return
 	}
-	resp.Source = "llm"
-	resp.UsedMemory = len(memEntries) > 0
-	resp.RequestID = requestID
-
-	if h.needsGuidance(resp.SQL) {
-		if guidance := h.generateGuidanceResponse(req, schemaContext, resp.SQL, requestID); guidance != nil {
-			h.saveChatMessage(userID, sessionID, "assistant", guidance.Reasoning)
-			h.completeProgress(requestID, "生成完成（提示）")
-			c.JSON(http.StatusOK, models.SuccessResponse{
-				Code:    http.StatusOK,
-				Message: "SQL guidance",
-				Data:    guidance,
-			})
-			return
-		}
-	}
 
-	h.appendMemory(userID, sessionID, req.Query, resp)
-	success = true
-	h.completeProgress(requestID, "生成完成")
-	h.saveChatMessage(userID, sessionID, "assistant", formatSQLChatMessage(resp))
+	var message, done string
+	switch gen.outcome {
+	case generatedTemplate:
+		message, done = localize(c, "SQL generated from template"), i18n.T(locale, "Generated (template)")
+	case generatedClarification:
+		message, done = localize(c, "Clarification needed"), i18n.T(locale, "More information needed")
+	case generatedGuidance:
+		message, done = localize(c, "SQL generation guidance"), i18n.T(locale, "Generated (guidance)")
+	case generatedNoSQL:
+		message, done = localize(c, "SQL guidance"), i18n.T(locale, "Generated (guidance)")
+	default:
+		message, done = localize(c, "SQL generated successfully"), i18n.T(locale, "Generation complete")
+	}
+	if gen.hasSQL() {
+		h.saveChatMessage(userID, sessionID, "assistant", formatSQLChatMessage(locale, gen.resp))
+	}
+	success = gen.outcome != generatedGuidance
+	h.completeProgress(requestID, done)
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "SQL generated successfully",
-		Data:    resp,
+		Message: message,
+		Data:    gen.resp,
 	})
 }
 
@@ -327,22 +355,27 @@ func (h *APIHandler) ExecuteSQL(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Invalid request",
+			Message: localize(c, "Invalid request"),
 			Details: err.Error(),
 		})
 		return
 	}
 
+	if strings.TrimSpace(req.RequestID) == "" {
+		req.RequestID = uuid.New().String()
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
+	ctx = withSessionIdentity(ctx, c, req.RequestID, "execute_sql")
 
 	// Execute SQL
 	start := time.Now()
 	success := false
 	metricExtra := map[string]interface{}{
-		"user_id":   c.GetString("user_id"),
-		"page":      req.Page,
-		"page_size": req.PageSize,
+		"user_id":    c.GetString("user_id"),
+		"page":       req.Page,
+		"page_size":  req.PageSize,
+		"request_id": req.RequestID,
 	}
 	defer func() {
 		h.recordMetric("execute_sql", start, success, metricExtra)
@@ -353,7 +386,7 @@ func (h *APIHandler) ExecuteSQL(c *gin.Context) {
 		metricExtra["error"] = err.Error()
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "Failed to execute SQL",
+			Message: localize(c, "Failed to execute SQL"),
 			Details: err.Error(),
 		})
 		return
@@ -364,13 +397,17 @@ func (h *APIHandler) ExecuteSQL(c *gin.Context) {
 	h.logAuditTrail(userID, "EXECUTE_SQL", req.SQL, result.Success, result.Error)
 	if result.Success {
 		success = true
+		result.ExecutionID = h.cacheResult(ctx, userID, req.SQL, result)
+		if req.Chart {
+			result.Chart = h.recommendChart(ctx, "", req.SQL, result, false)
+		}
 	} else {
 		metricExtra["error"] = result.Error
 	}
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "SQL executed successfully",
+		Message: localize(c, "SQL executed successfully"),
 		Data:    result,
 	})
 }
@@ -381,7 +418,7 @@ func (h *APIHandler) ExportSQLResult(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Invalid request",
+			Message: localize(c, "Invalid request"),
 			Details: err.Error(),
 		})
 		return
@@ -394,7 +431,7 @@ func (h *APIHandler) ExportSQLResult(c *gin.Context) {
 	if format != "excel" && format != "word" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Unsupported format",
+			Message: localize(c, "Unsupported format"),
 		})
 		return
 	}
@@ -410,7 +447,7 @@ func (h *APIHandler) ExportSQLResult(c *gin.Context) {
 	if err := h.sqlExecutor.ValidateSQL(req.SQL); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Invalid SQL",
+			Message: localize(c, "Invalid SQL"),
 			Details: err.Error(),
 		})
 		return
@@ -418,18 +455,19 @@ func (h *APIHandler) ExportSQLResult(c *gin.Context) {
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Minute)
 	defer cancel()
+	ctx = withSessionIdentity(ctx, c, uuid.New().String(), "export_sql")
 
 	result, err := h.dbClient.ExecuteQueryRange(ctx, req.SQL, 0, limit)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "SQL execution failed",
+			Message: localize(c, "SQL execution failed"),
 			Details: err.Error(),
 		})
 		return
 	}
 	if result == nil || !result.Success {
-		message := "SQL execution failed"
+		message := localize(c, "SQL execution failed")
 		if result != nil && result.Error != "" {
 			message = result.Error
 		}
@@ -441,18 +479,18 @@ func (h *APIHandler) ExportSQLResult(c *gin.Context) {
 	}
 
 	rows := stringifySQLRows(result.Rows)
+	locale := requestLocale(c)
+	total := i18n.T(locale, "Total rows: %d", result.RowCount)
+	if result.HasMore {
+		total = i18n.T(locale, "Total rows: %d (limited to the first %d)", result.RowCount, limit)
+	}
 	notes := []string{
-		fmt.Sprintf("导出时间：%s", time.Now().Format("2006-01-02 15:04:05")),
-		fmt.Sprintf("总行数：%d%s", result.RowCount, func() string {
-			if result.HasMore {
-				return fmt.Sprintf("（已限制在前 %d 行）", limit)
-			}
-			return ""
-		}()),
-		"SQL：" + truncateText(strings.TrimSpace(req.SQL), 400),
+		i18n.T(locale, "Exported at: %s", time.Now().Format("2006-01-02 15:04:05")),
+		total,
+		i18n.T(locale, "SQL: %s", truncateText(strings.TrimSpace(req.SQL), 400)),
 	}
 
-	doc := buildTableDocument("SQL 查询结果导出", notes, result.Columns, rows)
+	doc := buildTableDocument(locale, i18n.T(locale, "SQL query result export"), notes, result.Columns, rows)
 	filename := req.Filename
 	if strings.TrimSpace(filename) == "" {
 		filename = fmt.Sprintf("sql_result_%s", time.Now().Format("20060102_150405"))
@@ -475,7 +513,7 @@ func (h *APIHandler) DebugSQL(c *gin.Context) {
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Invalid request",
+			Message: localize(c, "Invalid request"),
 			Details: err.Error(),
 		})
 		return
@@ -483,6 +521,7 @@ func (h *APIHandler) DebugSQL(c *gin.Context) {
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
+	ctx = withLLMScope(ctx, c.GetString("user_id"), c.GetString("username"), "", uuid.New().String())
 
 	// Get schema context
 	schemaContext, err := h.getDatabaseSchemaContext(ctx, "")
@@ -493,9 +532,13 @@ func (h *APIHandler) DebugSQL(c *gin.Context) {
 	// Call LLM to debug
 	resp, err := h.llmClient.DebugSQL(ctx, &req, schemaContext)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Code:    http.StatusInternalServerError,
-			Message: "Failed to debug SQL",
+		status := http.StatusInternalServerError
+		if errors.Is(err, llm.ErrBudgetExceeded) {
+			status = http.StatusTooManyRequests
+		}
+		c.JSON(status, models.ErrorResponse{
+			Code:    status,
+			Message: localize(c, "Failed to debug SQL"),
 			Details: err.Error(),
 		})
 		return
@@ -503,7 +546,7 @@ func (h *APIHandler) DebugSQL(c *gin.Context) {
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Debug suggestions provided",
+		Message: localize(c, "Debug suggestions provided"),
 		Data:    resp,
 	})
 }
@@ -522,7 +565,7 @@ func (h *APIHandler) SaveSQL(c *gin.Context) {
 		extra["error"] = err.Error()
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Invalid request",
+			Message: localize(c, "Invalid request"),
 			Details: err.Error(),
 		})
 		return
@@ -571,7 +614,7 @@ func (h *APIHandler) SaveSQL(c *gin.Context) {
 
 	c.JSON(http.StatusCreated, models.SuccessResponse{
 		Code:    http.StatusCreated,
-		Message: "SQL saved successfully",
+		Message: localize(c, "SQL saved successfully"),
 		Data:    record,
 	})
 }
@@ -596,7 +639,7 @@ func (h *APIHandler) GetHistory(c *gin.Context) {
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "History retrieved successfully",
+		Message: localize(c, "History retrieved successfully"),
 		Data:    histories,
 	})
 }
@@ -606,7 +649,7 @@ func (h *APIHandler) DeleteHistory(c *gin.Context) {
 	if h.reportStore == nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Report store is not configured",
+			Message: localize(c, "Report store is not configured"),
 		})
 		return
 	}
@@ -614,7 +657,7 @@ func (h *APIHandler) DeleteHistory(c *gin.Context) {
 	if reportID == "" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Report ID is required",
+			Message: localize(c, "Report ID is required"),
 		})
 		return
 	}
@@ -633,7 +676,7 @@ func (h *APIHandler) DeleteHistory(c *gin.Context) {
 		h.logger.Error("Failed to delete report", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "Failed to delete report",
+			Message: localize(c, "Failed to delete report"),
 			Details: err.Error(),
 		})
 		return
@@ -647,7 +690,7 @@ func (h *APIHandler) ListTemplates(c *gin.Context) {
 	if h.templateSvc == nil {
 		c.JSON(http.StatusOK, models.SuccessResponse{
 			Code:    http.StatusOK,
-			Message: "Templates disabled",
+			Message: localize(c, "Templates disabled"),
 			Data:    []templates.Template{},
 		})
 		return
@@ -665,7 +708,7 @@ func (h *APIHandler) ListTemplates(c *gin.Context) {
 		extra["error"] = err.Error()
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "Failed to list templates",
+			Message: localize(c, "Failed to list templates"),
 			Details: err.Error(),
 		})
 		return
@@ -673,7 +716,7 @@ func (h *APIHandler) ListTemplates(c *gin.Context) {
 	success = true
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Templates retrieved",
+		Message: localize(c, "Templates retrieved"),
 		Data:    list,
 	})
 }
@@ -682,7 +725,7 @@ func (h *APIHandler) CreateTemplate(c *gin.Context) {
 	if h.templateSvc == nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Templates disabled",
+			Message: localize(c, "Templates disabled"),
 		})
 		return
 	}
@@ -697,7 +740,7 @@ func (h *APIHandler) CreateTemplate(c *gin.Context) {
 		extra["error"] = err.Error()
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Invalid template payload",
+			Message: localize(c, "Invalid template payload"),
 			Details: err.Error(),
 		})
 		return
@@ -717,7 +760,7 @@ func (h *APIHandler) CreateTemplate(c *gin.Context) {
 		h.logger.Error("Failed to save template", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "Failed to save template",
+			Message: localize(c, "Failed to save template"),
 			Details: err.Error(),
 		})
 		return
@@ -729,7 +772,7 @@ func (h *APIHandler) CreateTemplate(c *gin.Context) {
 	}
 	c.JSON(http.StatusCreated, models.SuccessResponse{
 		Code:    http.StatusCreated,
-		Message: "Template created",
+		Message: localize(c, "Template created"),
 		Data:    list,
 	})
 }
@@ -738,7 +781,7 @@ func (h *APIHandler) UpdateTemplate(c *gin.Context) {
 	if h.templateSvc == nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Templates disabled",
+			Message: localize(c, "Templates disabled"),
 		})
 		return
 	}
@@ -755,7 +798,7 @@ func (h *APIHandler) UpdateTemplate(c *gin.Context) {
 		extra["error"] = "not_found"
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Code:    http.StatusNotFound,
-			Message: "Template not found",
+			Message: localize(c, "Template not found"),
 		})
 		return
 	}
@@ -763,7 +806,7 @@ func (h *APIHandler) UpdateTemplate(c *gin.Context) {
 		extra["error"] = "forbidden"
 		c.JSON(http.StatusForbidden, models.ErrorResponse{
 			Code:    http.StatusForbidden,
-			Message: "Template is not editable",
+			Message: localize(c, "Template is not editable"),
 		})
 		return
 	}
@@ -772,7 +815,7 @@ func (h *APIHandler) UpdateTemplate(c *gin.Context) {
 		extra["error"] = err.Error()
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Invalid template payload",
+			Message: localize(c, "Invalid template payload"),
 			Details: err.Error(),
 		})
 		return
@@ -787,7 +830,7 @@ func (h *APIHandler) UpdateTemplate(c *gin.Context) {
 		h.logger.Error("Failed to update template", zap.Error(err))
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "Failed to update template",
+			Message: localize(c, "Failed to update template"),
 		})
 		return
 	}
@@ -797,7 +840,7 @@ func (h *APIHandler) UpdateTemplate(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Template updated",
+		Message: localize(c, "Template updated"),
 		Data:    list,
 	})
 }
@@ -806,7 +849,7 @@ func (h *APIHandler) DeleteTemplate(c *gin.Context) {
 	if h.templateSvc == nil {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "Templates disabled",
+			Message: localize(c, "Templates disabled"),
 		})
 		return
 	}
@@ -823,7 +866,7 @@ func (h *APIHandler) DeleteTemplate(c *gin.Context) {
 		extra["error"] = "not_found"
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Code:    http.StatusNotFound,
-			Message: "Template not found",
+			Message: localize(c, "Template not found"),
 		})
 		return
 	}
@@ -831,7 +874,7 @@ func (h *APIHandler) DeleteTemplate(c *gin.Context) {
 		extra["error"] = "forbidden"
 		c.JSON(http.StatusForbidden, models.ErrorResponse{
 			Code:    http.StatusForbidden,
-			Message: "Template is not editable",
+			Message: localize(c, "Template is not editable"),
 		})
 		return
 	}
@@ -839,7 +882,7 @@ func (h *APIHandler) DeleteTemplate(c *gin.Context) {
 		extra["error"] = err.Error()
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "Failed to delete template",
+			Message: localize(c, "Failed to delete template"),
 			Details: err.Error(),
 		})
 		return
@@ -848,7 +891,7 @@ func (h *APIHandler) DeleteTemplate(c *gin.Context) {
 	success = true
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Template deleted",
+		Message: localize(c, "Template deleted"),
 		Data:    list,
 	})
 }
@@ -863,7 +906,7 @@ func (h *APIHandler) GetMonitorStats(c *gin.Context) {
 		}
 		c.JSON(http.StatusOK, models.SuccessResponse{
 			Code:    http.StatusOK,
-			Message: "Monitor disabled",
+			Message: localize(c, "Monitor disabled"),
 			Data:    empty,
 		})
 		return
@@ -890,14 +933,14 @@ func (h *APIHandler) GetMonitorStats(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "Failed to load stats",
+			Message: localize(c, "Failed to load stats"),
 			Details: err.Error(),
 		})
 		return
 	}
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Monitor stats retrieved",
+		Message: localize(c, "Monitor stats retrieved"),
 		Data:    dashboard,
 	})
 }
@@ -907,7 +950,7 @@ func (h *APIHandler) GetGenerationStatus(c *gin.Context) {
 	if h.progressStore == nil {
 		c.JSON(http.StatusNotFound, models.ErrorResponse{
 			Code:    http.StatusNotFound,
-			Message: "Progress store not configured",
+			Message: localize(c, "Progress store not configured"),
 		})
 		return
 	}
@@ -915,7 +958,7 @@ func (h *APIHandler) GetGenerationStatus(c *gin.Context) {
 	if requestID == "" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "request_id is required",
+			Message: localize(c, "request_id is required"),
 		})
 		return
 	}
@@ -924,7 +967,7 @@ func (h *APIHandler) GetGenerationStatus(c *gin.Context) {
 		entry = &progress.Entry{
 			ID:        requestID,
 			Stage:     "pending",
-			Message:   "任务排队中",
+			Message:   localize(c, "Queued"),
 			Done:      false,
 			Success:   false,
 			UpdatedAt: time.Now(),
@@ -932,7 +975,7 @@ func (h *APIHandler) GetGenerationStatus(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Progress retrieved",
+		Message: localize(c, "Progress retrieved"),
 		Data:    entry,
 	})
 }
@@ -941,7 +984,7 @@ func (h *APIHandler) ListChatSessions(c *gin.Context) {
 	if h.chatStore == nil {
 		c.JSON(http.StatusOK, models.SuccessResponse{
 			Code:    http.StatusOK,
-			Message: "Chat store disabled",
+			Message: localize(c, "Chat store disabled"),
 			Data:    map[string]time.Time{},
 		})
 		return
@@ -963,7 +1006,7 @@ func (h *APIHandler) ListChatSessions(c *gin.Context) {
 		extra["error"] = err.Error()
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "Failed to list sessions",
+			Message: localize(c, "Failed to list sessions"),
 			Details: err.Error(),
 		})
 		return
@@ -971,7 +1014,7 @@ func (h *APIHandler) ListChatSessions(c *gin.Context) {
 	success = true
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Chat sessions retrieved",
+		Message: localize(c, "Chat sessions retrieved"),
 		Data:    sessions,
 	})
 }
@@ -980,7 +1023,7 @@ func (h *APIHandler) GetChatMessages(c *gin.Context) {
 	if h.chatStore == nil {
 		c.JSON(http.StatusOK, models.SuccessResponse{
 			Code:    http.StatusOK,
-			Message: "Chat store disabled",
+			Message: localize(c, "Chat store disabled"),
 			Data:    []chat.Message{},
 		})
 		return
@@ -989,7 +1032,7 @@ func (h *APIHandler) GetChatMessages(c *gin.Context) {
 	if sessionID == "" {
 		c.JSON(http.StatusBadRequest, models.ErrorResponse{
 			Code:    http.StatusBadRequest,
-			Message: "session_id required",
+			Message: localize(c, "session_id required"),
 		})
 		return
 	}
@@ -1019,7 +1062,7 @@ func (h *APIHandler) GetChatMessages(c *gin.Context) {
 		extra["error"] = err.Error()
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "Failed to load messages",
+			Message: localize(c, "Failed to load messages"),
 			Details: err.Error(),
 		})
 		return
@@ -1027,7 +1070,7 @@ func (h *APIHandler) GetChatMessages(c *gin.Context) {
 	success = true
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Chat history retrieved",
+		Message: localize(c, "Chat history retrieved"),
 		Data:    messages,
 	})
 }
@@ -1068,12 +1111,14 @@ func (h *APIHandler) ExportChatSession(c *gin.Context) {
 				msg.Content,
 			}
 		}
+		locale := requestLocale(c)
 		notes := []string{
-			fmt.Sprintf("会话：%s", sessionID),
-			fmt.Sprintf("导出条数：%d", len(messages)),
-			fmt.Sprintf("导出时间：%s", time.Now().Format("2006-01-02 15:04:05")),
+			i18n.T(locale, "Session: %s", sessionID),
+			i18n.T(locale, "Messages exported: %d", len(messages)),
+			i18n.T(locale, "Exported at: %s", time.Now().Format("2006-01-02 15:04:05")),
 		}
-		doc := buildTableDocument("对话导出", notes, []string{"时间", "角色", "内容"}, rows)
+		headers := []string{i18n.T(locale, "Time"), i18n.T(locale, "Role"), i18n.T(locale, "Content")}
+		doc := buildTableDocument(locale, i18n.T(locale, "Chat export"), notes, headers, rows)
 		filename := fmt.Sprintf("chat_%s_%s", sessionID, time.Now().Format("20060102_150405"))
 		contentType := "application/vnd.ms-excel"
 		ext := ".xls"
@@ -1099,7 +1144,7 @@ func (h *APIHandler) AdminListUsers(c *gin.Context) {
 	if h.userService == nil {
 		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
 			Code:    http.StatusServiceUnavailable,
-			Message: "User service unavailable",
+			Message: localize(c, "User service unavailable"),
 		})
 		return
 	}
@@ -1107,14 +1152,14 @@ func (h *APIHandler) AdminListUsers(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "Failed to load users",
+			Message: localize(c, "Failed to load users"),
 			Details: err.Error(),
 		})
 		return
 	}
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Users retrieved",
+		Message: localize(c, "Users retrieved"),
 		Data:    users,
 	})
 }
@@ -1123,7 +1168,7 @@ func (h *APIHandler) AdminUserUsage(c *gin.Context) {
 	if h.monitor == nil {
 		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
 			Code:    http.StatusServiceUnavailable,
-			Message: "Monitor service unavailable",
+			Message: localize(c, "Monitor service unavailable"),
 		})
 		return
 	}
@@ -1143,18 +1188,27 @@ func (h *APIHandler) AdminUserUsage(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "Failed to load usage stats",
+			Message: localize(c, "Failed to load usage stats"),
 			Details: err.Error(),
 		})
 		return
 	}
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Usage stats retrieved",
+		Message: localize(c, "Usage stats retrieved"),
 		Data:    usage,
 	})
 }
 
+// AdminPoolStats returns connection pool statistics for the Oracle and app DB pools
+func (h *APIHandler) AdminPoolStats(c *gin.Context) {
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Pool stats retrieved"),
+		Data:    h.poolStats(),
+	})
+}
+
 // GetDatabaseInfo returns database information
 func (h *APIHandler) GetDatabaseInfo(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -1169,7 +1223,7 @@ func (h *APIHandler) GetDatabaseInfo(c *gin.Context) {
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 			Code:    http.StatusInternalServerError,
-			Message: "Failed to get database info",
+			Message: localize(c, "Failed to get database info"),
 			Details: err.Error(),
 		})
 		return
@@ -1178,7 +1232,7 @@ func (h *APIHandler) GetDatabaseInfo(c *gin.Context) {
 
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Database info retrieved successfully",
+		Message: localize(c, "Database info retrieved successfully"),
 		Data:    info,
 	})
 }
@@ -1190,7 +1244,7 @@ func (h *APIHandler) SQLWebSocket(c *gin.Context) {
 		h.logger.Warn("WebSocket missing token", zap.String("ip", c.ClientIP()))
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 			Code:    http.StatusUnauthorized,
-			Message: "Missing token",
+			Message: localize(c, "Missing token"),
 		})
 		return
 	}
@@ -1200,7 +1254,7 @@ func (h *APIHandler) SQLWebSocket(c *gin.Context) {
 		h.logger.Warn("WebSocket invalid token", zap.String("ip", c.ClientIP()), zap.Error(err))
 		c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 			Code:    http.StatusUnauthorized,
-			Message: "Invalid token",
+			Message: localize(c, "Invalid token"),
 			Details: err.Error(),
 		})
 		return
@@ -1225,19 +1279,20 @@ func (h *APIHandler) SQLWebSocket(c *gin.Context) {
 		h.logger.Info("WebSocket connection closed", zap.String("user_id", claims.UserID))
 	}()
 
-	var req models.SQLGenerateRequest
+	// Ask mode reads page_size too; the rest is a generate request.
+	var req models.AskRequest
 	if err := conn.ReadJSON(&req); err != nil {
 		h.logger.Warn("WebSocket payload invalid",
 			zap.String("user_id", claims.UserID),
 			zap.Error(err))
-		h.writeWSError(conn, "Invalid request payload")
+		h.writeWSError(conn, localize(c, "Invalid request payload"))
 		return
 	}
 
 	req.Query = strings.TrimSpace(req.Query)
 	if req.Query == "" {
 		h.logger.Warn("WebSocket empty query", zap.String("user_id", claims.UserID))
-		h.writeWSError(conn, "Query is required")
+		h.writeWSError(conn, localize(c, "Query is required"))
 		return
 	}
 
@@ -1254,7 +1309,12 @@ func (h *APIHandler) SQLWebSocket(c *gin.Context) {
 		zap.String("request_id", req.RequestID),
 	)
 
-	h.handleWebSocketGeneration(conn, claims.UserID, &req)
+	locale := resolveLocale(claims.Locale, c.GetHeader("Accept-Language"))
+	if strings.EqualFold(strings.TrimSpace(req.Mode), "ask") {
+		h.handleWebSocketAsk(conn, locale, claims.UserID, claims.Username, &req)
+		return
+	}
+	h.handleWebSocketGeneration(conn, locale, claims.UserID, claims.Username, &req.SQLGenerateRequest)
 }
 
 // ListSessions returns available memory sessions for the user
@@ -1262,7 +1322,7 @@ func (h *APIHandler) ListSessions(c *gin.Context) {
 	if h.memoryStore == nil {
 		c.JSON(http.StatusOK, models.SuccessResponse{
 			Code:    http.StatusOK,
-			Message: "Memory store disabled",
+			Message: localize(c, "Memory store disabled"),
 			Data:    map[string]string{},
 		})
 		return
@@ -1271,7 +1331,7 @@ func (h *APIHandler) ListSessions(c *gin.Context) {
 	sessions := h.memoryStore.ListSessions(targetUser)
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Sessions retrieved",
+		Message: localize(c, "Sessions retrieved"),
 		Data:    sessions,
 	})
 }
@@ -1281,7 +1341,7 @@ func (h *APIHandler) GetSessionMemory(c *gin.Context) {
 	if h.memoryStore == nil {
 		c.JSON(http.StatusOK, models.SuccessResponse{
 			Code:    http.StatusOK,
-			Message: "Memory store disabled",
+			Message: localize(c, "Memory store disabled"),
 			Data:    []memory.Entry{},
 		})
 		return
@@ -1291,7 +1351,7 @@ func (h *APIHandler) GetSessionMemory(c *gin.Context) {
 	entries := h.memoryStore.GetSession(targetUser, sessionID)
 	c.JSON(http.StatusOK, models.SuccessResponse{
 		Code:    http.StatusOK,
-		Message: "Session history retrieved",
+		Message: localize(c, "Session history retrieved"),
 		Data:    entries,
 	})
 }
@@ -1308,6 +1368,27 @@ func scopedUserID(c *gin.Context) string {
 	return userID
 }
 
+// withSessionIdentity tags ctx so the Oracle session running the query is
+// attributed to the calling user (client identifier, client info, module/action).
+func withSessionIdentity(ctx context.Context, c *gin.Context, requestID, action string) context.Context {
+	return db.WithSessionIdentity(ctx, db.SessionIdentity{
+		UserID:    c.GetString("user_id"),
+		Username:  c.GetString("username"),
+		RequestID: requestID,
+		Action:    action,
+	})
+}
+
+// withLLMScope attributes LLM token usage in ctx to the calling user and request.
+func withLLMScope(ctx context.Context, userID, username, sessionID, requestID string) context.Context {
+	return llm.WithCallScope(ctx, llm.CallScope{
+		UserID:    userID,
+		Username:  username,
+		SessionID: sessionID,
+		RequestID: requestID,
+	})
+}
+
 func isAdmin(c *gin.Context) bool {
 	return strings.EqualFold(getUserRole(c), "admin")
 }
@@ -1435,6 +1516,64 @@ func (h *APIHandler) getDatabaseSchemaContext(ctx context.Context, tableNames st
 	return builder.String(), nil
 }
 
+func (h *APIHandler) newHealthChecker() *health.Checker {
+	timeout := 5 * time.Second
+	critical := map[string]bool{}
+	catalogMaxAge := 24 * time.Hour
+	checkLLM := false
+	if h.cfg != nil {
+		if h.cfg.HealthCheckTimeoutSec > 0 {
+			timeout = time.Duration(h.cfg.HealthCheckTimeoutSec) * time.Second
+		}
+		for _, name := range h.cfg.HealthCriticalChecks {
+			critical[strings.ToLower(strings.TrimSpace(name))] = true
+		}
+		if h.cfg.HealthCatalogMaxAgeSec > 0 {
+			catalogMaxAge = time.Duration(h.cfg.HealthCatalogMaxAgeSec) * time.Second
+		}
+		checkLLM = h.cfg.HealthCheckLLM
+	}
+
+	checker := health.NewChecker(timeout)
+	checker.Register("oracle", critical["oracle"], func(ctx context.Context) error {
+		if h.dbClient == nil {
+			return fmt.Errorf("oracle client not configured")
+		}
+		return h.dbClient.TestConnection(ctx)
+	})
+	checker.Register("app_db", critical["app_db"], func(ctx context.Context) error {
+		if h.appDB == nil {
+			return fmt.Errorf("app database not configured")
+		}
+		return h.appDB.PingContext(ctx)
+	})
+	checker.Register("schema_catalog", critical["schema_catalog"], func(ctx context.Context) error {
+		if h.dbClient == nil {
+			return fmt.Errorf("oracle client not configured")
+		}
+		refreshedAt, count := h.dbClient.CatalogFreshness()
+		if refreshedAt.IsZero() {
+			return fmt.Errorf("schema catalog has not been loaded yet")
+		}
+		if age := time.Since(refreshedAt); age > catalogMaxAge {
+			return fmt.Errorf("schema catalog is stale: last refreshed %s ago", age.Round(time.Second))
+		}
+		if count == 0 {
+			return fmt.Errorf("schema catalog is empty")
+		}
+		return nil
+	})
+	if checkLLM {
+		checker.Register("llm", critical["llm"], func(ctx context.Context) error {
+			if h.llmClient == nil {
+				return fmt.Errorf("llm client not configured")
+			}
+			return h.llmClient.Ping(ctx)
+		})
+	}
+	return checker
+}
+
 func (h *APIHandler) logAuditTrail(userID, action, sql string, success bool, errMsg string) {
 	status := "SUCCESS"
 	if !success {
@@ -1551,19 +1690,7 @@ func buildMemoryContext(entries []memory.Entry) string {
 	if len(entries) == 0 {
 		return ""
 	}
-	blocks := make([]string, 0, len(entries))
-	for idx, entry := range entries {
-		block := fmt.Sprintf(
-			"%d) USER: %s\n   SQL: %s",
-			idx+1,
-			truncateForMemory(entry.Query, maxMemoryQueryChars),
-			truncateForMemory(entry.SQL, maxMemorySQLChars),
-		)
-		if entry.Reasoning != "" {
-			block += "\n   REASONING: " + truncateForMemory(entry.Reasoning, maxMemoryReasoningChars)
-		}
-		blocks = append(blocks, block)
-	}
+	blocks := memoryBlocks(entries)
 
 	contextText := strings.Join(blocks, "\n")
 	if len(contextText) <= maxMemoryContextChars {
@@ -1585,7 +1712,7 @@ func buildMemoryContext(entries []memory.Entry) string {
 		))
 	}
 	var compact []string
-	compact = append(compact, fmt.Sprintf("已压缩的历史（共 %d 条）：%s", compressedCount, strings.Join(summaryParts, " | ")))
+	compact = append(compact, fmt.Sprintf("COMPRESSED EARLIER TURNS (%d): %s", compressedCount, strings.Join(summaryParts, " | ")))
 	compact = append(compact, blocks[len(blocks)-keep:]...)
 	compactText := strings.Join(compact, "\n")
 	if len(compactText) > maxMemoryContextChars {
@@ -1594,19 +1721,45 @@ func buildMemoryContext(entries []memory.Entry) string {
 	return compactText
 }
 
+// memoryBlocks renders each entry as a numbered turn for prompts.
+func memoryBlocks(entries []memory.Entry) []string {
+	blocks := make([]string, 0, len(entries))
+	for idx, entry := range entries {
+		switch entry.Source {
+		case memorySourceClarification:
+			blocks = append(blocks, fmt.Sprintf("%d) USER: %s\n   ASSISTANT ASKED: %s", idx+1,
+				truncateForMemory(entry.Query, maxMemoryQueryChars), truncateForMemory(entry.Reasoning, maxMemoryReasoningChars)))
+			continue
+		case memorySourceClarifyAnswer:
+			blocks = append(blocks, fmt.Sprintf("%d) USER ANSWERED: %s", idx+1, truncateForMemory(entry.Query, maxMemoryQueryChars)))
+			continue
+		}
+		block := fmt.Sprintf(
+			"%d) USER: %s\n   SQL: %s",
+			idx+1,
+			truncateForMemory(entry.Query, maxMemoryQueryChars),
+			truncateForMemory(entry.SQL, maxMemorySQLChars),
+		)
+		if entry.Reasoning != "" {
+			block += "\n   REASONING: " + truncateForMemory(entry.Reasoning, maxMemoryReasoningChars)
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
+// appendMemory remembers a finished generation and records it for feedback.
 func (h *APIHandler) appendMemory(userID, sessionID, query string, resp *models.SQLGenerateResponse) {
-	if h.memoryStore == nil || resp == nil {
+	if resp == nil {
 		return
 	}
-	entry := memory.Entry{
+	memoryID := h.appendMemoryEntry(userID, sessionID, memory.Entry{
 		Query:     query,
 		SQL:       resp.SQL,
 		Reasoning: resp.Reasoning,
 		Source:    resp.Source,
-	}
-	if err := h.memoryStore.Append(userID, sessionID, entry); err != nil {
-		h.logger.Warn("Failed to persist memory entry", zap.Error(err))
-	}
+	})
+	h.recordGeneration(userID, sessionID, query, resp, memoryID)
 }
 
 func convertReportToHistory(report *reports.Report) *models.SQLHistoryRecord {
@@ -1648,11 +1801,11 @@ func (h *APIHandler) completeProgress(requestID, message string) {
 	h.progressStore.Complete(requestID, message)
 }
 
-func (h *APIHandler) failProgress(requestID, errMsg string) {
+func (h *APIHandler) failProgress(locale, requestID, errMsg string) {
 	if h.progressStore == nil || requestID == "" {
 		return
 	}
-	h.progressStore.Fail(requestID, errMsg)
+	h.progressStore.Fail(requestID, i18n.T(locale, "Request failed"), errMsg)
 }
 
 func truncateForMemory(text string, max int) string {
@@ -1667,11 +1820,15 @@ func truncateForMemory(text string, max int) string {
 	return string(runes[:max]) + "..."
 }
 
-func (h *APIHandler) handleWebSocketGeneration(conn *websocket.Conn, userID string, req *models.SQLGenerateRequest) {
+func (h *APIHandler) handleWebSocketGeneration(conn *websocket.Conn, locale, userID, username string, req *models.SQLGenerateRequest) {
 	sessionID := strings.TrimSpace(req.SessionID)
 	if sessionID == "" {
 		sessionID = userID
 	}
+	// Feedback and progress are keyed by the request ID.
+	if strings.TrimSpace(req.RequestID) == "" {
+		req.RequestID = uuid.New().String()
+	}
 	start := time.Now()
 	success := false
 	metricExtra := map[string]interface{}{"session_id": sessionID}
@@ -1679,65 +1836,34 @@ func (h *APIHandler) handleWebSocketGeneration(conn *websocket.Conn, userID stri
 		h.recordMetric("generate_ws", start, success, metricExtra)
 	}()
 
-	h.writeWSProgress(conn, "received", "已收到生成请求")
+	h.writeWSProgress(conn, "received", i18n.T(locale, "Generation request received"))
 	h.saveChatMessage(userID, sessionID, "user", req.Query)
 
-	if h.templateSvc != nil {
-		if tpl := h.templateSvc.Match(req.Query); tpl != nil {
-			h.writeWSProgress(conn, "template_matched", fmt.Sprintf("命中模版：%s", tpl.Name))
-			resp := &models.SQLGenerateResponse{
-				SQL:        tpl.SQL,
-				Reasoning:  fmt.Sprintf("命中内置报表模版：%s", tpl.Name),
-				Source:     "template",
-				TemplateID: tpl.ID,
-				UsedMemory: false,
-				RequestID:  req.RequestID,
-			}
-			h.appendMemory(userID, sessionID, req.Query, resp)
-			h.saveChatMessage(userID, sessionID, "assistant", formatSQLChatMessage(resp))
-			metricExtra["template_id"] = tpl.ID
-			success = true
-			h.writeWSComplete(conn, resp)
-			return
-		}
-	}
-
 	ctx, cancel := context.WithTimeout(context.Background(), h.generateTimeout)
 	defer cancel()
-
-	h.writeWSProgress(conn, "prepare_context", "正在加载数据库元数据")
-	schemaContext, err := h.getDatabaseSchemaContext(ctx, req.TableNames)
-	if err != nil {
-		h.logger.Warn("Failed to get schema context", zap.Error(err))
-		schemaContext = "Error retrieving schema"
-	}
-
-	var memEntries []memory.Entry
-	if h.memoryStore != nil {
-		memEntries = h.memoryStore.GetRecent(userID, sessionID, 12)
-	}
-	memoryContext := buildMemoryContext(memEntries)
-	if len(memEntries) > 0 {
-		h.writeWSProgress(conn, "memory_loaded", fmt.Sprintf("复用 %d 条历史", len(memEntries)))
-	}
-
-	h.writeWSProgress(conn, "llm_call", "LLM 正在生成 SQL")
-	resp, err := h.llmClient.GenerateSQLStream(ctx, req, schemaContext, memoryContext, func(chunk string) {
-		h.writeWSChunk(conn, chunk, false)
+	ctx = withLLMScope(ctx, userID, username, sessionID, req.RequestID)
+	ctx = i18n.WithLocale(ctx, locale)
+
+	gen, err := h.runGeneration(ctx, userID, username, sessionID, req.RequestID, req, generationHooks{
+		progress: func(stage, message string) { h.writeWSProgress(conn, stage, message) },
+		chunk:    func(chunk string) { h.writeWSChunk(conn, chunk, false) },
+		agentStep: func(step models.AgentStep) {
+			_ = conn.WriteJSON(wsMessage{Type: "agent_step", Step: &step})
+		},
+		repair: func(attempt models.RepairAttempt) {
+			_ = conn.WriteJSON(wsMessage{Type: "repair_attempt", Attempt: &attempt})
+		},
+		extra: metricExtra,
 	})
 	if err != nil {
-		metricExtra["error"] = err.Error()
 		h.writeWSError(conn, err.Error())
 		return
 	}
-	resp.Source = "llm"
-	resp.UsedMemory = len(memEntries) > 0
-	resp.RequestID = req.RequestID
-
-	h.appendMemory(userID, sessionID, req.Query, resp)
-	h.saveChatMessage(userID, sessionID, "assistant", formatSQLChatMessage(resp))
-	success = true
-	h.writeWSComplete(conn, resp)
+	if gen.hasSQL() {
+		h.saveChatMessage(userID, sessionID, "assistant", formatSQLChatMessage(locale, gen.resp))
+	}
+	success = gen.outcome != generatedGuidance
+	h.writeWSComplete(conn, gen.resp)
 }
 
 func (h *APIHandler) writeWSProgress(conn *websocket.Conn, stage, message string) {
@@ -1763,6 +1889,7 @@ func (h *APIHandler) writeWSComplete(conn *websocket.Conn, resp *models.SQLGener
 		Reasoning:  resp.Reasoning,
 		TemplateID: resp.TemplateID,
 		UsedMemory: resp.UsedMemory,
+		Result:     resp,
 	})
 }
 
@@ -1782,7 +1909,7 @@ func (h *APIHandler) saveChatMessage(userID, sessionID, role, content string) {
 	}
 }
 
-func formatSQLChatMessage(resp *models.SQLGenerateResponse) string {
+func formatSQLChatMessage(locale string, resp *models.SQLGenerateResponse) string {
 	if resp == nil {
 		return ""
 	}
@@ -1792,14 +1919,26 @@ func formatSQLChatMessage(resp *models.SQLGenerateResponse) string {
 		if message != "" {
 			message += "\n\n"
 		}
-		message += "Reasoning:\n" + reasoning
+		message += i18n.T(locale, "Reasoning:") + "\n" + reasoning
 	}
 	if message == "" {
-		message = "生成结果已返回，请查看上方提示。"
+		message = i18n.T(locale, "The result has been returned; see the hints above.")
 	}
 	return message
 }
 
+// guidanceIssue is what the model said when it could not produce usable SQL.
+func guidanceIssue(resp *models.SQLGenerateResponse) string {
+	issue := strings.TrimSpace(resp.SQL)
+	if explanation := strings.TrimSpace(resp.Explanation); explanation != "" {
+		if issue != "" {
+			issue += "\n"
+		}
+		issue += explanation
+	}
+	return issue
+}
+
 func (h *APIHandler) needsGuidance(sql string) bool {
 	sql = strings.TrimSpace(sql)
 	if sql == "" {
@@ -1818,13 +1957,15 @@ func (h *APIHandler) needsGuidance(sql string) bool {
 	return false
 }
 
-func (h *APIHandler) generateGuidanceResponse(req models.SQLGenerateRequest, schemaContext, issue string, requestID string) *models.SQLGenerateResponse {
+// generateGuidanceResponse runs on its own timeout (the generation deadline
+// may already have passed) but keeps parent's values for LLM accounting.
+func (h *APIHandler) generateGuidanceResponse(parent context.Context, req models.SQLGenerateRequest, schemaContext, issue string, requestID string) *models.SQLGenerateResponse {
 	if h.llmClient == nil {
 		return nil
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(parent), 30*time.Second)
 	defer cancel()
-	hint, err := h.llmClient.GenerateGuidance(ctx, req.Query, schemaContext, issue)
+	hint, err := h.llmClient.GenerateGuidance(ctx, req.Query, schemaContext, issue, languageOf(parent))
 	if err != nil {
 		h.logger.Warn("Failed to get guidance", zap.Error(err))
 		return nil
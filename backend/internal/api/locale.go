@@ -0,0 +1,45 @@
+package api
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yourusername/db_asst/internal/i18n"
+)
+
+// requestLocale picks the language for responses to c: the user's profile
+// locale, then Accept-Language, then DEFAULT_LOCALE.
+func requestLocale(c *gin.Context) string {
+	return resolveLocale(c.GetString("locale"), c.GetHeader("Accept-Language"))
+}
+
+func resolveLocale(profile, acceptLanguage string) string {
+	if locale := i18n.Match(profile); locale != "" {
+		return locale
+	}
+	if locale := i18n.FromAcceptLanguage(acceptLanguage); locale != "" {
+		return locale
+	}
+	return i18n.Default()
+}
+
+// localize translates message for the request.
+func localize(c *gin.Context, message string, args ...interface{}) string {
+	return i18n.T(requestLocale(c), message, args...)
+}
+
+// languageOf names the locale carried by ctx, or the default locale, for
+// instructing the LLM which language to answer in.
+func languageOf(ctx context.Context) string {
+	locale := i18n.FromContext(ctx)
+	if locale == "" {
+		locale = i18n.Default()
+	}
+	return i18n.LanguageName(locale)
+}
+
+// tr translates message for the locale carried by ctx.
+func tr(ctx context.Context, message string, args ...interface{}) string {
+	return i18n.T(i18n.FromContext(ctx), message, args...)
+}
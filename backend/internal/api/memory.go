@@ -0,0 +1,207 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/memory"
+)
+
+const (
+	// memoryWindow is how many recent turns are read for the prompt.
+	memoryWindow = 12
+	// maxUncoveredMemoryEntries bounds the turns read after the session summary.
+	maxUncoveredMemoryEntries = 40
+	// maxSummaryBatchEntries bounds the turns folded into the summary per LLM call.
+	maxSummaryBatchEntries = 20
+	// summaryRetryBase and summaryRetryMax bound the wait before a session
+	// whose summary failed is summarized again; it doubles per failure.
+	summaryRetryBase = 30 * time.Second
+	summaryRetryMax  = 30 * time.Minute
+)
+
+// summaryBackoff tracks consecutive summary failures of a session.
+type summaryBackoff struct {
+	failures int
+	until    time.Time
+}
+
+// loadMemory builds the conversation memory for a generation prompt and
+// returns it with the number of turns it covers. When the turns not yet in
+// the session summary exceed the memory budget, the older ones are folded
+// into the summary by the LLM; if that fails they are truncated instead and
+// the session is not summarized again until its backoff has passed.
+func (h *APIHandler) loadMemory(ctx context.Context, userID, sessionID string, progress func(stage, message string)) (string, int) {
+	if h.memoryStore == nil {
+		return "", 0
+	}
+	var summary *memory.Summary
+	var entries []memory.Entry
+	if h.summaryEnabled() {
+		summary, _ = h.memoryStore.GetSummary(userID, sessionID)
+		entries = h.uncoveredMemory(userID, sessionID, summary)
+		if needsSummary(summary, entries) && h.summaryDue(userID, sessionID) {
+			progress("memory_summary", tr(ctx, "Summarizing earlier conversation"))
+			var err error
+			summary, entries, err = h.foldMemory(ctx, userID, sessionID, summary, entries)
+			retry := h.summaryResult(userID, sessionID, err)
+			if err != nil {
+				h.logger.Warn("Failed to refresh session summary",
+					zap.String("user_id", userID),
+					zap.String("session_id", sessionID),
+					zap.Duration("retry_in", retry),
+					zap.Error(err))
+			}
+		}
+		if len(entries) > memoryWindow {
+			entries = entries[len(entries)-memoryWindow:]
+		}
+	} else {
+		entries = h.memoryStore.GetRecent(userID, sessionID, memoryWindow)
+	}
+
+	turns := len(entries)
+	if summary != nil {
+		turns += summary.Turns
+	}
+	if turns > 0 {
+		progress("memory_loaded", tr(ctx, "Loaded %d history entries", turns))
+	}
+	return sessionMemoryContext(summary, entries), turns
+}
+
+func (h *APIHandler) summaryEnabled() bool {
+	return h.cfg != nil && h.cfg.MemorySummary && h.llmClient != nil
+}
+
+func (h *APIHandler) summaryMaxChars() int {
+	if h.cfg != nil && h.cfg.MemorySummaryMaxChars > 0 {
+		return h.cfg.MemorySummaryMaxChars
+	}
+	return 2000
+}
+
+// summaryDue reports whether the session is not backing off after a failed
+// summary.
+func (h *APIHandler) summaryDue(userID, sessionID string) bool {
+	v, ok := h.summaryBackoffs.Load(userID + "/" + sessionID)
+	return !ok || time.Now().After(v.(summaryBackoff).until)
+}
+
+// summaryResult records the outcome of summarizing the session and returns
+// how long to wait before trying again after a failure.
+func (h *APIHandler) summaryResult(userID, sessionID string, err error) time.Duration {
+	key := userID + "/" + sessionID
+	if err == nil {
+		h.summaryBackoffs.Delete(key)
+		return 0
+	}
+	var state summaryBackoff
+	if v, ok := h.summaryBackoffs.Load(key); ok {
+		state = v.(summaryBackoff)
+	}
+	wait := summaryRetryBase
+	for i := 0; i < state.failures && wait < summaryRetryMax; i++ {
+		wait *= 2
+	}
+	if wait > summaryRetryMax {
+		wait = summaryRetryMax
+	}
+	state.failures++
+	state.until = time.Now().Add(wait)
+	h.summaryBackoffs.Store(key, state)
+	return wait
+}
+
+// uncoveredMemory returns the turns after those in summary, oldest first.
+// Without a summary, or when too many have piled up (e.g. summaries were
+// turned off for a while), the most recent maxUncoveredMemoryEntries turns
+// are returned and older ones are skipped.
+func (h *APIHandler) uncoveredMemory(userID, sessionID string, summary *memory.Summary) []memory.Entry {
+	if summary == nil {
+		return h.memoryStore.GetRecent(userID, sessionID, maxUncoveredMemoryEntries)
+	}
+	entries := h.memoryStore.GetSince(userID, sessionID, summary.CoveredUntil, maxUncoveredMemoryEntries)
+	if len(entries) >= maxUncoveredMemoryEntries {
+		return h.memoryStore.GetRecent(userID, sessionID, maxUncoveredMemoryEntries)
+	}
+	// Turns stored in the same second as the last summarized one are
+	// returned again; skip up to and including it.
+	for i, entry := range entries {
+		if entry.ID == summary.LastEntryID {
+			return entries[i+1:]
+		}
+	}
+	return entries
+}
+
+// needsSummary reports whether entries should be folded into the summary:
+// when they no longer fit the memory budget next to it, or when older turns
+// would otherwise drop out of the window without being summarized.
+func needsSummary(summary *memory.Summary, entries []memory.Entry) bool {
+	if len(entries) <= minVerboseMemoryEntriesKeep {
+		return false
+	}
+	if len(entries) > memoryWindow {
+		return true
+	}
+	budget := maxMemoryContextChars
+	if summary != nil {
+		budget -= len(summary.Summary)
+	}
+	return len(strings.Join(memoryBlocks(entries), "\n")) > budget
+}
+
+// foldMemory folds all but the last few entries into the session summary,
+// saving it after each batch, and returns the summary and the entries left.
+// On error the state saved so far is returned with it.
+func (h *APIHandler) foldMemory(ctx context.Context, userID, sessionID string, summary *memory.Summary, entries []memory.Entry) (*memory.Summary, []memory.Entry, error) {
+	maxChars := h.summaryMaxChars()
+	for len(entries) > minVerboseMemoryEntriesKeep {
+		batch := len(entries) - minVerboseMemoryEntriesKeep
+		if batch > maxSummaryBatchEntries {
+			batch = maxSummaryBatchEntries
+		}
+		previous, turns := "", 0
+		if summary != nil {
+			previous, turns = summary.Summary, summary.Turns
+		}
+		text, version, err := h.llmClient.SummarizeSession(ctx, previous, strings.Join(memoryBlocks(entries[:batch]), "\n"), maxChars)
+		if err != nil {
+			return summary, entries, err
+		}
+		if text == "" {
+			return summary, entries, errors.New("empty session summary")
+		}
+		last := entries[batch-1]
+		next := &memory.Summary{
+			Summary:       truncateForMemory(text, maxChars),
+			LastEntryID:   last.ID,
+			CoveredUntil:  last.CreatedAt,
+			Turns:         turns + batch,
+			PromptVersion: version,
+		}
+		if err := h.memoryStore.SaveSummary(userID, sessionID, *next); err != nil {
+			return summary, entries, err
+		}
+		summary, entries = next, entries[batch:]
+	}
+	return summary, entries, nil
+}
+
+// sessionMemoryContext puts the session summary ahead of the recent turns.
+func sessionMemoryContext(summary *memory.Summary, entries []memory.Entry) string {
+	recent := buildMemoryContext(entries)
+	if summary == nil || strings.TrimSpace(summary.Summary) == "" {
+		return recent
+	}
+	text := "SESSION SUMMARY (earlier turns):\n" + summary.Summary
+	if recent != "" {
+		text += "\n\nRECENT TURNS:\n" + recent
+	}
+	return text
+}
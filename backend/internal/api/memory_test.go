@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/yourusername/db_asst/internal/memory"
+)
+
+func TestNeedsSummary(t *testing.T) {
+	entries := func(n int) []memory.Entry {
+		return make([]memory.Entry, n)
+	}
+	tests := []struct {
+		name    string
+		summary *memory.Summary
+		entries []memory.Entry
+		want    bool
+	}{
+		{"few turns", nil, entries(minVerboseMemoryEntriesKeep), false},
+		{"window without summary", nil, entries(memoryWindow), false},
+		{"past window without summary", nil, entries(memoryWindow + 1), true},
+		{"past window with summary", &memory.Summary{Summary: "s"}, entries(memoryWindow + 1), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := needsSummary(tt.summary, tt.entries); got != tt.want {
+				t.Errorf("needsSummary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummaryBackoff(t *testing.T) {
+	h := &APIHandler{}
+	if !h.summaryDue("u", "s") {
+		t.Fatal("new session should be due")
+	}
+	fail := errors.New("llm down")
+	if wait := h.summaryResult("u", "s", fail); wait != summaryRetryBase {
+		t.Errorf("first wait = %v, want %v", wait, summaryRetryBase)
+	}
+	if h.summaryDue("u", "s") {
+		t.Error("session should back off after a failure")
+	}
+	if !h.summaryDue("u", "other") {
+		t.Error("backoff should be per session")
+	}
+	if wait := h.summaryResult("u", "s", fail); wait != 2*summaryRetryBase {
+		t.Errorf("second wait = %v, want %v", wait, 2*summaryRetryBase)
+	}
+	for i := 0; i < 70; i++ {
+		h.summaryResult("u", "s", fail)
+	}
+	if wait := h.summaryResult("u", "s", fail); wait != summaryRetryMax {
+		t.Errorf("wait = %v, want cap %v", wait, summaryRetryMax)
+	}
+	h.summaryResult("u", "s", nil)
+	if !h.summaryDue("u", "s") {
+		t.Error("success should clear the backoff")
+	}
+}
+
+func TestBuildMemoryContextCompresses(t *testing.T) {
+	entries := make([]memory.Entry, 10)
+	for i := range entries {
+		entries[i] = memory.Entry{Query: "show orders", SQL: "SELECT " + strings.Repeat("X, ", 600) + "1 FROM ORDERS"}
+	}
+	text := buildMemoryContext(entries)
+	if !strings.HasPrefix(text, "COMPRESSED EARLIER TURNS (7): [1] show orders -> ") {
+		t.Errorf("context starts with %q", text[:80])
+	}
+	if len(text) > maxMemoryContextChars+len("...") {
+		t.Errorf("context has %d chars, budget %d", len(text), maxMemoryContextChars)
+	}
+}
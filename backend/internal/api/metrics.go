@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yourusername/db_asst/internal/db"
+)
+
+// Metrics exposes pool statistics and LLM circuit breaker state in Prometheus
+// text exposition format.
+func (h *APIHandler) Metrics(c *gin.Context) {
+	stats := h.poolStats()
+	var builder strings.Builder
+	writeMetric := func(name, kind, help string, value func(db.PoolStats) int64) {
+		builder.WriteString(fmt.Sprintf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind))
+		for _, s := range stats {
+			builder.WriteString(fmt.Sprintf("%s{pool=%q} %d\n", name, s.Name, value(s)))
+		}
+	}
+	writeMetric("db_asst_pool_max_open", "gauge", "Maximum number of open connections.", func(s db.PoolStats) int64 { return int64(s.MaxOpen) })
+	writeMetric("db_asst_pool_open", "gauge", "Established connections, in use and idle.", func(s db.PoolStats) int64 { return int64(s.Open) })
+	writeMetric("db_asst_pool_in_use", "gauge", "Connections currently in use.", func(s db.PoolStats) int64 { return int64(s.InUse) })
+	writeMetric("db_asst_pool_idle", "gauge", "Idle connections.", func(s db.PoolStats) int64 { return int64(s.Idle) })
+	writeMetric("db_asst_pool_wait_count_total", "counter", "Total number of connections waited for.", func(s db.PoolStats) int64 { return s.WaitCount })
+	writeMetric("db_asst_pool_wait_duration_ms_total", "counter", "Total time blocked waiting for a connection.", func(s db.PoolStats) int64 { return s.WaitDurationMs })
+
+	if h.llmClient != nil {
+		states := h.llmClient.BreakerStates()
+		backends := make([]string, 0, len(states))
+		for name := range states {
+			backends = append(backends, name)
+		}
+		sort.Strings(backends)
+		builder.WriteString("# HELP db_asst_llm_circuit_open Whether the LLM backend circuit breaker is open (1) or half-open (0.5).\n# TYPE db_asst_llm_circuit_open gauge\n")
+		for _, name := range backends {
+			value := "0"
+			switch states[name] {
+			case "open":
+				value = "1"
+			case "half_open":
+				value = "0.5"
+			}
+			builder.WriteString(fmt.Sprintf("db_asst_llm_circuit_open{backend=%q} %s\n", name, value))
+		}
+	}
+
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.String(http.StatusOK, builder.String())
+}
+
+func (h *APIHandler) poolStats() []db.PoolStats {
+	var stats []db.PoolStats
+	if h.dbClient != nil {
+		stats = append(stats, h.dbClient.PoolStats()...)
+	}
+	if h.appDB != nil {
+		stats = append(stats, db.NewPoolStats("app_db", h.appDB))
+	}
+	return stats
+}
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"strings"
 
@@ -19,7 +20,7 @@ func AuthMiddleware(jwtManager *auth.JWTManager, logger *zap.Logger) gin.Handler
 		if authHeader == "" {
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Code:    http.StatusUnauthorized,
-				Message: "Missing authorization header",
+				Message: localize(c, "Missing authorization header"),
 			})
 			c.Abort()
 			return
@@ -30,7 +31,7 @@ func AuthMiddleware(jwtManager *auth.JWTManager, logger *zap.Logger) gin.Handler
 		if len(parts) != 2 || parts[0] != "Bearer" {
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Code:    http.StatusUnauthorized,
-				Message: "Invalid authorization header format",
+				Message: localize(c, "Invalid authorization header format"),
 			})
 			c.Abort()
 			return
@@ -44,7 +45,7 @@ func AuthMiddleware(jwtManager *auth.JWTManager, logger *zap.Logger) gin.Handler
 			logger.Warn("Invalid token", zap.Error(err))
 			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
 				Code:    http.StatusUnauthorized,
-				Message: "Invalid or expired token",
+				Message: localize(c, "Invalid or expired token"),
 			})
 			c.Abort()
 			return
@@ -55,6 +56,7 @@ func AuthMiddleware(jwtManager *auth.JWTManager, logger *zap.Logger) gin.Handler
 		c.Set("username", claims.Username)
 		c.Set("email", claims.Email)
 		c.Set("role", claims.Role)
+		c.Set("locale", claims.Locale)
 
 		c.Next()
 	}
@@ -68,7 +70,28 @@ func AdminOnlyMiddleware() gin.HandlerFunc {
 		if strings.ToLower(role) != "admin" {
 			c.JSON(http.StatusForbidden, models.ErrorResponse{
 				Code:    http.StatusForbidden,
-				Message: "管理员权限不足",
+				Message: localize(c, "Admin privileges required"),
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// MetricsAuthMiddleware requires "Authorization: Bearer <token>" when token
+// is set, so scrapers can be given access without a user account
+func MetricsAuthMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			c.JSON(http.StatusUnauthorized, models.ErrorResponse{
+				Code:    http.StatusUnauthorized,
+				Message: localize(c, "Invalid metrics token"),
 			})
 			c.Abort()
 			return
@@ -85,7 +108,7 @@ func ErrorHandlingMiddleware(logger *zap.Logger) gin.HandlerFunc {
 				logger.Error("Panic recovered", zap.Any("error", err))
 				c.JSON(http.StatusInternalServerError, models.ErrorResponse{
 					Code:    http.StatusInternalServerError,
-					Message: "Internal server error",
+					Message: localize(c, "Internal server error"),
 				})
 			}
 		}()
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yourusername/db_asst/internal/i18n"
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+// GetProfile returns the caller's profile and the locale in effect.
+func (h *APIHandler) GetProfile(c *gin.Context) {
+	if h.userService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: localize(c, "User service unavailable"),
+		})
+		return
+	}
+	user, err := h.userService.GetUserByID(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:    http.StatusNotFound,
+			Message: localize(c, "User not found"),
+			Details: err.Error(),
+		})
+		return
+	}
+	user.Password = ""
+	c.Set("locale", user.Locale)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Profile retrieved"),
+		Data: models.ProfileResponse{
+			User:    *user,
+			Locale:  requestLocale(c),
+			Locales: i18n.Supported(),
+		},
+	})
+}
+
+// UpdateProfile changes the caller's locale and returns a new token carrying
+// it, so later requests use it without a database lookup.
+func (h *APIHandler) UpdateProfile(c *gin.Context) {
+	var req models.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: err.Error(),
+		})
+		return
+	}
+	if h.userService == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: localize(c, "User service unavailable"),
+		})
+		return
+	}
+	userID := c.GetString("user_id")
+	if req.Locale != nil {
+		locale := strings.TrimSpace(*req.Locale)
+		if locale != "" {
+			if locale = i18n.Match(locale); locale == "" {
+				c.JSON(http.StatusBadRequest, models.ErrorResponse{
+					Code:    http.StatusBadRequest,
+					Message: localize(c, "Unsupported locale"),
+					Details: "supported locales: " + strings.Join(i18n.Supported(), ", "),
+				})
+				return
+			}
+		}
+		if err := h.userService.UpdateLocale(userID, locale); err != nil {
+			c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+				Code:    http.StatusInternalServerError,
+				Message: localize(c, "Failed to update profile"),
+				Details: err.Error(),
+			})
+			return
+		}
+	}
+
+	user, err := h.userService.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, models.ErrorResponse{
+			Code:    http.StatusNotFound,
+			Message: localize(c, "User not found"),
+			Details: err.Error(),
+		})
+		return
+	}
+	user.Password = ""
+	token, expiresIn, err := h.jwtManager.GenerateToken(user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: localize(c, "Token generation failed"),
+		})
+		return
+	}
+	c.Set("locale", user.Locale)
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Profile updated"),
+		Data: models.ProfileResponse{
+			User:      *user,
+			Locale:    requestLocale(c),
+			Locales:   i18n.Supported(),
+			Token:     token,
+			ExpiresIn: expiresIn,
+		},
+	})
+}
@@ -0,0 +1,236 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/i18n"
+	"github.com/yourusername/db_asst/internal/models"
+	"github.com/yourusername/db_asst/internal/prompts"
+)
+
+type promptVersionRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Datasource  string `json:"datasource"`
+	Description string `json:"description"`
+	System      string `json:"system"`
+	Context     string `json:"context"`
+	User        string `json:"user" binding:"required"`
+	Activate    bool   `json:"activate"`
+}
+
+type promptPreviewRequest struct {
+	Name       string `json:"name" binding:"required"`
+	Datasource string `json:"datasource"`
+	TemplateID string `json:"template_id"`
+	// Draft sections; when User is set they are rendered instead of a stored version.
+	System     string `json:"system"`
+	Context    string `json:"context"`
+	User       string `json:"user"`
+	Query      string `json:"query"`
+	TableNames string `json:"table_names"`
+	SQL        string `json:"sql"`
+	Error      string `json:"error"`
+	Memory     string `json:"memory"`
+}
+
+// AdminListPrompts lists built-in and stored prompt template versions
+func (h *APIHandler) AdminListPrompts(c *gin.Context) {
+	list, err := h.llmClient.Prompts().List(strings.TrimSpace(c.Query("name")))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: localize(c, "Failed to load prompt templates"),
+			Details: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Prompt templates retrieved"),
+		Data: gin.H{
+			"datasource": h.llmClient.Datasource(),
+			"templates":  list,
+		},
+	})
+}
+
+// AdminCreatePrompt stores a new version of a prompt template
+func (h *APIHandler) AdminCreatePrompt(c *gin.Context) {
+	var req promptVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: err.Error(),
+		})
+		return
+	}
+	userID, _ := c.Get("user_id")
+	tpl := &prompts.Template{
+		Name:        strings.TrimSpace(req.Name),
+		Datasource:  req.Datasource,
+		Description: req.Description,
+		System:      req.System,
+		Context:     req.Context,
+		User:        req.User,
+	}
+	if id, ok := userID.(string); ok {
+		tpl.CreatedBy = id
+	}
+	if err := h.llmClient.Prompts().CreateVersion(tpl, req.Activate); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Failed to save prompt template"),
+			Details: err.Error(),
+		})
+		return
+	}
+	h.logger.Info("Prompt template version created",
+		zap.String("name", tpl.Name),
+		zap.String("datasource", tpl.Datasource),
+		zap.Int("version", tpl.Version),
+		zap.Bool("active", tpl.Active))
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Prompt template saved"),
+		Data:    tpl,
+	})
+}
+
+// AdminActivatePrompt makes a stored version the active one
+func (h *APIHandler) AdminActivatePrompt(c *gin.Context) {
+	tpl, err := h.llmClient.Prompts().Activate(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Failed to activate prompt template"),
+			Details: err.Error(),
+		})
+		return
+	}
+	h.logger.Info("Prompt template activated",
+		zap.String("name", tpl.Name),
+		zap.String("datasource", tpl.Datasource),
+		zap.Int("version", tpl.Version))
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Prompt template activated"),
+		Data:    tpl,
+	})
+}
+
+// AdminResetPrompt deactivates stored versions so the built-in template applies
+func (h *APIHandler) AdminResetPrompt(c *gin.Context) {
+	name := c.Param("name")
+	datasource := strings.TrimSpace(c.Query("datasource"))
+	if err := h.llmClient.Prompts().Reset(name, datasource); err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: localize(c, "Failed to reset prompt template"),
+			Details: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Prompt template reset to builtin"),
+	})
+}
+
+// AdminPreviewPrompt renders a stored version, a draft or the active template
+// with the real schema context, without calling the LLM
+func (h *APIHandler) AdminPreviewPrompt(c *gin.Context) {
+	var req promptPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: err.Error(),
+		})
+		return
+	}
+	svc := h.llmClient.Prompts()
+	datasource := strings.TrimSpace(req.Datasource)
+	if datasource == "" {
+		datasource = h.llmClient.Datasource()
+	}
+
+	var tpl *prompts.Template
+	var err error
+	switch {
+	case strings.TrimSpace(req.User) != "":
+		tpl = &prompts.Template{Name: req.Name, Datasource: datasource, System: req.System, Context: req.Context, User: req.User}
+		err = prompts.Validate(tpl)
+	case req.TemplateID != "":
+		tpl, err = svc.Get(req.TemplateID)
+	default:
+		tpl, err = svc.Resolve(req.Name, datasource)
+		if tpl != nil && err != nil {
+			h.logger.Warn("Prompt store lookup failed, previewing builtin", zap.Error(err))
+			err = nil
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Failed to load prompt template"),
+			Details: err.Error(),
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	schemaContext, err := h.getDatabaseSchemaContext(ctx, req.TableNames)
+	if err != nil {
+		h.logger.Warn("Failed to get schema context", zap.Error(err))
+		schemaContext = "Error retrieving schema"
+	}
+
+	var data interface{}
+	switch tpl.Name {
+	case prompts.Debug:
+		data = prompts.DebugData{Schema: schemaContext, SQL: req.SQL, Error: req.Error}
+	case prompts.Guidance:
+		data = prompts.GuidanceData{Query: req.Query, Schema: schemaContext, Issue: req.Error, Language: i18n.LanguageName(requestLocale(c))}
+	case prompts.Explain:
+		data = prompts.ExplainData{SQL: req.SQL, Context: req.Query, Language: i18n.LanguageName(requestLocale(c))}
+	case prompts.Chart:
+		data = prompts.ChartData{Query: req.Query, SQL: req.SQL, Sample: "(no rows)"}
+	case prompts.ResultSummary:
+		data = prompts.ResultSummaryData{Query: req.Query, SQL: req.SQL, Result: "(no rows)"}
+	case prompts.SessionSummary:
+		data = prompts.SessionSummaryData{Turns: req.Memory, MaxChars: h.summaryMaxChars()}
+	default:
+		data = prompts.SQLGenerationData{Schema: schemaContext, Memory: req.Memory, Query: req.Query}
+	}
+	rendered, err := prompts.RenderTemplate(tpl, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Failed to render prompt template"),
+			Details: err.Error(),
+		})
+		return
+	}
+
+	tokens := 0
+	for _, msg := range rendered.Messages {
+		tokens += h.llmClient.Provider().CountTokens(msg.Content)
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Prompt rendered"),
+		Data: gin.H{
+			"prompt_version":   rendered.Label(),
+			"messages":         rendered.Messages,
+			"estimated_tokens": tokens,
+		},
+	})
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/db"
+	"github.com/yourusername/db_asst/internal/executor"
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+const maxRepairRounds = 5
+
+var oraCodePattern = regexp.MustCompile(`ORA-\d{5}`)
+
+// repairRounds reports whether the execute-and-repair loop is on for req and
+// how many DebugSQL rounds it allows.
+func (h *APIHandler) repairRounds(req *models.SQLGenerateRequest) (int, bool) {
+	if h.sqlExecutor == nil || h.llmClient == nil {
+		return 0, false
+	}
+	enabled := h.cfg != nil && h.cfg.SQLAutoRepair
+	if req.AutoRepair != nil {
+		enabled = *req.AutoRepair
+	}
+	if !enabled {
+		return 0, false
+	}
+	rounds := req.MaxRepairRounds
+	if rounds <= 0 && h.cfg != nil {
+		rounds = h.cfg.SQLRepairMaxRounds
+	}
+	if rounds < 0 {
+		rounds = 0
+	}
+	if rounds > maxRepairRounds {
+		rounds = maxRepairRounds
+	}
+	return rounds, true
+}
+
+// repairSQL dry-runs resp.SQL and, while it fails with a validation or ORA-
+// error, asks DebugSQL for a fix, up to rounds times. resp is updated in place
+// with the last SQL tried, the attempt history and whether it passed. Errors
+// that are not about the SQL itself (timeouts, connection loss) end the loop
+// without repair. progress and onAttempt may be nil.
+func (h *APIHandler) repairSQL(ctx context.Context, identity db.SessionIdentity, resp *models.SQLGenerateResponse, schemaContext string, rounds int, progress func(stage, message string), onAttempt func(models.RepairAttempt)) {
+	if progress == nil {
+		progress = func(string, string) {}
+	}
+	mode := executor.DryRunLimit
+	timeout := 10 * time.Second
+	if h.cfg != nil {
+		if h.cfg.SQLRepairDryRun == executor.DryRunExplain {
+			mode = executor.DryRunExplain
+		}
+		if h.cfg.SQLRepairTimeoutSec > 0 {
+			timeout = time.Duration(h.cfg.SQLRepairTimeoutSec) * time.Second
+		}
+	}
+	identity.Action = "repair_dry_run"
+	dbCtx := db.WithSessionIdentity(ctx, identity)
+
+	sql := resp.SQL
+	for round := 0; ; round++ {
+		progress("repair_check", tr(ctx, "Checking SQL (round %d)", round+1))
+		attempt := models.RepairAttempt{Round: round, SQL: sql, Stage: "validate"}
+		started := time.Now()
+		err := h.sqlExecutor.ValidateSQL(sql)
+		if err == nil {
+			attempt.Stage = "dry_run"
+			err = h.sqlExecutor.CheckSQL(dbCtx, sql, mode, timeout)
+		}
+		attempt.DurationMs = time.Since(started).Milliseconds()
+		resp.SQL = sql
+
+		if err == nil {
+			attempt.Success = true
+			resp.Attempts = append(resp.Attempts, attempt)
+			resp.Validated = true
+			if onAttempt != nil {
+				onAttempt(attempt)
+			}
+			if round > 0 {
+				resp.Reasoning = strings.TrimSpace(resp.Reasoning + "\n" + tr(ctx, "(auto-repaired in %d rounds)", round))
+			}
+			return
+		}
+
+		attempt.Error = err.Error()
+		attempt.OracleCode = oraCodePattern.FindString(attempt.Error)
+		repairable := attempt.Stage == "validate" || attempt.OracleCode != ""
+		if !repairable || round >= rounds || ctx.Err() != nil {
+			resp.Attempts = append(resp.Attempts, attempt)
+			if onAttempt != nil {
+				onAttempt(attempt)
+			}
+			return
+		}
+
+		progress("repair_round", tr(ctx, "SQL check failed (%s), repairing", firstNonEmpty(attempt.OracleCode, attempt.Stage)))
+		fix, debugErr := h.llmClient.DebugSQL(ctx, &models.SQLDebugRequest{SQL: sql, Error: attempt.Error}, schemaContext)
+		if fix != nil {
+			attempt.Analysis = strings.TrimSpace(fix.AnalysisText)
+		}
+		resp.Attempts = append(resp.Attempts, attempt)
+		if onAttempt != nil {
+			onAttempt(attempt)
+		}
+		if debugErr != nil {
+			h.logger.Warn("SQL repair failed", zap.String("request_id", identity.RequestID), zap.Error(debugErr))
+			return
+		}
+		next := strings.TrimSpace(fix.SuggestedSQL)
+		if next == "" || next == sql {
+			return
+		}
+		sql = next
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
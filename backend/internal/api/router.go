@@ -1,6 +1,8 @@
 package api
 
 import (
+	"database/sql"
+
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 
@@ -8,18 +10,24 @@ import (
 	"github.com/yourusername/db_asst/internal/auth"
 	"github.com/yourusername/db_asst/internal/chat"
 	"github.com/yourusername/db_asst/internal/db"
+	"github.com/yourusername/db_asst/internal/examples"
 	"github.com/yourusername/db_asst/internal/executor"
+	"github.com/yourusername/db_asst/internal/feedback"
+	"github.com/yourusername/db_asst/internal/glossary"
 	"github.com/yourusername/db_asst/internal/llm"
 	"github.com/yourusername/db_asst/internal/memory"
 	"github.com/yourusername/db_asst/internal/monitor"
 	"github.com/yourusername/db_asst/internal/progress"
 	"github.com/yourusername/db_asst/internal/reports"
+	"github.com/yourusername/db_asst/internal/results"
 	"github.com/yourusername/db_asst/internal/templates"
+	"github.com/yourusername/db_asst/internal/usage"
 )
 
 func SetupRoutes(
 	router *gin.Engine,
 	dbClient *db.OracleClient,
+	appDB *sql.DB,
 	jwtManager *auth.JWTManager,
 	userService *auth.UserService,
 	sqlExecutor *executor.SQLExecutor,
@@ -29,12 +37,17 @@ func SetupRoutes(
 	reportStore *reports.Store,
 	chatStore *chat.Store,
 	monitorSvc *monitor.Monitor,
+	usageSvc *usage.Service,
+	glossarySvc *glossary.Service,
+	examplesSvc *examples.Service,
+	feedbackStore *feedback.Store,
 	progressStore *progress.Store,
+	resultCache *results.Cache,
 	cfg *config.Config,
 	logger *zap.Logger,
 ) {
 	// Create handler
-	handler := NewAPIHandler(dbClient, jwtManager, userService, sqlExecutor, llmClient, templateSvc, memoryStore, reportStore, chatStore, monitorSvc, progressStore, cfg, logger)
+	handler := NewAPIHandler(dbClient, appDB, jwtManager, userService, sqlExecutor, llmClient, templateSvc, memoryStore, reportStore, chatStore, monitorSvc, usageSvc, glossarySvc, examplesSvc, feedbackStore, progressStore, resultCache, cfg, logger)
 
 	// Apply global middleware
 	router.Use(CORSMiddleware())
@@ -43,6 +56,9 @@ func SetupRoutes(
 
 	// Health check (no auth required)
 	router.GET("/health", handler.Health)
+	router.GET("/health/live", handler.Liveness)
+	router.GET("/health/ready", handler.Readiness)
+	router.GET("/metrics", MetricsAuthMiddleware(cfg.MetricsToken), handler.Metrics)
 
 	// Auth routes (no auth required)
 	auth := router.Group("/api/auth")
@@ -59,8 +75,13 @@ func SetupRoutes(
 		sql := protected.Group("/sql")
 		{
 			sql.POST("/generate", handler.GenerateSQL)
+			sql.POST("/ask", handler.AskQuestion)
 			sql.POST("/execute", handler.ExecuteSQL)
 			sql.POST("/debug", handler.DebugSQL)
+			sql.POST("/explain-nl", handler.ExplainSQL)
+			sql.POST("/chart", handler.RecommendChart)
+			sql.POST("/transform", handler.TransformResult)
+			sql.POST("/feedback", handler.SubmitFeedback)
 			sql.POST("/export", handler.ExportSQLResult)
 			sql.POST("/save", handler.SaveSQL)
 			sql.GET("/history", handler.GetHistory)
@@ -76,6 +97,14 @@ func SetupRoutes(
 			db.GET("/info", handler.GetDatabaseInfo)
 		}
 
+		protected.GET("/profile", handler.GetProfile)
+		protected.PUT("/profile", handler.UpdateProfile)
+		protected.GET("/usage/me", handler.GetMyUsage)
+		protected.GET("/glossary", handler.ListGlossary)
+		protected.GET("/examples", handler.ListExamples)
+		protected.POST("/examples/promote", handler.PromoteExample)
+		protected.DELETE("/examples/:id", handler.DeleteExample)
+
 		chatGroup := protected.Group("/chat")
 		{
 			chatGroup.GET("/sessions", handler.ListChatSessions)
@@ -104,6 +133,18 @@ func SetupRoutes(
 	{
 		adminGroup.GET("/users", handler.AdminListUsers)
 		adminGroup.GET("/usage", handler.AdminUserUsage)
+		adminGroup.GET("/pools", handler.AdminPoolStats)
+		adminGroup.GET("/costs", handler.AdminCostReport)
+		adminGroup.GET("/costs/requests/:request_id", handler.AdminRequestCost)
+		adminGroup.GET("/prompts", handler.AdminListPrompts)
+		adminGroup.POST("/prompts", handler.AdminCreatePrompt)
+		adminGroup.POST("/prompts/preview", handler.AdminPreviewPrompt)
+		adminGroup.POST("/prompts/:id/activate", handler.AdminActivatePrompt)
+		adminGroup.DELETE("/prompts/active/:name", handler.AdminResetPrompt)
+		adminGroup.POST("/glossary", handler.AdminCreateGlossaryTerm)
+		adminGroup.PUT("/glossary/:id", handler.AdminUpdateGlossaryTerm)
+		adminGroup.DELETE("/glossary/:id", handler.AdminDeleteGlossaryTerm)
+		adminGroup.GET("/feedback/report", handler.AdminFeedbackReport)
 	}
 
 	ws := router.Group("/api/ws")
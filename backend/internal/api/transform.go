@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/models"
+	"github.com/yourusername/db_asst/internal/results"
+)
+
+// TransformResult filters, groups, pivots and sorts the buffered rows of a
+// previous execution, or rows sent inline, without querying the database
+// again. The full transformed result is buffered under a new execution ID so
+// transforms can be chained; the response carries the requested page.
+func (h *APIHandler) TransformResult(c *gin.Context) {
+	start := time.Now()
+	success := false
+	extra := map[string]interface{}{}
+	defer func() {
+		h.recordMetric("sql_transform", start, success, extra)
+	}()
+
+	var req models.TransformRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		extra["error"] = err.Error()
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: err.Error(),
+		})
+		return
+	}
+	if req.ExecutionID == "" && len(req.Columns) == 0 {
+		extra["error"] = "no result"
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid request"),
+			Details: "send execution_id, or columns and rows",
+		})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	source := &models.SQLExecuteResponse{Success: true, Columns: req.Columns, Rows: req.Rows}
+	sql := ""
+	if req.ExecutionID != "" {
+		entry, err := h.resultCache.Get(userID, req.ExecutionID)
+		if err != nil {
+			extra["error"] = err.Error()
+			status := http.StatusInternalServerError
+			if errors.Is(err, results.ErrNotFound) {
+				status = http.StatusNotFound
+			}
+			c.JSON(status, models.ErrorResponse{
+				Code:    status,
+				Message: localize(c, "Execution not available"),
+				Details: err.Error(),
+			})
+			return
+		}
+		source, sql = entry.Result, entry.SQL
+	}
+	extra["operations"] = len(req.Operations)
+	extra["source_rows"] = len(source.Rows)
+
+	result, err := results.Apply(source, req.Operations)
+	if err != nil {
+		extra["error"] = err.Error()
+		c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Code:    http.StatusBadRequest,
+			Message: localize(c, "Invalid transform"),
+			Details: err.Error(),
+		})
+		return
+	}
+	result.ExecutionID = h.resultCache.Put(userID, sql, result)
+
+	page := req.Page
+	if page <= 0 {
+		page = 1
+	}
+	pageSize := h.transformPageSize(req.PageSize)
+	full := result.Rows
+	from := (page - 1) * pageSize
+	if from > len(full) {
+		from = len(full)
+	}
+	to := from + pageSize
+	if to > len(full) {
+		to = len(full)
+	}
+	paged := *result
+	paged.Rows = full[from:to]
+	paged.RowCount = len(paged.Rows)
+	paged.Page = page
+	paged.PageSize = pageSize
+	paged.HasMore = to < len(full)
+	paged.ExecTime = time.Since(start).Milliseconds()
+	if req.Chart {
+		paged.Chart = h.recommendChart(c.Request.Context(), "", sql, result, false)
+	}
+
+	extra["rows"] = len(full)
+	success = true
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Result transformed"),
+		Data:    &paged,
+	})
+}
+
+func (h *APIHandler) transformPageSize(requested int) int {
+	size, limit := 50, 200
+	if h.cfg != nil && h.cfg.SQLDefaultPageSize > 0 {
+		size = h.cfg.SQLDefaultPageSize
+	}
+	if h.cfg != nil && h.cfg.SQLMaxPageSize > 0 {
+		limit = h.cfg.SQLMaxPageSize
+	}
+	if requested > 0 {
+		size = requested
+	}
+	if size > limit {
+		size = limit
+	}
+	return size
+}
+
+// resultBufferTimeout bounds refetching a result in full for the cache.
+const resultBufferTimeout = time.Minute
+
+// cacheResult buffers a successful execution for transforms and returns its
+// execution ID. When result is only a page of a larger result, the first
+// RESULT_CACHE_MAX_ROWS rows are fetched again so that group_by and pivot see
+// them all; a result over the cap, or a failed refetch, stays partial and
+// those operations are refused.
+func (h *APIHandler) cacheResult(ctx context.Context, userID, sql string, result *models.SQLExecuteResponse) string {
+	if !h.resultCache.Enabled() {
+		return ""
+	}
+	buffered := result
+	if result.HasMore || result.Page > 1 {
+		maxRows := 10000
+		if h.cfg != nil && h.cfg.ResultCacheMaxRows > 0 {
+			maxRows = h.cfg.ResultCacheMaxRows
+		}
+		full, err := h.sqlExecutor.PreviewSQL(ctx, sql, maxRows, resultBufferTimeout)
+		if err == nil {
+			full.Page, full.PageSize = 1, len(full.Rows)
+			buffered = full
+		} else {
+			h.logger.Warn("Failed to buffer the full result", zap.Error(err))
+		}
+	}
+	return h.resultCache.Put(userID, sql, buffered)
+}
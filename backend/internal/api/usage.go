@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+// GetMyUsage returns the caller's LLM budgets and current spend
+func (h *APIHandler) GetMyUsage(c *gin.Context) {
+	if h.usageSvc == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: localize(c, "Usage service unavailable"),
+		})
+		return
+	}
+	userID := c.GetString("user_id")
+	username := c.GetString("username")
+	budgets, err := h.usageSvc.Budgets(userID, username)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: localize(c, "Failed to load usage"),
+			Details: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Usage retrieved"),
+		Data: gin.H{
+			"team":    h.usageSvc.TeamFor(username),
+			"budgets": budgets,
+		},
+	})
+}
+
+// AdminCostReport aggregates LLM tokens and cost per user
+func (h *APIHandler) AdminCostReport(c *gin.Context) {
+	if h.usageSvc == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: localize(c, "Usage service unavailable"),
+		})
+		return
+	}
+	now := time.Now()
+	to := now
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	if fromStr := c.Query("from"); fromStr != "" {
+		if parsed, err := time.Parse(time.RFC3339, fromStr); err == nil {
+			from = parsed
+		}
+	}
+	if toStr := c.Query("to"); toStr != "" {
+		if parsed, err := time.Parse(time.RFC3339, toStr); err == nil {
+			to = parsed
+		}
+	}
+	report, err := h.usageSvc.Report(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: localize(c, "Failed to load cost report"),
+			Details: err.Error(),
+		})
+		return
+	}
+	total := 0.0
+	for _, row := range report {
+		total += row.CostUSD
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Cost report retrieved"),
+		Data: gin.H{
+			"from":           from,
+			"to":             to,
+			"total_cost_usd": total,
+			"users":          report,
+		},
+	})
+}
+
+// AdminRequestCost lists the LLM calls accounted to one request
+func (h *APIHandler) AdminRequestCost(c *gin.Context) {
+	if h.usageSvc == nil {
+		c.JSON(http.StatusServiceUnavailable, models.ErrorResponse{
+			Code:    http.StatusServiceUnavailable,
+			Message: localize(c, "Usage service unavailable"),
+		})
+		return
+	}
+	records, err := h.usageSvc.RequestUsage(c.Param("request_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+			Code:    http.StatusInternalServerError,
+			Message: localize(c, "Failed to load request usage"),
+			Details: err.Error(),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, models.SuccessResponse{
+		Code:    http.StatusOK,
+		Message: localize(c, "Request usage retrieved"),
+		Data:    records,
+	})
+}
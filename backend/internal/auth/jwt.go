@@ -23,6 +23,7 @@ type CustomClaims struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Role     string `json:"role"`
+	Locale   string `json:"locale,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -41,6 +42,7 @@ func (jm *JWTManager) GenerateToken(user *models.User) (string, int, error) {
 		Username: user.Username,
 		Email:    user.Email,
 		Role:     user.Role,
+		Locale:   user.Locale,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -144,7 +146,10 @@ CREATE TABLE IF NOT EXISTS users (
 		if _, err := us.db.Exec(mysqlDDL); err != nil {
 			return err
 		}
-		return us.ensureRoleColumn()
+		if err := us.ensureRoleColumn(); err != nil {
+			return err
+		}
+		return us.ensureLocaleColumn()
 	case "oracle":
 		const check = `SELECT COUNT(*) FROM USER_TABLES WHERE TABLE_NAME = 'USERS'`
 		var count int
@@ -152,7 +157,7 @@ CREATE TABLE IF NOT EXISTS users (
 			return err
 		}
 		if count > 0 {
-			return nil
+			return us.ensureLocaleColumn()
 		}
 		const oracleDDL = `
 CREATE TABLE USERS (
@@ -174,7 +179,10 @@ CREATE TABLE USERS (
 		if err != nil {
 			return err
 		}
-		return us.ensureRoleColumn()
+		if err := us.ensureRoleColumn(); err != nil {
+			return err
+		}
+		return us.ensureLocaleColumn()
 	default:
 		return fmt.Errorf("unsupported user service driver: %s", us.driver)
 	}
@@ -215,6 +223,37 @@ func (us *UserService) ensureRoleColumn() error {
 	}
 }
 
+// ensureLocaleColumn adds the nullable locale column to tables created
+// before users could pick a language.
+func (us *UserService) ensureLocaleColumn() error {
+	switch us.driver {
+	case "mysql":
+		const check = `SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = 'users' AND column_name = 'locale'`
+		var count int
+		if err := us.db.QueryRow(check).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		_, err := us.db.Exec(`ALTER TABLE users ADD COLUMN locale VARCHAR(16) NULL`)
+		return err
+	case "oracle":
+		const check = `SELECT COUNT(*) FROM USER_TAB_COLUMNS WHERE TABLE_NAME = 'USERS' AND COLUMN_NAME = 'LOCALE'`
+		var count int
+		if err := us.db.QueryRow(check).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		_, err := us.db.Exec(`ALTER TABLE USERS ADD (LOCALE VARCHAR2(16))`)
+		return err
+	default:
+		return nil
+	}
+}
+
 // RegisterUser registers a new user
 func (us *UserService) RegisterUser(username, email, password string) (*models.User, error) {
 	username = strings.TrimSpace(username)
@@ -268,44 +307,61 @@ func (us *UserService) RegisterUser(username, email, password string) (*models.U
 
 // GetUserByUsername retrieves a user by username
 func (us *UserService) GetUserByUsername(username string) (*models.User, error) {
-	query := `SELECT id, username, email, password, role, created_at, updated_at
+	query := `SELECT id, username, email, password, role, locale, created_at, updated_at
 		FROM users WHERE username = ? LIMIT 1`
 	if us.driver == "oracle" {
-		query = `SELECT id, username, email, password, role, created_at, updated_at
+		query = `SELECT id, username, email, password, role, locale, created_at, updated_at
 			FROM USERS WHERE username = :1 AND ROWNUM = 1`
 	}
 
-	row := us.db.QueryRow(query, strings.TrimSpace(username))
-	user := &models.User{}
-	if err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, err
-	}
-	return user, nil
+	return scanUser(us.db.QueryRow(query, strings.TrimSpace(username)))
 }
 
 // GetUserByID retrieves a user by ID
 func (us *UserService) GetUserByID(userID string) (*models.User, error) {
-	query := `SELECT id, username, email, password, role, created_at, updated_at
+	query := `SELECT id, username, email, password, role, locale, created_at, updated_at
 		FROM users WHERE id = ? LIMIT 1`
 	if us.driver == "oracle" {
-		query = `SELECT id, username, email, password, role, created_at, updated_at
+		query = `SELECT id, username, email, password, role, locale, created_at, updated_at
 			FROM USERS WHERE id = :1 AND ROWNUM = 1`
 	}
 
-	row := us.db.QueryRow(query, userID)
+	return scanUser(us.db.QueryRow(query, userID))
+}
+
+func scanUser(row *sql.Row) (*models.User, error) {
 	user := &models.User{}
-	if err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role, &user.CreatedAt, &user.UpdatedAt); err != nil {
+	var locale sql.NullString
+	if err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Password, &user.Role, &locale, &user.CreatedAt, &user.UpdatedAt); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("user not found")
 		}
 		return nil, err
 	}
+	user.Locale = locale.String
 	return user, nil
 }
 
+// UpdateLocale sets the user's preferred locale; empty clears it.
+func (us *UserService) UpdateLocale(userID, locale string) error {
+	var value interface{}
+	if locale != "" {
+		value = locale
+	}
+	query := `UPDATE users SET locale = ?, updated_at = ? WHERE id = ?`
+	if us.driver == "oracle" {
+		query = `UPDATE USERS SET LOCALE = :1, UPDATED_AT = :2 WHERE ID = :3`
+	}
+	res, err := us.db.Exec(query, value, time.Now(), userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
+
 // VerifyUserPassword verifies a user's password
 func (us *UserService) VerifyUserPassword(username, password string) (*models.User, error) {
 	user, err := us.GetUserByUsername(username)
@@ -386,9 +442,9 @@ func (us *UserService) EnsureAdminUser(username, email, password string) error {
 
 // ListUsers returns all users without passwords.
 func (us *UserService) ListUsers() ([]models.User, error) {
-	query := `SELECT id, username, email, role, created_at, updated_at FROM users ORDER BY created_at ASC`
+	query := `SELECT id, username, email, role, locale, created_at, updated_at FROM users ORDER BY created_at ASC`
 	if us.driver == "oracle" {
-		query = `SELECT id, username, email, role, created_at, updated_at FROM USERS ORDER BY created_at ASC`
+		query = `SELECT id, username, email, role, locale, created_at, updated_at FROM USERS ORDER BY created_at ASC`
 	}
 	rows, err := us.db.Query(query)
 	if err != nil {
@@ -399,9 +455,11 @@ func (us *UserService) ListUsers() ([]models.User, error) {
 	var users []models.User
 	for rows.Next() {
 		var user models.User
-		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.CreatedAt, &user.UpdatedAt); err != nil {
+		var locale sql.NullString
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Role, &locale, &user.CreatedAt, &user.UpdatedAt); err != nil {
 			return nil, err
 		}
+		user.Locale = locale.String
 		users = append(users, user)
 	}
 	return users, nil
@@ -0,0 +1,41 @@
+// Package cellvalue interprets the loosely typed cell values database drivers
+// return, so that result transforms and charts read them the same way.
+package cellvalue
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Number converts numeric values, including numeric strings without leading
+// zeros as some drivers return for NUMBER columns. Booleans, infinities and
+// NaN are not numbers.
+func Number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case nil, bool:
+		return 0, false
+	case string:
+		s := strings.TrimSpace(n)
+		if s == "" || len(s) > 1 && s[0] == '0' && s[1] != '.' {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err == nil && !math.IsInf(f, 0) && !math.IsNaN(f)
+	case fmt.Stringer:
+		return Number(n.String())
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		return f, !math.IsInf(f, 0) && !math.IsNaN(f)
+	}
+	return 0, false
+}
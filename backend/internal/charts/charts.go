@@ -0,0 +1,394 @@
+// Package charts recommends a visualization for a query result and renders
+// it as a Vega-Lite spec the web and desktop clients can draw directly.
+package charts
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/yourusername/db_asst/internal/cellvalue"
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+// Chart types.
+const (
+	Bar   = "bar"
+	Line  = "line"
+	Pie   = "pie"
+	Table = "table"
+)
+
+// Vega-Lite field types.
+const (
+	Quantitative = "quantitative"
+	Temporal     = "temporal"
+	Ordinal      = "ordinal"
+	Nominal      = "nominal"
+)
+
+// SchemaURL is the Vega-Lite schema the specs target.
+const SchemaURL = "https://vega.github.io/schema/vega-lite/v5.json"
+
+const (
+	// maxDistinct stops counting distinct values beyond this many.
+	maxDistinct = 1000
+	// maxBarCategories is the most categories drawn as bars.
+	maxBarCategories = 50
+	// maxPieSlices is the most categories drawn as a pie.
+	maxPieSlices = 6
+	// maxSeries is the most values a series column may have.
+	maxSeries = 10
+)
+
+var (
+	datePattern = regexp.MustCompile(`^\d{4}[-/]\d{1,2}([-/]\d{1,2})?([ T]\d{1,2}:\d{2}(:\d{2})?(\.\d+)?(Z|[+-]\d{2}:?\d{2})?)?$`)
+	// idPattern matches identifier-like column names, whose numbers are labels.
+	idPattern = regexp.MustCompile(`(?i)(^|_)(ID|NO|CODE|NUM)$|编号|代码|编码`)
+	// periodPattern matches year/month/quarter columns holding plain numbers.
+	periodPattern = regexp.MustCompile(`(?i)(^|_)(YEAR|YR|MONTH|MON|QUARTER|QTR|WEEK|DAY|PERIOD)$|年|月|季度|周`)
+)
+
+// Profile works out the field type, distinct count and nulls of each column.
+// Masked columns are always nominal.
+func Profile(result *models.SQLExecuteResponse) []models.ChartColumn {
+	masked := make(map[string]bool, len(result.MaskedColumns))
+	for _, name := range result.MaskedColumns {
+		masked[name] = true
+	}
+	columns := make([]models.ChartColumn, len(result.Columns))
+	for i, name := range result.Columns {
+		col := models.ChartColumn{Name: name}
+		seen := make(map[string]bool)
+		numeric, temporal, integral, values := true, true, true, 0
+		for _, row := range result.Rows {
+			if i >= len(row) || row[i] == nil {
+				col.Nulls++
+				continue
+			}
+			values++
+			v := row[i]
+			if len(seen) < maxDistinct {
+				seen[fmt.Sprint(v)] = true
+			}
+			f, isNum := cellvalue.Number(v)
+			if !isNum {
+				numeric = false
+			} else if f != math.Trunc(f) {
+				integral = false
+			}
+			if !isTime(v) {
+				temporal = false
+			}
+		}
+		col.Distinct = len(seen)
+		switch {
+		case values == 0 || masked[name]:
+			col.Type = Nominal
+		case temporal:
+			col.Type = Temporal
+		case numeric && idPattern.MatchString(name):
+			col.Type = Nominal
+		case numeric && integral && periodPattern.MatchString(name):
+			col.Type = Ordinal
+		case numeric:
+			col.Type = Quantitative
+		default:
+			col.Type = Nominal
+		}
+		columns[i] = col
+	}
+	return columns
+}
+
+func isTime(v interface{}) bool {
+	switch t := v.(type) {
+	case time.Time:
+		return true
+	case string:
+		return datePattern.MatchString(strings.TrimSpace(t))
+	}
+	return false
+}
+
+// Suggest picks a chart for the result from its column profile:
+//   - a time column and measures make a line chart;
+//   - a category and one non-negative measure with a few categories, each
+//     once, make a pie;
+//   - otherwise a category and measures make a bar chart;
+//   - anything else, or too many categories, stays a table.
+//
+// A second low-cardinality category splits a single measure into series.
+// The returned recommendation has no spec; see Render.
+func Suggest(result *models.SQLExecuteResponse) *models.ChartRecommendation {
+	columns := Profile(result)
+	rec := &models.ChartRecommendation{Type: Table, Source: "heuristic", Columns: columns}
+	var measures []string
+	var temporal, categories []models.ChartColumn
+	for _, col := range columns {
+		switch col.Type {
+		case Quantitative:
+			measures = append(measures, col.Name)
+		case Temporal, Ordinal:
+			temporal = append(temporal, col)
+		default:
+			categories = append(categories, col)
+		}
+	}
+	switch {
+	case len(result.Rows) == 0:
+		rec.Reason = "The result has no rows."
+		return rec
+	case len(measures) == 0:
+		rec.Reason = "The result has no numeric column to plot."
+		return rec
+	case len(temporal)+len(categories) == 0:
+		rec.Reason = "The result has no category or time column to plot against."
+		return rec
+	case len(result.Rows) == 1:
+		rec.Reason = "A single row reads best as a table."
+		return rec
+	}
+
+	series := func(exclude string) string {
+		if len(measures) != 1 {
+			return ""
+		}
+		for _, col := range categories {
+			if col.Name != exclude && col.Distinct >= 2 && col.Distinct <= maxSeries {
+				return col.Name
+			}
+		}
+		return ""
+	}
+
+	if len(temporal) > 0 && temporal[0].Distinct >= 2 {
+		rec.Type, rec.X, rec.Y = Line, temporal[0].Name, measures
+		rec.Series = series("")
+		rec.Reason = fmt.Sprintf("%s is a time dimension, so the measures are shown as a trend.", rec.X)
+		rec.Title = title(rec)
+		return rec
+	}
+
+	var x models.ChartColumn
+	if len(categories) > 0 {
+		x = categories[0]
+	} else {
+		x = temporal[0]
+	}
+	if x.Distinct > maxBarCategories {
+		rec.Reason = fmt.Sprintf("%s has %d values, too many to chart.", x.Name, x.Distinct)
+		return rec
+	}
+	rec.X, rec.Y = x.Name, measures
+	rec.Series = series(x.Name)
+	if len(measures) == 1 && rec.Series == "" && x.Distinct >= 2 && x.Distinct <= maxPieSlices &&
+		x.Distinct == len(result.Rows) && nonNegative(result, measures[0]) {
+		rec.Type = Pie
+		rec.Reason = fmt.Sprintf("%s has only %d categories, so their shares of %s are shown as a pie.", x.Name, x.Distinct, measures[0])
+	} else {
+		rec.Type = Bar
+		rec.Reason = fmt.Sprintf("%s is a category, so the measures are compared as bars.", x.Name)
+	}
+	rec.Title = title(rec)
+	return rec
+}
+
+func title(rec *models.ChartRecommendation) string {
+	text := strings.Join(rec.Y, ", ") + " by " + rec.X
+	if rec.Series != "" {
+		text += " and " + rec.Series
+	}
+	return text
+}
+
+func nonNegative(result *models.SQLExecuteResponse, column string) bool {
+	idx := indexOf(result.Columns, column)
+	for _, row := range result.Rows {
+		if idx < len(row) && row[idx] != nil {
+			if f, ok := cellvalue.Number(row[idx]); !ok || f < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Validate checks that rec only uses the result's columns in ways its chart
+// type supports, e.g. after the LLM picked them.
+func Validate(rec *models.ChartRecommendation, columns []models.ChartColumn) error {
+	types := make(map[string]string, len(columns))
+	for _, col := range columns {
+		types[col.Name] = col.Type
+	}
+	switch rec.Type {
+	case Table:
+		return nil
+	case Bar, Line, Pie:
+	default:
+		return fmt.Errorf("unknown chart type %q", rec.Type)
+	}
+	if _, ok := types[rec.X]; !ok {
+		return fmt.Errorf("x column %q is not in the result", rec.X)
+	}
+	if len(rec.Y) == 0 {
+		return fmt.Errorf("no y column")
+	}
+	for _, y := range rec.Y {
+		if y == rec.X || y == rec.Series {
+			return fmt.Errorf("column %q is used twice", y)
+		}
+		if types[y] != Quantitative {
+			return fmt.Errorf("y column %q is not numeric", y)
+		}
+	}
+	if rec.Series != "" {
+		if _, ok := types[rec.Series]; !ok {
+			return fmt.Errorf("series column %q is not in the result", rec.Series)
+		}
+		if rec.Series == rec.X {
+			return fmt.Errorf("column %q is used twice", rec.Series)
+		}
+		if len(rec.Y) > 1 {
+			return fmt.Errorf("a series needs a single y column")
+		}
+	}
+	if rec.Type == Pie && (len(rec.Y) != 1 || rec.Series != "") {
+		return fmt.Errorf("a pie needs one y column and no series")
+	}
+	return nil
+}
+
+// Render sets rec.Spec to a Vega-Lite spec with up to maxPoints rows inline
+// (all when maxPoints <= 0). Tables get no spec.
+func Render(rec *models.ChartRecommendation, result *models.SQLExecuteResponse, maxPoints int) {
+	rec.Spec, rec.Truncated = nil, false
+	if rec.Type == Table {
+		return
+	}
+	types := make(map[string]string, len(rec.Columns))
+	for _, col := range rec.Columns {
+		types[col.Name] = col.Type
+	}
+
+	used := append([]string{rec.X}, rec.Y...)
+	if rec.Series != "" {
+		used = append(used, rec.Series)
+	}
+	rows := result.Rows
+	if maxPoints > 0 && len(rows) > maxPoints {
+		rows, rec.Truncated = rows[:maxPoints], true
+	}
+	values := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		value := make(map[string]interface{}, len(used))
+		for _, name := range used {
+			idx := indexOf(result.Columns, name)
+			if idx < 0 || idx >= len(row) {
+				continue
+			}
+			value[name] = specValue(row[idx], types[name])
+		}
+		values = append(values, value)
+	}
+
+	spec := map[string]interface{}{
+		"$schema":     SchemaURL,
+		"width":       "container",
+		"data":        map[string]interface{}{"values": values},
+		"description": rec.Reason,
+	}
+	if rec.Title != "" {
+		spec["title"] = rec.Title
+	}
+	y := map[string]interface{}{"field": field(rec.Y[0]), "type": Quantitative, "title": rec.Y[0]}
+	var color map[string]interface{}
+	if len(rec.Y) > 1 {
+		// Several measures become one value field keyed by measure name.
+		spec["transform"] = []interface{}{map[string]interface{}{"fold": fields(rec.Y), "as": []string{"measure", "value"}}}
+		y = map[string]interface{}{"field": "value", "type": Quantitative, "title": strings.Join(rec.Y, ", ")}
+		color = map[string]interface{}{"field": "measure", "type": Nominal, "title": nil}
+	} else if rec.Series != "" {
+		color = map[string]interface{}{"field": field(rec.Series), "type": Nominal, "title": rec.Series}
+	}
+
+	encoding := map[string]interface{}{}
+	switch rec.Type {
+	case Pie:
+		spec["mark"] = map[string]interface{}{"type": "arc", "tooltip": true}
+		encoding["theta"] = map[string]interface{}{"field": field(rec.Y[0]), "type": Quantitative, "title": rec.Y[0]}
+		encoding["color"] = map[string]interface{}{"field": field(rec.X), "type": Nominal, "title": rec.X}
+	case Line:
+		spec["mark"] = map[string]interface{}{"type": "line", "point": true, "tooltip": true}
+		xType := types[rec.X]
+		if xType != Temporal {
+			xType = Ordinal
+		}
+		encoding["x"] = map[string]interface{}{"field": field(rec.X), "type": xType, "title": rec.X}
+		encoding["y"] = y
+	default:
+		spec["mark"] = map[string]interface{}{"type": "bar", "tooltip": true}
+		x := map[string]interface{}{"field": field(rec.X), "type": Nominal, "title": rec.X}
+		switch {
+		case types[rec.X] == Temporal || types[rec.X] == Ordinal:
+			x["type"] = Ordinal
+		case len(rec.Y) == 1 && rec.Series == "":
+			x["sort"] = "-y"
+		}
+		encoding["x"] = x
+		encoding["y"] = y
+		if len(rec.Y) > 1 {
+			encoding["xOffset"] = map[string]interface{}{"field": "measure"}
+		}
+	}
+	if color != nil {
+		encoding["color"] = color
+	}
+	spec["encoding"] = encoding
+	rec.Spec = spec
+}
+
+// specValue converts a cell for the inline data: times become ISO strings and
+// numbers become JSON numbers.
+func specValue(v interface{}, fieldType string) interface{} {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case time.Time:
+		return t.Format(time.RFC3339)
+	}
+	if fieldType == Quantitative {
+		if f, ok := cellvalue.Number(v); ok {
+			return f
+		}
+	}
+	if _, ok := v.(fmt.Stringer); ok {
+		return fmt.Sprint(v)
+	}
+	return v
+}
+
+// field escapes a column name for a Vega-Lite field reference, where dots
+// and brackets would otherwise denote nested access.
+func field(name string) string {
+	return strings.NewReplacer(`\`, `\\`, ".", `\.`, "[", `\[`, "]", `\]`).Replace(name)
+}
+
+func fields(names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = field(name)
+	}
+	return out
+}
+
+func indexOf(list []string, value string) int {
+	for i, v := range list {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
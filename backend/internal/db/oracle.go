@@ -22,6 +22,16 @@ type OracleClient struct {
 	mu     sync.RWMutex
 	// columnCommentCache caches COLUMN_NAME -> comment for quick decoration
 	columnCommentCache map[string]string
+	// catalogRefreshedAt records the last successful table list fetch
+	catalogRefreshedAt time.Time
+	catalogTableCount  int
+
+	cfg            *config.Config
+	sessionTagging bool
+	module         string
+	// proxyPools holds one pool per Oracle proxy client (CONNECT THROUGH)
+	proxyPools map[string]*sql.DB
+	proxyMu    sync.Mutex
 }
 
 var (
@@ -56,14 +66,15 @@ func Initialize(cfg *config.Config, logger *zap.Logger) (*OracleClient, error) {
 		}
 
 		// Set connection pool parameters
-		db.SetMaxOpenConns(25)
-		db.SetMaxIdleConns(5)
-		db.SetConnMaxLifetime(5 * time.Minute)
+		ApplyPoolConfig(db, cfg.OraclePool)
 
 		oracleInstance = &OracleClient{
-			db:     db,
-			logger: logger,
-			schema: schema,
+			db:             db,
+			logger:         logger,
+			schema:         schema,
+			cfg:            cfg,
+			sessionTagging: cfg.OracleSessionTagging,
+			module:         cfg.OracleSessionModule,
 		}
 
 		logger.Info("Oracle database connected successfully")
@@ -79,6 +90,12 @@ func GetInstance() *OracleClient {
 
 // Close closes the database connection
 func (c *OracleClient) Close() error {
+	c.proxyMu.Lock()
+	for key, pool := range c.proxyPools {
+		_ = pool.Close()
+		delete(c.proxyPools, key)
+	}
+	c.proxyMu.Unlock()
 	if c.db != nil {
 		return c.db.Close()
 	}
@@ -110,13 +127,83 @@ func (c *OracleClient) GetAllTables(ctx context.Context, excludeSystemTables boo
 		tables = append(tables, tableName)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.catalogRefreshedAt = time.Now()
+	c.catalogTableCount = len(tables)
+	c.mu.Unlock()
+
 	c.logger.Info("Fetched table list",
 		zap.Int("count", len(tables)),
 		zap.String("schema", c.schema))
 
+	return tables, nil
+}
+
+// SearchTables returns up to limit tables whose name or comment contains
+// any of keywords (case-insensitive), with their comments. No keywords
+// matches every table.
+func (c *OracleClient) SearchTables(ctx context.Context, keywords []string, limit int) ([]models.TableSchema, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	args := []interface{}{c.schema}
+	var conditions []string
+	for _, keyword := range keywords {
+		keyword = strings.ToUpper(strings.TrimSpace(keyword))
+		if keyword == "" {
+			continue
+		}
+		args = append(args, "%"+keyword+"%")
+		n := len(args)
+		conditions = append(conditions, fmt.Sprintf("UPPER(t.TABLE_NAME) LIKE :%d OR UPPER(tc.COMMENTS) LIKE :%d", n, n))
+	}
+	filter := ""
+	if len(conditions) > 0 {
+		filter = "AND (" + strings.Join(conditions, " OR ") + ")"
+	}
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT TABLE_NAME, COMMENTS FROM (
+			SELECT t.TABLE_NAME, COALESCE(tc.COMMENTS, '') AS COMMENTS
+			FROM ALL_TABLES t
+			LEFT JOIN ALL_TAB_COMMENTS tc
+				ON tc.OWNER = t.OWNER
+				AND tc.TABLE_NAME = t.TABLE_NAME
+			WHERE t.OWNER = :1 %s
+			ORDER BY t.TABLE_NAME
+		) WHERE ROWNUM <= :%d
+	`, filter, len(args))
+
+	rows, err := c.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		c.logger.Error("Failed to search tables", zap.Strings("keywords", keywords), zap.Error(err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []models.TableSchema
+	for rows.Next() {
+		var table models.TableSchema
+		if err := rows.Scan(&table.TableName, &table.Comment); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
 	return tables, rows.Err()
 }
 
+// CatalogFreshness reports when the table catalog was last fetched successfully
+// and how many tables it contained. A zero time means it was never loaded.
+func (c *OracleClient) CatalogFreshness() (time.Time, int) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.catalogRefreshedAt, c.catalogTableCount
+}
+
 // GetTableSchema returns the schema information for a specific table
 func (c *OracleClient) GetTableSchema(ctx context.Context, tableName string) (*models.TableSchema, error) {
 	// Get table comment
@@ -234,7 +321,17 @@ func (c *OracleClient) GetTableColumns(ctx context.Context, tableName string) ([
 func (c *OracleClient) ExecuteQuery(ctx context.Context, query string) (*models.SQLExecuteResponse, error) {
 	start := time.Now()
 
-	rows, err := c.db.QueryContext(ctx, query)
+	conn, release, err := c.acquireConn(ctx)
+	if err != nil {
+		c.logger.Error("Failed to acquire connection", zap.Error(err))
+		return &models.SQLExecuteResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	defer release()
+
+	rows, err := conn.QueryContext(ctx, query)
 	if err != nil {
 		c.logger.Error("Failed to execute query", zap.String("query", query), zap.Error(err))
 		return &models.SQLExecuteResponse{
@@ -306,7 +403,17 @@ func (c *OracleClient) ExecuteQueryRange(ctx context.Context, query string, offs
     SELECT inner_query.*, ROWNUM rnum FROM (%s) inner_query WHERE ROWNUM <= :1
 ) WHERE rnum > :2`, sanitized)
 
-	rows, err := c.db.QueryContext(ctx, wrapped, maxRow, offset)
+	conn, release, err := c.acquireConn(ctx)
+	if err != nil {
+		c.logger.Error("Failed to acquire connection", zap.Error(err))
+		return &models.SQLExecuteResponse{
+			Success: false,
+			Error:   err.Error(),
+		}, nil
+	}
+	defer release()
+
+	rows, err := conn.QueryContext(ctx, wrapped, maxRow, offset)
 	if err != nil {
 		c.logger.Error("Failed to execute paginated query", zap.Error(err))
 		return &models.SQLExecuteResponse{
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"sort"
+
+	"github.com/yourusername/db_asst/config"
+)
+
+// PoolStats is a JSON-friendly view of sql.DBStats for one pool.
+type PoolStats struct {
+	Name              string `json:"name"`
+	MaxOpen           int    `json:"max_open"`
+	Open              int    `json:"open"`
+	InUse             int    `json:"in_use"`
+	Idle              int    `json:"idle"`
+	WaitCount         int64  `json:"wait_count"`
+	WaitDurationMs    int64  `json:"wait_duration_ms"`
+	MaxIdleClosed     int64  `json:"max_idle_closed"`
+	MaxIdleTimeClosed int64  `json:"max_idle_time_closed"`
+	MaxLifetimeClosed int64  `json:"max_lifetime_closed"`
+}
+
+// ApplyPoolConfig applies configured pool limits; zero values keep database/sql defaults.
+func ApplyPoolConfig(pool *sql.DB, cfg config.PoolConfig) {
+	if pool == nil {
+		return
+	}
+	if cfg.MaxOpenConns > 0 {
+		pool.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		pool.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		pool.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	}
+	if cfg.ConnMaxIdleTime > 0 {
+		pool.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+	}
+}
+
+// NewPoolStats snapshots the statistics of pool under the given name.
+func NewPoolStats(name string, pool *sql.DB) PoolStats {
+	if pool == nil {
+		return PoolStats{Name: name}
+	}
+	stats := pool.Stats()
+	return PoolStats{
+		Name:              name,
+		MaxOpen:           stats.MaxOpenConnections,
+		Open:              stats.OpenConnections,
+		InUse:             stats.InUse,
+		Idle:              stats.Idle,
+		WaitCount:         stats.WaitCount,
+		WaitDurationMs:    stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:     stats.MaxIdleClosed,
+		MaxIdleTimeClosed: stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed: stats.MaxLifetimeClosed,
+	}
+}
+
+// PoolStats returns statistics for the main Oracle pool and any proxy pools.
+// Proxy pools are labelled by a hash of the proxy client so that Oracle
+// account names do not leak into metrics.
+func (c *OracleClient) PoolStats() []PoolStats {
+	result := []PoolStats{NewPoolStats("oracle", c.db)}
+
+	c.proxyMu.Lock()
+	defer c.proxyMu.Unlock()
+	names := make([]string, 0, len(c.proxyPools))
+	for name := range c.proxyPools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result = append(result, NewPoolStats("oracle_proxy:"+proxyPoolLabel(name), c.proxyPools[name]))
+	}
+	return result
+}
+
+// proxyPoolLabel returns a short stable hash of a proxy client name.
+func proxyPoolLabel(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:4])
+}
@@ -0,0 +1,161 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Oracle limits for the session attributes we set (bytes).
+const (
+	maxClientIdentifierLen = 64
+	maxClientInfoLen       = 64
+	maxModuleLen           = 48
+	maxActionLen           = 32
+)
+
+// SessionIdentity describes the application user on whose behalf a query runs.
+// It is attached to the Oracle session so DBAs can attribute activity in
+// V$SESSION / unified audit trail to assistant users.
+type SessionIdentity struct {
+	UserID    string
+	Username  string
+	RequestID string
+	Action    string
+}
+
+type sessionIdentityKey struct{}
+
+// WithSessionIdentity returns a context carrying the end-user identity.
+func WithSessionIdentity(ctx context.Context, identity SessionIdentity) context.Context {
+	return context.WithValue(ctx, sessionIdentityKey{}, identity)
+}
+
+// SessionIdentityFromContext extracts the identity stored by WithSessionIdentity.
+func SessionIdentityFromContext(ctx context.Context) (SessionIdentity, bool) {
+	identity, ok := ctx.Value(sessionIdentityKey{}).(SessionIdentity)
+	return identity, ok
+}
+
+// acquireConn pins a pooled connection for the duration of one query and tags
+// it with the caller's identity. The returned release func clears the tags and
+// hands the connection back to the pool.
+func (c *OracleClient) acquireConn(ctx context.Context) (*sql.Conn, func(), error) {
+	identity, hasIdentity := SessionIdentityFromContext(ctx)
+
+	pool := c.db
+	if hasIdentity {
+		proxyPool, err := c.proxyPool(identity.Username)
+		if err != nil {
+			return nil, nil, err
+		}
+		if proxyPool != nil {
+			pool = proxyPool
+		}
+	}
+
+	conn, err := pool.Conn(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !hasIdentity || !c.sessionTagging {
+		return conn, func() { _ = conn.Close() }, nil
+	}
+
+	if err := c.tagSession(ctx, conn, identity); err != nil {
+		c.logger.Warn("Failed to tag Oracle session",
+			zap.String("user_id", identity.UserID),
+			zap.Error(err))
+	}
+	release := func() {
+		clearCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := c.clearSessionTag(clearCtx, conn); err != nil {
+			c.logger.Warn("Failed to clear Oracle session tag", zap.Error(err))
+			// Do not return a connection with a stale identity to the pool.
+			_ = conn.Raw(func(interface{}) error { return driver.ErrBadConn })
+		}
+		_ = conn.Close()
+	}
+	return conn, release, nil
+}
+
+func (c *OracleClient) tagSession(ctx context.Context, conn *sql.Conn, identity SessionIdentity) error {
+	clientID := identity.Username
+	if clientID == "" {
+		clientID = identity.UserID
+	}
+	clientInfo := fmt.Sprintf("uid=%s;op=%s", identity.UserID, identity.Action)
+	// ACTION is limited to 32 bytes: a UUID request ID fits once hyphens are dropped.
+	action := strings.ReplaceAll(identity.RequestID, "-", "")
+	if action == "" {
+		action = identity.Action
+	}
+	_, err := conn.ExecContext(ctx, `BEGIN
+    DBMS_SESSION.SET_IDENTIFIER(:1);
+    DBMS_APPLICATION_INFO.SET_CLIENT_INFO(:2);
+    DBMS_APPLICATION_INFO.SET_MODULE(:3, :4);
+END;`,
+		truncateBytes(clientID, maxClientIdentifierLen),
+		truncateBytes(clientInfo, maxClientInfoLen),
+		truncateBytes(c.module, maxModuleLen),
+		truncateBytes(action, maxActionLen),
+	)
+	return err
+}
+
+func (c *OracleClient) clearSessionTag(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `BEGIN
+    DBMS_SESSION.CLEAR_IDENTIFIER;
+    DBMS_APPLICATION_INFO.SET_CLIENT_INFO(NULL);
+    DBMS_APPLICATION_INFO.SET_MODULE(NULL, NULL);
+END;`)
+	return err
+}
+
+// proxyPool returns a pool authenticated via CONNECT THROUGH for the Oracle
+// proxy client mapped to username, or nil when proxy auth does not apply.
+func (c *OracleClient) proxyPool(username string) (*sql.DB, error) {
+	if c.cfg == nil {
+		return nil, nil
+	}
+	client := c.cfg.ResolveOracleProxyClient(username)
+	if client == "" {
+		return nil, nil
+	}
+	key := strings.ToUpper(client)
+
+	c.proxyMu.Lock()
+	defer c.proxyMu.Unlock()
+	if pool, ok := c.proxyPools[key]; ok {
+		return pool, nil
+	}
+	pool, err := sql.Open("oracle", c.cfg.GetOracleProxyConnStr(client))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Oracle proxy connection for %s: %w", client, err)
+	}
+	ApplyPoolConfig(pool, c.cfg.OracleProxyPool)
+	if c.proxyPools == nil {
+		c.proxyPools = make(map[string]*sql.DB)
+	}
+	c.proxyPools[key] = pool
+	c.logger.Info("Oracle proxy pool opened", zap.String("proxy_client", key))
+	return pool, nil
+}
+
+func truncateBytes(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	// Avoid splitting a multi-byte rune.
+	cut := max
+	for cut > 0 && (value[cut]&0xC0) == 0x80 {
+		cut--
+	}
+	return value[:cut]
+}
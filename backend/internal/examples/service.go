@@ -0,0 +1,181 @@
+package examples
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	cacheTTL = time.Minute
+	// minSimilarity drops examples that share little more than stop words.
+	minSimilarity = 0.2
+)
+
+// Match is an example with its similarity to a question.
+type Match struct {
+	Example
+	Score float64 `json:"score"`
+}
+
+// Service retrieves the examples most similar to a question. Similarity is
+// lexical: words for Latin text and character bigrams for CJK text, compared
+// by cosine over token sets.
+type Service struct {
+	store    *Store
+	mu       sync.Mutex
+	cached   []indexed
+	loadedAt time.Time
+}
+
+type indexed struct {
+	example Example
+	tokens  map[string]struct{}
+}
+
+// NewService creates the example service backed by store.
+func NewService(store *Store) *Service {
+	return &Service{store: store}
+}
+
+// List returns all examples.
+func (s *Service) List() ([]Example, error) {
+	return s.store.List()
+}
+
+// Get returns one example.
+func (s *Service) Get(id string) (*Example, error) {
+	return s.store.Get(id)
+}
+
+// Promote stores a verified question/SQL pair.
+func (s *Service) Promote(ex *Example) error {
+	ex.Question = strings.TrimSpace(ex.Question)
+	ex.SQL = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(ex.SQL), ";"))
+	if ex.Question == "" || ex.SQL == "" {
+		return fmt.Errorf("question and sql are required")
+	}
+	if err := s.store.Upsert(ex); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Delete removes an example.
+func (s *Service) Delete(id string) error {
+	if err := s.store.Delete(id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Similar returns up to k examples most similar to question.
+func (s *Service) Similar(question string, k int) ([]Match, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	all, err := s.index()
+	if err != nil {
+		return nil, err
+	}
+	query := tokenize(question)
+	if len(query) == 0 {
+		return nil, nil
+	}
+	var matches []Match
+	for _, item := range all {
+		if score := cosine(query, item.tokens); score >= minSimilarity {
+			matches = append(matches, Match{Example: item.example, Score: math.Round(score*1000) / 1000})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// FormatForPrompt renders examples as question/SQL demonstrations.
+func FormatForPrompt(matches []Match) string {
+	blocks := make([]string, len(matches))
+	for i, m := range matches {
+		blocks[i] = fmt.Sprintf("Question: %s\nSQL: %s", m.Question, m.SQL)
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+func (s *Service) index() ([]indexed, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached != nil && time.Since(s.loadedAt) < cacheTTL {
+		return s.cached, nil
+	}
+	list, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]indexed, len(list))
+	for i, ex := range list {
+		items[i] = indexed{example: ex, tokens: tokenize(ex.Question)}
+	}
+	s.cached = items
+	s.loadedAt = time.Now()
+	return items, nil
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.cached = nil
+	s.mu.Unlock()
+}
+
+// tokenize splits text into lower-cased words of two or more characters
+// and bigrams of consecutive Han characters.
+func tokenize(text string) map[string]struct{} {
+	tokens := make(map[string]struct{})
+	var word []rune
+	var prevHan rune
+	flush := func() {
+		if len(word) >= 2 {
+			tokens[string(word)] = struct{}{}
+		}
+		word = word[:0]
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			if prevHan != 0 {
+				tokens[string([]rune{prevHan, r})] = struct{}{}
+			}
+			prevHan = r
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+		prevHan = 0
+	}
+	flush()
+	return tokens
+}
+
+func cosine(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range a {
+		if _, ok := b[t]; ok {
+			shared++
+		}
+	}
+	return float64(shared) / math.Sqrt(float64(len(a))*float64(len(b)))
+}
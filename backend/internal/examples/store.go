@@ -0,0 +1,187 @@
+package examples
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when an example does not exist.
+var ErrNotFound = errors.New("example not found")
+
+// Source types an example can be promoted from.
+const (
+	SourceReport = "report"
+	SourceMemory = "memory"
+)
+
+// Example is a verified question/SQL pair used as a few-shot demonstration.
+type Example struct {
+	ID         string    `json:"id"`
+	Question   string    `json:"question"`
+	SQL        string    `json:"sql"`
+	SourceType string    `json:"source_type"`
+	SourceID   string    `json:"source_id"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Store persists examples in the app database.
+type Store struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewStore creates the example store and ensures its table exists.
+func NewStore(db *sql.DB, driver string) (*Store, error) {
+	if db == nil {
+		return nil, errors.New("example store requires db handle")
+	}
+	s := &Store{db: db, driver: driver}
+	if err := s.ensureTable(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) ensureTable() error {
+	switch s.driver {
+	case "mysql":
+		const ddl = `CREATE TABLE IF NOT EXISTS sql_examples (
+            id CHAR(36) PRIMARY KEY,
+            question TEXT NOT NULL,
+            sql_text LONGTEXT NOT NULL,
+            source_type VARCHAR(16) NOT NULL,
+            source_id VARCHAR(64) NOT NULL,
+            created_by VARCHAR(64),
+            created_at DATETIME NOT NULL,
+            UNIQUE KEY uk_example_source (source_type, source_id)
+        ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`
+		_, err := s.db.Exec(ddl)
+		return err
+	case "oracle":
+		const check = `SELECT COUNT(*) FROM USER_TABLES WHERE TABLE_NAME = 'SQL_EXAMPLES'`
+		var count int
+		if err := s.db.QueryRow(check).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		const ddl = `CREATE TABLE SQL_EXAMPLES (
+            ID VARCHAR2(36) PRIMARY KEY,
+            QUESTION CLOB NOT NULL,
+            SQL_TEXT CLOB NOT NULL,
+            SOURCE_TYPE VARCHAR2(16) NOT NULL,
+            SOURCE_ID VARCHAR2(64) NOT NULL,
+            CREATED_BY VARCHAR2(64),
+            CREATED_AT TIMESTAMP NOT NULL,
+            CONSTRAINT UK_EXAMPLE_SOURCE UNIQUE (SOURCE_TYPE, SOURCE_ID)
+        )`
+		_, err := s.db.Exec(ddl)
+		return err
+	default:
+		return fmt.Errorf("unsupported driver %s", s.driver)
+	}
+}
+
+// List returns all examples, newest first.
+func (s *Store) List() ([]Example, error) {
+	query := `SELECT id, question, sql_text, source_type, source_id, created_by, created_at FROM sql_examples ORDER BY created_at DESC`
+	if s.driver == "oracle" {
+		query = `SELECT ID, QUESTION, SQL_TEXT, SOURCE_TYPE, SOURCE_ID, CREATED_BY, CREATED_AT FROM SQL_EXAMPLES ORDER BY CREATED_AT DESC`
+	}
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]Example, 0)
+	for rows.Next() {
+		var ex Example
+		var createdBy sql.NullString
+		if err := rows.Scan(&ex.ID, &ex.Question, &ex.SQL, &ex.SourceType, &ex.SourceID, &createdBy, &ex.CreatedAt); err != nil {
+			return nil, err
+		}
+		ex.CreatedBy = createdBy.String
+		result = append(result, ex)
+	}
+	return result, rows.Err()
+}
+
+// Get returns one example by ID.
+func (s *Store) Get(id string) (*Example, error) {
+	query := `SELECT id, question, sql_text, source_type, source_id, created_by, created_at FROM sql_examples WHERE id = ?`
+	if s.driver == "oracle" {
+		query = `SELECT ID, QUESTION, SQL_TEXT, SOURCE_TYPE, SOURCE_ID, CREATED_BY, CREATED_AT FROM SQL_EXAMPLES WHERE ID = :1`
+	}
+	var ex Example
+	var createdBy sql.NullString
+	err := s.db.QueryRow(query, id).Scan(&ex.ID, &ex.Question, &ex.SQL, &ex.SourceType, &ex.SourceID, &createdBy, &ex.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	ex.CreatedBy = createdBy.String
+	return &ex, nil
+}
+
+// Upsert stores ex. Promoting the same source again replaces its question and SQL.
+func (s *Store) Upsert(ex *Example) error {
+	if ex.ID == "" {
+		ex.ID = uuid.New().String()
+	}
+	if ex.CreatedAt.IsZero() {
+		ex.CreatedAt = time.Now()
+	}
+	switch s.driver {
+	case "mysql":
+		_, err := s.db.Exec(`INSERT INTO sql_examples (id, question, sql_text, source_type, source_id, created_by, created_at)
+            VALUES (?, ?, ?, ?, ?, ?, ?)
+            ON DUPLICATE KEY UPDATE question=VALUES(question), sql_text=VALUES(sql_text)`,
+			ex.ID, ex.Question, ex.SQL, ex.SourceType, ex.SourceID, ex.CreatedBy, ex.CreatedAt)
+		if err != nil {
+			return err
+		}
+	case "oracle":
+		_, err := s.db.Exec(`MERGE INTO SQL_EXAMPLES dst USING (SELECT :1 AS SOURCE_TYPE, :2 AS SOURCE_ID FROM dual) src
+            ON (dst.SOURCE_TYPE = src.SOURCE_TYPE AND dst.SOURCE_ID = src.SOURCE_ID)
+            WHEN MATCHED THEN UPDATE SET QUESTION=:3, SQL_TEXT=:4
+            WHEN NOT MATCHED THEN INSERT (ID, QUESTION, SQL_TEXT, SOURCE_TYPE, SOURCE_ID, CREATED_BY, CREATED_AT)
+            VALUES (:5, :3, :4, :1, :2, :6, :7)`,
+			ex.SourceType, ex.SourceID, ex.Question, ex.SQL, ex.ID, ex.CreatedBy, ex.CreatedAt)
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unsupported driver %s", s.driver)
+	}
+	// On update the existing row keeps its ID.
+	query := `SELECT id FROM sql_examples WHERE source_type = ? AND source_id = ?`
+	if s.driver == "oracle" {
+		query = `SELECT ID FROM SQL_EXAMPLES WHERE SOURCE_TYPE = :1 AND SOURCE_ID = :2`
+	}
+	return s.db.QueryRow(query, ex.SourceType, ex.SourceID).Scan(&ex.ID)
+}
+
+// Delete removes an example.
+func (s *Store) Delete(id string) error {
+	query := `DELETE FROM sql_examples WHERE id = ?`
+	if s.driver == "oracle" {
+		query = `DELETE FROM SQL_EXAMPLES WHERE ID = :1`
+	}
+	res, err := s.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
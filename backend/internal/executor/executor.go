@@ -313,6 +313,74 @@ func maskingKeys(col string) []string {
 	return []string{upper, strings.ToUpper(original)}
 }
 
+// Dry-run modes for CheckSQL.
+const (
+	DryRunLimit   = "limit"   // execute with a tiny row limit
+	DryRunExplain = "explain" // EXPLAIN PLAN only; needs a PLAN_TABLE the account can write
+)
+
+// CheckSQL validates sql and asks Oracle to parse and plan it without
+// fetching a real result set. The returned error is the validation or
+// database error (e.g. ORA-00904) that a repair step should address.
+func (e *SQLExecutor) CheckSQL(ctx context.Context, sql, mode string, timeout time.Duration) error {
+	if err := e.ValidateSQL(sql); err != nil {
+		return err
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var result *models.SQLExecuteResponse
+	var err error
+	if mode == DryRunExplain {
+		result, err = e.dbClient.ExecuteQuery(ctx, fmt.Sprintf("EXPLAIN PLAN FOR %s", strings.TrimSuffix(strings.TrimSpace(sql), ";")))
+	} else {
+		result, err = e.dbClient.ExecuteQueryRange(ctx, sql, 0, 1)
+	}
+	if err != nil {
+		return err
+	}
+	if result != nil && !result.Success {
+		return fmt.Errorf("%s", result.Error)
+	}
+	return nil
+}
+
+// SampleSQL validates sql and fetches at most limit rows, unmasked, for
+// internal comparison. Database errors are returned as errors.
+func (e *SQLExecutor) SampleSQL(ctx context.Context, sql string, limit int, timeout time.Duration) (*models.SQLExecuteResponse, error) {
+	if err := e.ValidateSQL(sql); err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result, err := e.dbClient.ExecuteQueryRange(ctx, sql, 0, limit)
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("%s", result.Error)
+	}
+	return result, nil
+}
+
+// PreviewSQL is SampleSQL with sensitive columns masked, for rows that are
+// shown to the LLM or buffered for transforms.
+func (e *SQLExecutor) PreviewSQL(ctx context.Context, sql string, limit int, timeout time.Duration) (*models.SQLExecuteResponse, error) {
+	result, err := e.SampleSQL(ctx, sql, limit, timeout)
+	if err != nil {
+		return nil, err
+	}
+	e.applyMasking(result)
+	return result, nil
+}
+
 // ExplainSQL provides execution plan information
 func (e *SQLExecutor) ExplainSQL(ctx context.Context, sql string) (map[string]interface{}, error) {
 	// Validate SQL first
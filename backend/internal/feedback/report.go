@@ -0,0 +1,117 @@
+package feedback
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Accuracy is the share of thumbs-up among rated generations in one group.
+type Accuracy struct {
+	Key       string  `json:"key"`
+	Rated     int     `json:"rated"`
+	Up        int     `json:"up"`
+	Down      int     `json:"down"`
+	Corrected int     `json:"corrected"`
+	Accuracy  float64 `json:"accuracy"`
+}
+
+// Report breaks rated generations down by template, prompt version, table
+// and model.
+type Report struct {
+	Overall         Accuracy   `json:"overall"`
+	ByTemplate      []Accuracy `json:"by_template"`
+	ByPromptVersion []Accuracy `json:"by_prompt_version"`
+	ByTable         []Accuracy `json:"by_table"`
+	ByModel         []Accuracy `json:"by_model"`
+}
+
+// noTemplate groups LLM generations that did not come from a report template.
+const noTemplate = "(llm)"
+
+// BuildReport aggregates rated records. A generation touching several tables
+// counts once for each of them.
+func BuildReport(records []Record) Report {
+	overall := &Accuracy{Key: "all"}
+	byTemplate := map[string]*Accuracy{}
+	byPrompt := map[string]*Accuracy{}
+	byTable := map[string]*Accuracy{}
+	byModel := map[string]*Accuracy{}
+	for _, rec := range records {
+		if rec.Rating == 0 {
+			continue
+		}
+		add(overall, rec)
+		template := rec.TemplateID
+		if template == "" {
+			template = noTemplate
+		}
+		add(group(byTemplate, template), rec)
+		if rec.PromptVersion != "" {
+			add(group(byPrompt, rec.PromptVersion), rec)
+		}
+		if rec.Model != "" {
+			add(group(byModel, rec.Model), rec)
+		}
+		seen := map[string]bool{}
+		for _, table := range rec.Tables {
+			table = strings.ToUpper(strings.TrimSpace(table))
+			if table == "" || seen[table] {
+				continue
+			}
+			seen[table] = true
+			add(group(byTable, table), rec)
+		}
+	}
+	finish(overall)
+	return Report{
+		Overall:         *overall,
+		ByTemplate:      sorted(byTemplate),
+		ByPromptVersion: sorted(byPrompt),
+		ByTable:         sorted(byTable),
+		ByModel:         sorted(byModel),
+	}
+}
+
+func group(groups map[string]*Accuracy, key string) *Accuracy {
+	a, ok := groups[key]
+	if !ok {
+		a = &Accuracy{Key: key}
+		groups[key] = a
+	}
+	return a
+}
+
+func add(a *Accuracy, rec Record) {
+	a.Rated++
+	if rec.Rating > 0 {
+		a.Up++
+	} else {
+		a.Down++
+	}
+	if strings.TrimSpace(rec.CorrectedSQL) != "" {
+		a.Corrected++
+	}
+}
+
+func finish(a *Accuracy) {
+	if a.Rated > 0 {
+		a.Accuracy = math.Round(float64(a.Up)/float64(a.Rated)*1000) / 1000
+	}
+}
+
+// sorted returns the groups with the most ratings first.
+func sorted(groups map[string]*Accuracy) []Accuracy {
+	result := make([]Accuracy, 0, len(groups))
+	for _, a := range groups {
+		finish(a)
+		result = append(result, *a)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Rated != result[j].Rated {
+			return result[i].Rated > result[j].Rated
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
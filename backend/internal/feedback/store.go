@@ -0,0 +1,240 @@
+package feedback
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrNotFound is returned when no generation is recorded for a request.
+var ErrNotFound = errors.New("generation not found")
+
+// Ratings stored for a thumbs-up or thumbs-down.
+const (
+	RatingUp   = 1
+	RatingDown = -1
+)
+
+// Record is one SQL generation and the feedback given on it. Rating is 0
+// until the user rates it.
+type Record struct {
+	RequestID     string     `json:"request_id"`
+	UserID        string     `json:"user_id"`
+	SessionID     string     `json:"session_id"`
+	Query         string     `json:"query"`
+	SQL           string     `json:"sql"`
+	Source        string     `json:"source"`
+	TemplateID    string     `json:"template_id,omitempty"`
+	PromptVersion string     `json:"prompt_version,omitempty"`
+	Model         string     `json:"model,omitempty"`
+	Tables        []string   `json:"tables,omitempty"`
+	MemoryID      string     `json:"memory_id,omitempty"`
+	Rating        int        `json:"rating"`
+	CorrectedSQL  string     `json:"corrected_sql,omitempty"`
+	Comment       string     `json:"comment,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	RatedAt       *time.Time `json:"rated_at,omitempty"`
+}
+
+// Store persists generations and their feedback in the app database.
+type Store struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewStore creates the feedback store and ensures its table exists.
+func NewStore(db *sql.DB, driver string) (*Store, error) {
+	if db == nil {
+		return nil, errors.New("feedback store requires db handle")
+	}
+	s := &Store{db: db, driver: driver}
+	if err := s.ensureTable(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) ensureTable() error {
+	switch s.driver {
+	case "mysql":
+		const ddl = `CREATE TABLE IF NOT EXISTS sql_feedback (
+            request_id VARCHAR(64) PRIMARY KEY,
+            user_id VARCHAR(64) NOT NULL,
+            session_id VARCHAR(128),
+            query_text TEXT NOT NULL,
+            sql_text LONGTEXT,
+            source VARCHAR(32),
+            template_id VARCHAR(64),
+            prompt_version VARCHAR(64),
+            model VARCHAR(128),
+            tables_json JSON,
+            memory_id VARCHAR(36),
+            rating SMALLINT NOT NULL DEFAULT 0,
+            corrected_sql LONGTEXT,
+            comment_text TEXT,
+            created_at DATETIME NOT NULL,
+            rated_at DATETIME NULL,
+            KEY idx_feedback_rated (rated_at)
+        ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`
+		_, err := s.db.Exec(ddl)
+		return err
+	case "oracle":
+		const check = `SELECT COUNT(*) FROM USER_TABLES WHERE TABLE_NAME = 'SQL_FEEDBACK'`
+		var count int
+		if err := s.db.QueryRow(check).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		const ddl = `CREATE TABLE SQL_FEEDBACK (
+            REQUEST_ID VARCHAR2(64) PRIMARY KEY,
+            USER_ID VARCHAR2(64) NOT NULL,
+            SESSION_ID VARCHAR2(128),
+            QUERY_TEXT CLOB NOT NULL,
+            SQL_TEXT CLOB,
+            SOURCE VARCHAR2(32),
+            TEMPLATE_ID VARCHAR2(64),
+            PROMPT_VERSION VARCHAR2(64),
+            MODEL VARCHAR2(128),
+            TABLES_JSON CLOB,
+            MEMORY_ID VARCHAR2(36),
+            RATING NUMBER(2) DEFAULT 0 NOT NULL,
+            CORRECTED_SQL CLOB,
+            COMMENT_TEXT CLOB,
+            CREATED_AT TIMESTAMP NOT NULL,
+            RATED_AT TIMESTAMP
+        )`
+		if _, err := s.db.Exec(ddl); err != nil {
+			return err
+		}
+		_, err := s.db.Exec(`CREATE INDEX IDX_FEEDBACK_RATED ON SQL_FEEDBACK (RATED_AT)`)
+		return err
+	default:
+		return fmt.Errorf("unsupported driver %s", s.driver)
+	}
+}
+
+// RecordGeneration stores a generation so it can be rated later. Recording
+// the same request again replaces the generation and keeps any feedback.
+// Request IDs come from clients, so a request ID already recorded for
+// another user is left alone.
+func (s *Store) RecordGeneration(rec *Record) error {
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now()
+	}
+	tables, _ := json.Marshal(rec.Tables)
+	switch s.driver {
+	case "mysql":
+		_, err := s.db.Exec(`INSERT INTO sql_feedback
+            (request_id, user_id, session_id, query_text, sql_text, source, template_id, prompt_version, model, tables_json, memory_id, created_at)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+            ON DUPLICATE KEY UPDATE sql_text=IF(user_id=VALUES(user_id), VALUES(sql_text), sql_text),
+            source=IF(user_id=VALUES(user_id), VALUES(source), source),
+            template_id=IF(user_id=VALUES(user_id), VALUES(template_id), template_id),
+            prompt_version=IF(user_id=VALUES(user_id), VALUES(prompt_version), prompt_version),
+            model=IF(user_id=VALUES(user_id), VALUES(model), model),
+            tables_json=IF(user_id=VALUES(user_id), VALUES(tables_json), tables_json),
+            memory_id=IF(user_id=VALUES(user_id), VALUES(memory_id), memory_id)`,
+			rec.RequestID, rec.UserID, rec.SessionID, rec.Query, rec.SQL, rec.Source, rec.TemplateID, rec.PromptVersion, rec.Model, string(tables), rec.MemoryID, rec.CreatedAt)
+		return err
+	case "oracle":
+		_, err := s.db.Exec(`MERGE INTO SQL_FEEDBACK dst USING (SELECT :1 AS REQUEST_ID FROM dual) src
+            ON (dst.REQUEST_ID = src.REQUEST_ID)
+            WHEN MATCHED THEN UPDATE SET SQL_TEXT=:5, SOURCE=:6, TEMPLATE_ID=:7, PROMPT_VERSION=:8, MODEL=:9, TABLES_JSON=:10, MEMORY_ID=:11
+                WHERE dst.USER_ID = :2
+            WHEN NOT MATCHED THEN INSERT (REQUEST_ID, USER_ID, SESSION_ID, QUERY_TEXT, SQL_TEXT, SOURCE, TEMPLATE_ID, PROMPT_VERSION, MODEL, TABLES_JSON, MEMORY_ID, CREATED_AT)
+            VALUES (:1, :2, :3, :4, :5, :6, :7, :8, :9, :10, :11, :12)`,
+			rec.RequestID, rec.UserID, rec.SessionID, rec.Query, rec.SQL, rec.Source, rec.TemplateID, rec.PromptVersion, rec.Model, string(tables), rec.MemoryID, rec.CreatedAt)
+		return err
+	default:
+		return fmt.Errorf("unsupported driver %s", s.driver)
+	}
+}
+
+// Rate stores the user's feedback on one of their generations.
+func (s *Store) Rate(userID, requestID string, rating int, correctedSQL, comment string) error {
+	query := `UPDATE sql_feedback SET rating = ?, corrected_sql = ?, comment_text = ?, rated_at = ? WHERE request_id = ? AND user_id = ?`
+	if s.driver == "oracle" {
+		query = `UPDATE SQL_FEEDBACK SET RATING = :1, CORRECTED_SQL = :2, COMMENT_TEXT = :3, RATED_AT = :4 WHERE REQUEST_ID = :5 AND USER_ID = :6`
+	}
+	res, err := s.db.Exec(query, rating, correctedSQL, comment, time.Now(), requestID, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Get returns the caller's generation and its feedback.
+func (s *Store) Get(userID, requestID string) (*Record, error) {
+	query := selectColumns + ` FROM sql_feedback WHERE request_id = ? AND user_id = ?`
+	if s.driver == "oracle" {
+		query = selectColumns + ` FROM SQL_FEEDBACK WHERE REQUEST_ID = :1 AND USER_ID = :2`
+	}
+	rec, err := scanRecord(s.db.QueryRow(query, requestID, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return rec, err
+}
+
+// ListRated returns generations rated between from and to, newest first.
+func (s *Store) ListRated(from, to time.Time) ([]Record, error) {
+	query := selectColumns + ` FROM sql_feedback WHERE rating <> 0 AND rated_at >= ? AND rated_at < ? ORDER BY rated_at DESC`
+	if s.driver == "oracle" {
+		query = selectColumns + ` FROM SQL_FEEDBACK WHERE RATING <> 0 AND RATED_AT >= :1 AND RATED_AT < :2 ORDER BY RATED_AT DESC`
+	}
+	rows, err := s.db.Query(query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]Record, 0)
+	for rows.Next() {
+		rec, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *rec)
+	}
+	return result, rows.Err()
+}
+
+const selectColumns = `SELECT request_id, user_id, session_id, query_text, sql_text, source, template_id, prompt_version, model,
+    tables_json, memory_id, rating, corrected_sql, comment_text, created_at, rated_at`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRecord(row scanner) (*Record, error) {
+	var rec Record
+	var sessionID, sqlText, source, templateID, promptVersion, model, tables, memoryID, corrected, comment sql.NullString
+	var ratedAt sql.NullTime
+	if err := row.Scan(&rec.RequestID, &rec.UserID, &sessionID, &rec.Query, &sqlText, &source, &templateID, &promptVersion, &model,
+		&tables, &memoryID, &rec.Rating, &corrected, &comment, &rec.CreatedAt, &ratedAt); err != nil {
+		return nil, err
+	}
+	rec.SessionID = sessionID.String
+	rec.SQL = sqlText.String
+	rec.Source = source.String
+	rec.TemplateID = templateID.String
+	rec.PromptVersion = promptVersion.String
+	rec.Model = model.String
+	rec.MemoryID = memoryID.String
+	rec.CorrectedSQL = corrected.String
+	rec.Comment = comment.String
+	if tables.Valid {
+		_ = json.Unmarshal([]byte(tables.String), &rec.Tables)
+	}
+	if ratedAt.Valid {
+		rec.RatedAt = &ratedAt.Time
+	}
+	return &rec, nil
+}
@@ -0,0 +1,201 @@
+package glossary
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	cacheTTL   = time.Minute
+	maxMatches = 10
+)
+
+// Service matches glossary terms against questions. Terms are cached briefly
+// so every generation does not hit the app database.
+type Service struct {
+	store    *Store
+	mu       sync.Mutex
+	cached   []Term
+	loadedAt time.Time
+}
+
+// NewService creates a glossary service backed by store.
+func NewService(store *Store) *Service {
+	return &Service{store: store}
+}
+
+// List returns all terms.
+func (s *Service) List() ([]Term, error) {
+	return s.store.List()
+}
+
+// Get returns one term.
+func (s *Service) Get(id string) (*Term, error) {
+	return s.store.Get(id)
+}
+
+// Save validates and stores t.
+func (s *Service) Save(t *Term) error {
+	t.Term = strings.TrimSpace(t.Term)
+	if t.Term == "" {
+		return fmt.Errorf("term is required")
+	}
+	if strings.TrimSpace(t.Definition) == "" && strings.TrimSpace(t.SQLExpression) == "" {
+		return fmt.Errorf("definition or sql_expression is required")
+	}
+	t.Synonyms = compact(t.Synonyms, false)
+	t.Tables = compact(t.Tables, true)
+	t.SQLExpression = strings.TrimSpace(t.SQLExpression)
+	t.Definition = strings.TrimSpace(t.Definition)
+	if err := s.store.Save(t); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Delete removes a term.
+func (s *Service) Delete(id string) error {
+	if err := s.store.Delete(id); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// Match returns the terms whose name or a synonym appears in query, most
+// specific (longest match) first. Latin names must match whole words so
+// "GMV" does not match inside another identifier; CJK names match as
+// substrings.
+func (s *Service) Match(query string) ([]Term, error) {
+	terms, err := s.terms()
+	if err != nil {
+		return nil, err
+	}
+	normalized := strings.ToLower(query)
+	type hit struct {
+		term   Term
+		length int
+	}
+	var hits []hit
+	for _, t := range terms {
+		best := 0
+		for _, name := range append([]string{t.Term}, t.Synonyms...) {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != "" && len(name) > best && containsTerm(normalized, name) {
+				best = len(name)
+			}
+		}
+		if best > 0 {
+			hits = append(hits, hit{term: t, length: best})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].length > hits[j].length })
+	if len(hits) > maxMatches {
+		hits = hits[:maxMatches]
+	}
+	result := make([]Term, len(hits))
+	for i, h := range hits {
+		result[i] = h.term
+	}
+	return result, nil
+}
+
+// FormatForPrompt renders matched terms for the generation prompt.
+func FormatForPrompt(terms []Term) string {
+	var builder strings.Builder
+	for i, t := range terms {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+		builder.WriteString("- " + t.Term)
+		if len(t.Synonyms) > 0 {
+			builder.WriteString(" (also: " + strings.Join(t.Synonyms, ", ") + ")")
+		}
+		if t.Definition != "" {
+			builder.WriteString(": " + t.Definition)
+		}
+		if t.SQLExpression != "" {
+			builder.WriteString("\n  SQL: " + t.SQLExpression)
+		}
+		if len(t.Tables) > 0 {
+			builder.WriteString("\n  Tables: " + strings.Join(t.Tables, ", "))
+		}
+	}
+	return builder.String()
+}
+
+func (s *Service) terms() ([]Term, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached != nil && time.Since(s.loadedAt) < cacheTTL {
+		return s.cached, nil
+	}
+	terms, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	s.cached = terms
+	s.loadedAt = time.Now()
+	return terms, nil
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.cached = nil
+	s.mu.Unlock()
+}
+
+func containsTerm(text, name string) bool {
+	if !isLatin(name) {
+		return strings.Contains(text, name)
+	}
+	for offset := 0; ; {
+		idx := strings.Index(text[offset:], name)
+		if idx < 0 {
+			return false
+		}
+		start := offset + idx
+		end := start + len(name)
+		if !wordByte(text, start-1) && !wordByte(text, end) {
+			return true
+		}
+		offset = start + 1
+	}
+}
+
+func isLatin(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII {
+			return false
+		}
+	}
+	return true
+}
+
+func wordByte(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return false
+	}
+	c := text[i]
+	return c == '_' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z'
+}
+
+func compact(values []string, upper bool) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if upper {
+			v = strings.ToUpper(v)
+		}
+		result = append(result, v)
+	}
+	return result
+}
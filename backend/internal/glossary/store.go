@@ -0,0 +1,200 @@
+package glossary
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrNotFound is returned when a term does not exist.
+var ErrNotFound = errors.New("glossary term not found")
+
+// Term is a business term and how it maps onto the schema.
+type Term struct {
+	ID            string    `json:"id"`
+	Term          string    `json:"term"`
+	Synonyms      []string  `json:"synonyms"`
+	Definition    string    `json:"definition"`
+	SQLExpression string    `json:"sql_expression"` // filter or expression, e.g. STATUS = 'A' AND CLOSED_AT IS NULL
+	Tables        []string  `json:"tables"`         // owning tables
+	CreatedBy     string    `json:"created_by,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Store persists glossary terms in the app database.
+type Store struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewStore creates the glossary store and ensures its table exists.
+func NewStore(db *sql.DB, driver string) (*Store, error) {
+	if db == nil {
+		return nil, errors.New("glossary store requires db handle")
+	}
+	s := &Store{db: db, driver: driver}
+	if err := s.ensureTable(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) ensureTable() error {
+	switch s.driver {
+	case "mysql":
+		const ddl = `CREATE TABLE IF NOT EXISTS glossary_terms (
+            id CHAR(36) PRIMARY KEY,
+            term VARCHAR(255) NOT NULL,
+            synonyms JSON,
+            definition TEXT,
+            sql_expression TEXT,
+            tables_json JSON,
+            created_by VARCHAR(64),
+            created_at DATETIME NOT NULL,
+            updated_at DATETIME NOT NULL,
+            UNIQUE KEY uk_glossary_term (term)
+        ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`
+		_, err := s.db.Exec(ddl)
+		return err
+	case "oracle":
+		const check = `SELECT COUNT(*) FROM USER_TABLES WHERE TABLE_NAME = 'GLOSSARY_TERMS'`
+		var count int
+		if err := s.db.QueryRow(check).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		const ddl = `CREATE TABLE GLOSSARY_TERMS (
+            ID VARCHAR2(36) PRIMARY KEY,
+            TERM VARCHAR2(255) NOT NULL,
+            SYNONYMS CLOB,
+            DEFINITION CLOB,
+            SQL_EXPRESSION CLOB,
+            TABLES_JSON CLOB,
+            CREATED_BY VARCHAR2(64),
+            CREATED_AT TIMESTAMP NOT NULL,
+            UPDATED_AT TIMESTAMP NOT NULL,
+            CONSTRAINT UK_GLOSSARY_TERM UNIQUE (TERM)
+        )`
+		_, err := s.db.Exec(ddl)
+		return err
+	default:
+		return fmt.Errorf("unsupported driver %s", s.driver)
+	}
+}
+
+const selectColumns = "id, term, synonyms, definition, sql_expression, tables_json, created_by, created_at, updated_at"
+
+// List returns all terms ordered by term.
+func (s *Store) List() ([]Term, error) {
+	query := "SELECT " + selectColumns + " FROM glossary_terms ORDER BY term"
+	if s.driver == "oracle" {
+		query = "SELECT " + selectColumns + " FROM GLOSSARY_TERMS ORDER BY TERM"
+	}
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]Term, 0)
+	for rows.Next() {
+		term, err := scanTerm(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *term)
+	}
+	return result, rows.Err()
+}
+
+// Get returns one term by ID.
+func (s *Store) Get(id string) (*Term, error) {
+	query := "SELECT " + selectColumns + " FROM glossary_terms WHERE id = ?"
+	if s.driver == "oracle" {
+		query = "SELECT " + selectColumns + " FROM GLOSSARY_TERMS WHERE ID = :1"
+	}
+	term, err := scanTerm(s.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return term, err
+}
+
+// Save inserts or updates t, assigning ID and timestamps.
+func (s *Store) Save(t *Term) error {
+	if t.ID == "" {
+		t.ID = uuid.New().String()
+	}
+	now := time.Now()
+	if t.CreatedAt.IsZero() {
+		t.CreatedAt = now
+	}
+	t.UpdatedAt = now
+	synonyms, _ := json.Marshal(t.Synonyms)
+	tables, _ := json.Marshal(t.Tables)
+
+	switch s.driver {
+	case "mysql":
+		_, err := s.db.Exec(`INSERT INTO glossary_terms (id, term, synonyms, definition, sql_expression, tables_json, created_by, created_at, updated_at)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+            ON DUPLICATE KEY UPDATE term=VALUES(term), synonyms=VALUES(synonyms), definition=VALUES(definition),
+            sql_expression=VALUES(sql_expression), tables_json=VALUES(tables_json), updated_at=VALUES(updated_at)`,
+			t.ID, t.Term, string(synonyms), t.Definition, t.SQLExpression, string(tables), t.CreatedBy, t.CreatedAt, t.UpdatedAt)
+		return err
+	case "oracle":
+		_, err := s.db.Exec(`MERGE INTO GLOSSARY_TERMS dst USING (SELECT :1 AS ID FROM dual) src
+            ON (dst.ID = src.ID)
+            WHEN MATCHED THEN UPDATE SET TERM=:2, SYNONYMS=:3, DEFINITION=:4, SQL_EXPRESSION=:5, TABLES_JSON=:6, UPDATED_AT=:9
+            WHEN NOT MATCHED THEN INSERT (ID, TERM, SYNONYMS, DEFINITION, SQL_EXPRESSION, TABLES_JSON, CREATED_BY, CREATED_AT, UPDATED_AT)
+            VALUES (:1, :2, :3, :4, :5, :6, :7, :8, :9)`,
+			t.ID, t.Term, string(synonyms), t.Definition, t.SQLExpression, string(tables), t.CreatedBy, t.CreatedAt, t.UpdatedAt)
+		return err
+	default:
+		return fmt.Errorf("unsupported driver %s", s.driver)
+	}
+}
+
+// Delete removes a term.
+func (s *Store) Delete(id string) error {
+	query := "DELETE FROM glossary_terms WHERE id = ?"
+	if s.driver == "oracle" {
+		query = "DELETE FROM GLOSSARY_TERMS WHERE ID = :1"
+	}
+	res, err := s.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTerm(row scanner) (*Term, error) {
+	var t Term
+	var synonyms, definition, expression, tables, createdBy sql.NullString
+	if err := row.Scan(&t.ID, &t.Term, &synonyms, &definition, &expression, &tables, &createdBy, &t.CreatedAt, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if synonyms.Valid {
+		_ = json.Unmarshal([]byte(synonyms.String), &t.Synonyms)
+	}
+	if tables.Valid {
+		_ = json.Unmarshal([]byte(tables.String), &t.Tables)
+	}
+	t.Definition = definition.String
+	t.SQLExpression = expression.String
+	t.CreatedBy = createdBy.String
+	return &t, nil
+}
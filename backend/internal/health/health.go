@@ -0,0 +1,104 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+)
+
+// CheckFunc probes one dependency; a non-nil error marks it as down.
+type CheckFunc func(ctx context.Context) error
+
+// DependencyStatus is the outcome of a single check.
+type DependencyStatus struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Critical  bool   `json:"critical"`
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Report aggregates all dependency checks.
+// Status is "down" when a critical dependency fails, "degraded" when only
+// non-critical ones fail, "up" otherwise.
+type Report struct {
+	Status    string             `json:"status"`
+	Checks    []DependencyStatus `json:"checks"`
+	CheckedAt time.Time          `json:"checked_at"`
+}
+
+type check struct {
+	name     string
+	critical bool
+	fn       CheckFunc
+}
+
+// Checker runs registered dependency checks concurrently.
+type Checker struct {
+	checks  []check
+	timeout time.Duration
+}
+
+// NewChecker creates a checker; each check gets its own timeout.
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Register adds a dependency check.
+func (c *Checker) Register(name string, critical bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Run executes all checks and builds the report.
+func (c *Checker) Run(ctx context.Context) Report {
+	results := make([]DependencyStatus, len(c.checks))
+	var wg sync.WaitGroup
+	for i, chk := range c.checks {
+		wg.Add(1)
+		go func(i int, chk check) {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+			start := time.Now()
+			err := chk.fn(checkCtx)
+			result := DependencyStatus{
+				Name:      chk.name,
+				Status:    StatusUp,
+				Critical:  chk.critical,
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+			results[i] = result
+		}(i, chk)
+	}
+	wg.Wait()
+
+	status := StatusUp
+	for _, result := range results {
+		if result.Status == StatusUp {
+			continue
+		}
+		if result.Critical {
+			status = StatusDown
+			break
+		}
+		status = StatusDegraded
+	}
+	return Report{
+		Status:    status,
+		Checks:    results,
+		CheckedAt: time.Now(),
+	}
+}
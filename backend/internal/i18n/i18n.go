@@ -0,0 +1,174 @@
+// Package i18n translates user-facing messages. Messages are written in
+// English in the code and used as catalog keys; each other language is a
+// JSON catalog in locales/ mapping the English text, format verbs included,
+// to its translation. Missing entries fall back to the English text.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Source is the locale messages are written in.
+const Source = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// names are how the LLM is told which language to answer in.
+var names = map[string]string{
+	"en":    "English",
+	"zh-CN": "Simplified Chinese (简体中文)",
+}
+
+var (
+	catalogs      = loadCatalogs()
+	defaultLocale = "zh-CN"
+)
+
+func loadCatalogs() map[string]map[string]string {
+	entries, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	loaded := make(map[string]map[string]string, len(entries))
+	for _, entry := range entries {
+		data, err := localeFS.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic(fmt.Sprintf("i18n: parse %s: %v", entry.Name(), err))
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return loaded
+}
+
+// Supported lists the available locales, the source locale first.
+func Supported() []string {
+	locales := []string{Source}
+	for locale := range catalogs {
+		if locale != Source {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales[1:])
+	return locales
+}
+
+// SetDefault sets the locale used when a request states no supported
+// preference. Unsupported tags are ignored.
+func SetDefault(tag string) {
+	if locale := Match(tag); locale != "" {
+		defaultLocale = locale
+	}
+}
+
+// Default returns the fallback locale.
+func Default() string {
+	return defaultLocale
+}
+
+// Match maps a language tag to a supported locale: exactly, ignoring case
+// and "_" versus "-", or else by primary language, so "zh-TW" and "zh" get
+// "zh-CN" and "en-US" gets "en". It returns "" when nothing matches.
+func Match(tag string) string {
+	tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+	if tag == "" {
+		return ""
+	}
+	primary := strings.ToLower(strings.SplitN(tag, "-", 2)[0])
+	fallback := ""
+	for _, locale := range Supported() {
+		if strings.EqualFold(locale, tag) {
+			return locale
+		}
+		if fallback == "" && strings.ToLower(strings.SplitN(locale, "-", 2)[0]) == primary {
+			fallback = locale
+		}
+	}
+	return fallback
+}
+
+// FromAcceptLanguage returns the supported locale the client prefers most
+// in an Accept-Language header, e.g. "zh-CN,zh;q=0.9,en;q=0.8", or "".
+func FromAcceptLanguage(header string) string {
+	type choice struct {
+		tag string
+		q   float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		tag := strings.TrimSpace(fields[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			choices = append(choices, choice{tag, q})
+		}
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	for _, c := range choices {
+		if locale := Match(c.tag); locale != "" {
+			return locale
+		}
+	}
+	return ""
+}
+
+// T translates message into locale, or the default locale when locale is
+// empty, and formats it with args like fmt.Sprintf.
+func T(locale, message string, args ...interface{}) string {
+	if locale == "" {
+		locale = defaultLocale
+	}
+	if translated, ok := catalogs[locale][message]; ok && translated != "" {
+		message = translated
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// LanguageName describes locale for an LLM instruction, e.g. "English".
+// Unknown tags are returned as they are.
+func LanguageName(locale string) string {
+	if name, ok := names[locale]; ok {
+		return name
+	}
+	return locale
+}
+
+type contextKey struct{}
+
+// WithLocale returns ctx carrying locale for messages produced under it.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, contextKey{}, locale)
+}
+
+// FromContext returns the locale set by WithLocale, or "".
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	locale, _ := ctx.Value(contextKey{}).(string)
+	return locale
+}
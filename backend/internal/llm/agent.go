@@ -0,0 +1,169 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/models"
+	"github.com/yourusername/db_asst/internal/prompts"
+)
+
+// SubmitSQLTool is the tool the agent calls with its final answer; its
+// arguments follow SQLGenerationSchema.
+const SubmitSQLTool = "submit_sql"
+
+const (
+	// agentResultRunes caps each tool result sent back to the model.
+	agentResultRunes = 4000
+	// agentPreviewRunes caps the result preview reported in AgentStep.
+	agentPreviewRunes = 300
+)
+
+// AgentTool is a tool the SQL agent may call. Run receives the raw JSON
+// arguments; its error is shown to the model, which may try again.
+type AgentTool struct {
+	Tool
+	Run func(ctx context.Context, arguments string) (string, error)
+}
+
+// SupportsTools reports whether the primary backend can call tools, which
+// agent-mode generation requires.
+func (c *LLMClient) SupportsTools() bool {
+	return supportsTools(c.provider)
+}
+
+// GenerateSQLAgent lets the model explore the database through tools until
+// it submits SQL, for at most maxSteps model turns. onStep is called after
+// every tool call and may be nil. When the step budget runs out the model
+// gets one last turn with only submit_sql available.
+func (c *LLMClient) GenerateSQLAgent(ctx context.Context, req *models.SQLGenerateRequest, gc GenerationContext, tools []AgentTool, maxSteps int, onStep func(models.AgentStep)) (*models.SQLGenerateResponse, error) {
+	rendered, err := c.renderPrompt(prompts.SQLAgent, sqlGenerationData(req, gc))
+	if err != nil {
+		return nil, err
+	}
+	if maxSteps <= 0 {
+		maxSteps = 8
+	}
+	submit := Tool{
+		Name:        SubmitSQLTool,
+		Description: "Submit the final Oracle SQL (or a clarifying question) with its explanation. Call it once, when done exploring.",
+		Parameters:  SQLGenerationSchema.Schema,
+	}
+	byName := make(map[string]AgentTool, len(tools))
+	defs := make([]Tool, 0, len(tools)+1)
+	for _, tool := range tools {
+		byName[tool.Name] = tool
+		defs = append(defs, tool.Tool)
+	}
+	defs = append(defs, submit)
+
+	messages := toMessages(rendered)
+	var steps []models.AgentStep
+	finish := func(reply, model string) *models.SQLGenerateResponse {
+		resp := c.parseSQLGenerationResponse(reply)
+		resp.PromptVersion = rendered.Label()
+		resp.Model = model
+		resp.AgentSteps = steps
+		return resp
+	}
+	for turn := 0; turn <= maxSteps; turn++ {
+		offered, choice := defs, ""
+		if turn == maxSteps {
+			offered, choice = []Tool{submit}, SubmitSQLTool
+			messages = append(messages, Message{Role: "user", Content: "The exploration budget is used up. Call submit_sql now with your best answer."})
+		}
+		resp, err := c.completeTools(ctx, rendered.Name, messages, offered, choice)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.ToolCalls) == 0 {
+			return finish(resp.Content, resp.Model), nil
+		}
+		messages = append(messages, Message{Role: "assistant", Content: resp.Content, ToolCalls: resp.ToolCalls})
+		for _, call := range resp.ToolCalls {
+			if call.Name == SubmitSQLTool {
+				return finish(call.Arguments, resp.Model), nil
+			}
+			step := c.runAgentTool(ctx, byName, call, len(steps)+1)
+			steps = append(steps, step.AgentStep)
+			if onStep != nil {
+				onStep(step.AgentStep)
+			}
+			messages = append(messages, Message{Role: "tool", ToolCallID: call.ID, Content: step.output})
+		}
+	}
+	return nil, fmt.Errorf("agent did not submit SQL within %d steps", maxSteps)
+}
+
+type agentStepResult struct {
+	models.AgentStep
+	output string // what the model sees
+}
+
+func (c *LLMClient) runAgentTool(ctx context.Context, tools map[string]AgentTool, call ToolCall, n int) agentStepResult {
+	step := agentStepResult{AgentStep: models.AgentStep{Step: n, Tool: call.Name, Arguments: strings.TrimSpace(call.Arguments)}}
+	tool, ok := tools[call.Name]
+	if !ok {
+		step.Error = fmt.Sprintf("unknown tool %q", call.Name)
+		step.output = "Error: " + step.Error
+		return step
+	}
+	start := time.Now()
+	output, err := tool.Run(ctx, toolArguments(call.Arguments))
+	step.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		c.logger.Debug("Agent tool failed", zap.String("tool", call.Name), zap.Error(err))
+		step.Error = err.Error()
+		step.output = "Error: " + err.Error()
+		return step
+	}
+	step.output = truncateRunes(output, agentResultRunes)
+	step.Result = truncateRunes(output, agentPreviewRunes)
+	return step
+}
+
+// completeTools makes one tool-enabled call through the backend chain,
+// skipping backends that cannot call tools. A non-empty choice forces that
+// tool to be called.
+func (c *LLMClient) completeTools(ctx context.Context, operation string, messages []Message, tools []Tool, choice string) (*CompletionResponse, error) {
+	if err := c.allow(ctx); err != nil {
+		return nil, err
+	}
+	req := CompletionRequest{
+		Messages:    messages,
+		Temperature: defaultTemperature,
+		MaxTokens:   2000,
+		Tools:       tools,
+		ToolChoice:  choice,
+	}
+	var result *CompletionResponse
+	err := c.call(ctx, "complete", func(b *backend) error {
+		if !supportsTools(b.provider) {
+			return fmt.Errorf("%w: %s", ErrToolsUnsupported, b.provider.Name())
+		}
+		resp, err := b.provider.Complete(ctx, req)
+		if err != nil {
+			return err
+		}
+		result = resp
+		result.Model = servedModel(b, resp)
+		completion := resp.Content
+		for _, call := range resp.ToolCalls {
+			completion += call.Name + call.Arguments
+		}
+		c.account(ctx, operation, b, req, completion, resp.Usage)
+		return nil
+	}, nil)
+	return result, err
+}
+
+func truncateRunes(text string, limit int) string {
+	if runes := []rune(text); len(runes) > limit {
+		return string(runes[:limit]) + "\n... (truncated)"
+	}
+	return text
+}
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/db_asst/internal/models"
+	"github.com/yourusername/db_asst/internal/prompts"
+)
+
+// chartSampleRows is how many result rows the chart prompt shows.
+const chartSampleRows = 10
+
+// ChartSchema describes the JSON reply for chart recommendation.
+var ChartSchema = &JSONSchema{
+	Name:        "chart_recommendation",
+	Description: "Choose how to chart a query result",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"type":   map[string]interface{}{"type": "string", "enum": []string{"bar", "line", "pie", "table"}},
+			"x":      map[string]interface{}{"type": "string"},
+			"y":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"series": map[string]interface{}{"type": "string"},
+			"title":  map[string]interface{}{"type": "string"},
+			"reason": map[string]interface{}{"type": "string"},
+		},
+		"required":             []string{"type", "x", "y", "series", "title", "reason"},
+		"additionalProperties": false,
+	},
+}
+
+// RecommendChart asks the LLM to refine the suggested chart for result in
+// light of the question. It returns only the chart type, columns, title and
+// reason; the caller validates them against the result.
+func (c *LLMClient) RecommendChart(ctx context.Context, question, sql string, result *models.SQLExecuteResponse, suggested *models.ChartRecommendation) (*models.ChartRecommendation, error) {
+	var columns strings.Builder
+	for _, col := range suggested.Columns {
+		fmt.Fprintf(&columns, "- %s (%s, %d distinct, %d null)\n", col.Name, col.Type, col.Distinct, col.Nulls)
+	}
+	sample, _ := FormatResultTable(result, chartSampleRows)
+	rendered, err := c.renderPrompt(prompts.Chart, prompts.ChartData{
+		Query:     strings.TrimSpace(question),
+		SQL:       strings.TrimSpace(sql),
+		Columns:   columns.String(),
+		Sample:    sample,
+		RowCount:  len(result.Rows),
+		Suggested: describeChart(suggested),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var schema *JSONSchema
+	if c.jsonMode {
+		schema = ChartSchema
+	}
+	response, err := c.complete(ctx, rendered, schema)
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Type   string   `json:"type"`
+		X      string   `json:"x"`
+		Y      []string `json:"y"`
+		Series string   `json:"series"`
+		Title  string   `json:"title"`
+		Reason string   `json:"reason"`
+	}
+	obj := extractJSONObject(response)
+	if obj == "" {
+		return nil, fmt.Errorf("chart recommendation is not JSON")
+	}
+	if err := json.Unmarshal([]byte(obj), &parsed); err != nil {
+		return nil, fmt.Errorf("parse chart recommendation: %w", err)
+	}
+	return &models.ChartRecommendation{
+		Type:          strings.ToLower(strings.TrimSpace(parsed.Type)),
+		X:             strings.TrimSpace(parsed.X),
+		Y:             parsed.Y,
+		Series:        strings.TrimSpace(parsed.Series),
+		Title:         strings.TrimSpace(parsed.Title),
+		Reason:        strings.TrimSpace(parsed.Reason),
+		Source:        "llm",
+		PromptVersion: rendered.Label(),
+	}, nil
+}
+
+func describeChart(rec *models.ChartRecommendation) string {
+	if rec.Type == "table" || rec.X == "" {
+		return "table (" + rec.Reason + ")"
+	}
+	text := fmt.Sprintf("%s: x=%s, y=%s", rec.Type, rec.X, strings.Join(rec.Y, ", "))
+	if rec.Series != "" {
+		text += ", series=" + rec.Series
+	}
+	return text
+}
@@ -1,69 +1,153 @@
 package llm
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/yourusername/db_asst/config"
 	"github.com/yourusername/db_asst/internal/models"
+	"github.com/yourusername/db_asst/internal/prompts"
 )
 
+// defaultTemperature is used for every call except sampled SQL candidates.
+const defaultTemperature = 0.7
+
 type LLMClient struct {
-	provider   string
-	apiKey     string
-	model      string
-	baseURL    string
-	timeout    time.Duration
-	httpClient *http.Client
-	logger     *zap.Logger
+	providerName string
+	provider     Provider
+	model        string
+	timeout      time.Duration
+	prompts      *prompts.Service
+	datasource   string
+	backends     []*backend // primary first, then fallback
+	retry        RetryPolicy
+	observer     AttemptObserver
+	meter        Meter
+	jsonMode     bool
+	logger       *zap.Logger
 }
 
-// NewLLMClient creates a new LLM client with flexible API support
-func NewLLMClient(cfg *config.Config, logger *zap.Logger) *LLMClient {
-	client := &LLMClient{
-		provider: cfg.LLMProvider,
-		apiKey:   cfg.LLMAPIKey,
+// NewLLMClient creates a new LLM client backed by the provider registered
+// under cfg.LLMProvider, rendering prompts from promptSvc
+func NewLLMClient(cfg *config.Config, promptSvc *prompts.Service, logger *zap.Logger) (*LLMClient, error) {
+	timeout := time.Duration(cfg.LLMTimeout) * time.Second
+	provider, err := NewProvider(cfg.LLMProvider, ProviderConfig{
+		APIKey:         cfg.LLMAPIKey,
+		Model:          cfg.LLMModel,
+		BaseURL:        cfg.LLMBaseURL,
+		APIVersion:     cfg.LLMAPIVersion,
+		EmbeddingModel: cfg.LLMEmbeddingModel,
+		Fixtures:       cfg.LLMMockFixtures,
+		HTTPClient:     &http.Client{Timeout: timeout},
+		Logger:         logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	breakerCooldown := time.Duration(cfg.LLMBreakerCooldownSec) * time.Second
+	backends := []*backend{{
+		provider: provider,
 		model:    cfg.LLMModel,
-		baseURL:  cfg.LLMBaseURL,
-		timeout:  time.Duration(cfg.LLMTimeout) * time.Second,
-		httpClient: &http.Client{
-			Timeout: time.Duration(cfg.LLMTimeout) * time.Second,
+		breaker:  newCircuitBreaker(cfg.LLMBreakerThreshold, breakerCooldown),
+	}}
+	if cfg.LLMFallbackProvider != "" || cfg.LLMFallbackModel != "" {
+		fallbackCfg := ProviderConfig{
+			APIKey:         cfg.LLMFallbackAPIKey,
+			Model:          cfg.LLMFallbackModel,
+			BaseURL:        cfg.LLMFallbackBaseURL,
+			APIVersion:     cfg.LLMAPIVersion,
+			EmbeddingModel: cfg.LLMEmbeddingModel,
+			Fixtures:       cfg.LLMMockFixtures,
+			HTTPClient:     &http.Client{Timeout: timeout},
+			Logger:         logger,
+		}
+		fallbackName := cfg.LLMFallbackProvider
+		if fallbackName == "" {
+			// Same provider, different model: reuse the primary credentials.
+			fallbackName = cfg.LLMProvider
+			fallbackCfg.APIKey = cfg.LLMAPIKey
+			fallbackCfg.BaseURL = cfg.LLMBaseURL
+		}
+		if fallbackCfg.Model == "" {
+			fallbackCfg.Model = cfg.LLMModel
+		}
+		fallback, err := NewProvider(fallbackName, fallbackCfg)
+		if err != nil {
+			return nil, fmt.Errorf("fallback provider: %w", err)
+		}
+		backends = append(backends, &backend{
+			provider: fallback,
+			model:    fallbackCfg.Model,
+			breaker:  newCircuitBreaker(cfg.LLMBreakerThreshold, breakerCooldown),
+		})
+	}
+	if promptSvc == nil {
+		if promptSvc, err = prompts.NewService(nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return &LLMClient{
+		providerName: provider.Name(),
+		provider:     provider,
+		model:        cfg.LLMModel,
+		timeout:      timeout,
+		prompts:      promptSvc,
+		datasource:   cfg.GetDatasourceName(),
+		backends:     backends,
+		jsonMode:     cfg.LLMJSONMode,
+		retry: RetryPolicy{
+			MaxRetries: cfg.LLMMaxRetries,
+			BaseDelay:  time.Duration(cfg.LLMRetryBaseMs) * time.Millisecond,
+			MaxDelay:   time.Duration(cfg.LLMRetryMaxMs) * time.Millisecond,
 		},
 		logger: logger,
-	}
+	}, nil
+}
 
-	// Set default base URLs if not provided
-	if client.baseURL == "" {
-		switch client.provider {
-		case "openai":
-			client.baseURL = "https://api.openai.com/v1"
-		case "claude":
-			client.baseURL = "https://api.anthropic.com/v1"
-		default:
-			client.baseURL = cfg.LLMBaseURL
-		}
-	}
+// OnAttempt registers an observer called after every provider attempt
+// (retries and fallbacks included). Call it before serving requests.
+func (c *LLMClient) OnAttempt(observer AttemptObserver) {
+	c.observer = observer
+}
 
-	return client
+// Provider exposes the underlying provider (embeddings, token counting)
+func (c *LLMClient) Provider() Provider {
+	return c.provider
+}
+
+// Prompts exposes the prompt template service
+func (c *LLMClient) Prompts() *prompts.Service {
+	return c.prompts
+}
+
+// Model is the configured primary model
+func (c *LLMClient) Model() string {
+	return c.model
+}
+
+// Datasource is the key used to pick datasource-specific prompt templates
+func (c *LLMClient) Datasource() string {
+	return c.datasource
 }
 
 // GenerateSQL generates SQL from natural language
-func (c *LLMClient) GenerateSQL(ctx context.Context, req *models.SQLGenerateRequest, schemaContext string, memoryContext string) (*models.SQLGenerateResponse, error) {
-	// Build the prompt
-	prompt := c.buildSQLGenerationPrompt(req, schemaContext, memoryContext)
+func (c *LLMClient) GenerateSQL(ctx context.Context, req *models.SQLGenerateRequest, gc GenerationContext) (*models.SQLGenerateResponse, error) {
+	rendered, err := c.renderPrompt(prompts.SQLGeneration, sqlGenerationData(req, gc))
+	if err != nil {
+		return nil, err
+	}
 
 	// Call LLM API
-	response, err := c.callLLMAPI(ctx, prompt)
+	response, model, err := c.completeAt(ctx, rendered, c.sqlSchema(), defaultTemperature)
 	if err != nil {
 		c.logger.Error("Failed to call LLM API", zap.Error(err))
 		return nil, err
@@ -71,54 +155,91 @@ func (c *LLMClient) GenerateSQL(ctx context.Context, req *models.SQLGenerateRequ
 
 	// Parse the response
 	sqlResp := c.parseSQLGenerationResponse(response)
+	sqlResp.PromptVersion = rendered.Label()
+	sqlResp.Model = model
 
 	return sqlResp, nil
 }
 
+// GenerateSQLCandidates samples one generation per temperature, concurrently.
+// Results are index-aligned with temperatures; a failed sample has a nil
+// response and its error in errs.
+func (c *LLMClient) GenerateSQLCandidates(ctx context.Context, req *models.SQLGenerateRequest, gc GenerationContext, temperatures []float64) ([]*models.SQLGenerateResponse, []error) {
+	results := make([]*models.SQLGenerateResponse, len(temperatures))
+	errs := make([]error, len(temperatures))
+	rendered, err := c.renderPrompt(prompts.SQLGeneration, sqlGenerationData(req, gc))
+	if err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return results, errs
+	}
+
+	var wg sync.WaitGroup
+	for i, temperature := range temperatures {
+		wg.Add(1)
+		go func(i int, temperature float64) {
+			defer wg.Done()
+			response, model, err := c.completeAt(ctx, rendered, c.sqlSchema(), temperature)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			results[i] = c.parseSQLGenerationResponse(response)
+			results[i].PromptVersion = rendered.Label()
+			results[i].Model = model
+		}(i, temperature)
+	}
+	wg.Wait()
+	return results, errs
+}
+
 // GenerateSQLStream streams SQL chunks back via callback.
 func (c *LLMClient) GenerateSQLStream(
 	ctx context.Context,
 	req *models.SQLGenerateRequest,
-	schemaContext string,
-	memoryContext string,
+	gc GenerationContext,
 	onChunk func(string),
 ) (*models.SQLGenerateResponse, error) {
-	if !c.supportsStreaming() {
-		resp, err := c.GenerateSQL(ctx, req, schemaContext, memoryContext)
-		if err == nil && onChunk != nil {
-			onChunk(resp.SQL)
-		}
-		return resp, err
+	rendered, err := c.renderPrompt(prompts.SQLGeneration, sqlGenerationData(req, gc))
+	if err != nil {
+		return nil, err
 	}
-
-	prompt := c.buildSQLGenerationPrompt(req, schemaContext, memoryContext)
 	var builder strings.Builder
+	// Clients are shown only the SQL as it streams, not the JSON envelope.
+	sqlChunks := newSQLFieldStreamer(onChunk)
 
-	if err := c.callLLMStream(ctx, prompt, func(chunk string) {
+	model, err := c.stream(ctx, rendered, c.sqlSchema(), func(chunk string) {
 		builder.WriteString(chunk)
-		if onChunk != nil {
-			onChunk(chunk)
-		}
-	}); err != nil {
+		sqlChunks.Write(chunk)
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	sql := strings.TrimSpace(builder.String())
-	if sql == "" {
+	if strings.TrimSpace(builder.String()) == "" {
 		return nil, fmt.Errorf("no SQL returned from stream")
 	}
 
-	return &models.SQLGenerateResponse{
-		SQL:       sql,
-		Reasoning: "Generated by LLM",
-		Source:    "llm",
-	}, nil
+	resp := c.parseSQLGenerationResponse(builder.String())
+	resp.PromptVersion = rendered.Label()
+	resp.Model = model
+	return resp, nil
 }
 
 // GenerateGuidance asks LLM to help users refine their query when SQL无法生成.
-func (c *LLMClient) GenerateGuidance(ctx context.Context, originalQuery, schemaContext, issue string) (string, error) {
-	prompt := c.buildGuidancePrompt(originalQuery, schemaContext, issue)
-	response, err := c.callLLMAPI(ctx, prompt)
+// language names the language to answer in; empty leaves it to the template.
+func (c *LLMClient) GenerateGuidance(ctx context.Context, originalQuery, schemaContext, issue, language string) (string, error) {
+	rendered, err := c.renderPrompt(prompts.Guidance, prompts.GuidanceData{
+		Query:    originalQuery,
+		Schema:   strings.TrimSpace(schemaContext),
+		Issue:    strings.TrimSpace(issue),
+		Language: strings.TrimSpace(language),
+	})
+	if err != nil {
+		return "", err
+	}
+	response, err := c.complete(ctx, rendered, nil)
 	if err != nil {
 		return "", err
 	}
@@ -127,11 +248,17 @@ func (c *LLMClient) GenerateGuidance(ctx context.Context, originalQuery, schemaC
 
 // DebugSQL generates debugging suggestions for a failed SQL query
 func (c *LLMClient) DebugSQL(ctx context.Context, req *models.SQLDebugRequest, schemaContext string) (*models.SQLDebugResponse, error) {
-	// Build the debug prompt
-	prompt := c.buildDebugPrompt(req, schemaContext)
+	rendered, err := c.renderPrompt(prompts.Debug, prompts.DebugData{
+		Schema: schemaContext,
+		SQL:    req.SQL,
+		Error:  req.Error,
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	// Call LLM API
-	response, err := c.callLLMAPI(ctx, prompt)
+	response, err := c.complete(ctx, rendered, nil)
 	if err != nil {
 		c.logger.Error("Failed to call LLM API for debugging", zap.Error(err))
 		return nil, err
@@ -143,290 +270,243 @@ func (c *LLMClient) DebugSQL(ctx context.Context, req *models.SQLDebugRequest, s
 	return debugResp, nil
 }
 
-// callLLMAPI calls the actual LLM API
-func (c *LLMClient) callLLMAPI(ctx context.Context, prompt string) (string, error) {
-	switch c.provider {
-	case "openai":
-		return c.callOpenAIAPI(ctx, prompt)
-	case "deepseek":
-		return c.callOpenAIAPI(ctx, prompt)
-	case "claude":
-		return c.callClaudeAPI(ctx, prompt)
-	default:
-		// Generic API call for custom/proxy services
-		return c.callGenericAPI(ctx, prompt)
+// Ping checks that the LLM endpoint is reachable and accepts the API key.
+// Providers without a cheap probe are not checked.
+func (c *LLMClient) Ping(ctx context.Context) error {
+	if pinger, ok := c.provider.(Pinger); ok {
+		return pinger.Ping(ctx)
 	}
+	return nil
 }
 
-func (c *LLMClient) buildGuidancePrompt(originalQuery, schemaContext, issue string) string {
-	builder := strings.Builder{}
-	builder.WriteString("你是一名经验丰富的 BI 助手，需要帮助用户改进提问方式以便生成正确 SQL。\n")
-	builder.WriteString("## 用户当前提问\n")
-	builder.WriteString(originalQuery)
-	builder.WriteString("\n\n")
-	if strings.TrimSpace(schemaContext) != "" {
-		builder.WriteString("## 已知的数据库信息\n")
-		builder.WriteString(schemaContext)
-		builder.WriteString("\n\n")
-	}
-	if strings.TrimSpace(issue) != "" {
-		builder.WriteString("## LLM 或数据库反馈的限制/错误\n")
-		builder.WriteString(issue)
-		builder.WriteString("\n\n")
-	}
-	builder.WriteString("请用中文向用户说明原因，并提供 2~3 条可执行的改进建议，例如提供需要的表字段、限定时间范围或换个表述方式。不要返回 SQL，只给出建议。")
-	return builder.String()
+// GenerationContext is the server-side context injected into SQL generation.
+type GenerationContext struct {
+	Schema   string
+	Memory   string
+	Glossary string // matched business glossary terms
+	Examples string // few-shot question/SQL demonstrations
 }
 
-// callOpenAIAPI calls OpenAI API
-func (c *LLMClient) callOpenAIAPI(ctx context.Context, prompt string) (string, error) {
-	url := fmt.Sprintf("%s/chat/completions", c.baseURL)
-
-	payload := map[string]interface{}{
-		"model":       c.model,
-		"messages":    []map[string]string{{"role": "user", "content": prompt}},
-		"temperature": 0.7,
-		"max_tokens":  2000,
-	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return "", err
+func sqlGenerationData(req *models.SQLGenerateRequest, gc GenerationContext) prompts.SQLGenerationData {
+	return prompts.SQLGenerationData{
+		Schema:             gc.Schema,
+		Memory:             strings.TrimSpace(gc.Memory),
+		Glossary:           strings.TrimSpace(gc.Glossary),
+		Examples:           strings.TrimSpace(gc.Examples),
+		AdditionalContext:  strings.TrimSpace(req.Context),
+		Query:              req.Query,
+		AllowClarification: req.AllowClarification != nil && *req.AllowClarification,
+		Clarification:      clarificationText(req),
 	}
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("OpenAI API error: %d - %s", resp.StatusCode, string(respBody))
-	}
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
-	}
-
-	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
-	}
-
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", err
-	}
-
-	if len(result.Choices) > 0 {
-		return result.Choices[0].Message.Content, nil
-	}
-
-	return "", fmt.Errorf("no response from OpenAI API")
 }
 
-// callClaudeAPI calls Claude API
-func (c *LLMClient) callClaudeAPI(ctx context.Context, prompt string) (string, error) {
-	url := fmt.Sprintf("%s/messages", c.baseURL)
-
-	payload := map[string]interface{}{
-		"model":      c.model,
-		"max_tokens": 2000,
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
+func clarificationText(req *models.SQLGenerateRequest) string {
+	answer := strings.TrimSpace(req.ClarificationAnswer)
+	if answer == "" {
+		return ""
 	}
-
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return "", err
+	if question := strings.TrimSpace(req.ClarificationQuestion); question != "" {
+		return fmt.Sprintf("Q: %s\nA: %s", question, answer)
 	}
+	return "A: " + answer
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
-	if err != nil {
-		return "", err
+// renderPrompt renders the active template for the client's datasource.
+// Store lookup and stored template errors fall back to the built-in template.
+func (c *LLMClient) renderPrompt(name string, data interface{}) (*prompts.Rendered, error) {
+	rendered, err := c.prompts.Render(name, c.datasource, data)
+	if rendered == nil {
+		return nil, err
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-api-key", c.apiKey)
-	req.Header.Set("anthropic-version", "2023-06-01")
-
-	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		c.logger.Warn("Stored prompt template unavailable, using builtin template",
+			zap.String("template", name), zap.Error(err))
 	}
-	defer resp.Body.Close()
+	return rendered, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("Claude API error: %d - %s", resp.StatusCode, string(respBody))
-	}
-
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", err
+func toMessages(rendered *prompts.Rendered) []Message {
+	messages := make([]Message, 0, len(rendered.Messages))
+	for _, msg := range rendered.Messages {
+		messages = append(messages, Message{Role: msg.Role, Content: msg.Content})
 	}
+	return messages
+}
 
-	var result struct {
-		Content []struct {
-			Text string `json:"text"`
-		} `json:"content"`
+// sqlSchema is the output schema for SQL generation, or nil when native JSON
+// mode is disabled.
+func (c *LLMClient) sqlSchema() *JSONSchema {
+	if !c.jsonMode {
+		return nil
 	}
+	return SQLGenerationSchema
+}
 
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", err
+// rejectedSchema reports a 400 about response_format or tools on a
+// structured request, typically a model or gateway without native JSON
+// output. Other 400s, such as an exceeded context length, are not retried.
+func rejectedSchema(req CompletionRequest, err error) bool {
+	var apiErr *APIError
+	if req.Schema == nil || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
 	}
-
-	if len(result.Content) > 0 {
-		return result.Content[0].Text, nil
+	body := strings.ToLower(apiErr.Body)
+	for _, hint := range []string{"response_format", "json_schema", "json_object", "tool"} {
+		if strings.Contains(body, hint) {
+			return true
+		}
 	}
-
-	return "", fmt.Errorf("no response from Claude API")
+	return false
 }
 
-// callGenericAPI calls a generic LLM API (for custom/proxy services)
-func (c *LLMClient) callGenericAPI(ctx context.Context, prompt string) (string, error) {
-	// Assume the service follows OpenAI-compatible API format
-	url := fmt.Sprintf("%s/chat/completions", c.baseURL)
-
-	payload := map[string]interface{}{
-		"model":       c.model,
-		"messages":    []map[string]string{{"role": "user", "content": prompt}},
-		"temperature": 0.7,
-		"max_tokens":  2000,
+// structuredRequest drops the schema for backends that rejected it before.
+func (c *LLMClient) structuredRequest(b *backend, req CompletionRequest) CompletionRequest {
+	if req.Schema != nil && b.schemaRejected.Load() {
+		return withoutSchema(req)
 	}
+	return req
+}
 
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return "", err
+// schemaFallback remembers that b rejected the schema, warning the first
+// time, and returns req without it for the retry.
+func (c *LLMClient) schemaFallback(b *backend, req CompletionRequest, err error) CompletionRequest {
+	if !b.schemaRejected.Swap(true) {
+		c.logger.Warn("Structured output rejected, sending prompts without schema to this backend from now on",
+			zap.String("provider", b.provider.Name()),
+			zap.String("model", b.model),
+			zap.Error(err))
 	}
+	return withoutSchema(req)
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
-	if err != nil {
-		return "", err
-	}
+func withoutSchema(req CompletionRequest) CompletionRequest {
+	req.Schema = nil
+	return req
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	if c.apiKey != "" {
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-	}
+// complete sends the rendered messages through the backend chain
+func (c *LLMClient) complete(ctx context.Context, rendered *prompts.Rendered, schema *JSONSchema) (string, error) {
+	content, _, err := c.completeAt(ctx, rendered, schema, defaultTemperature)
+	return content, err
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
+// completeAt also returns the model that answered, which is a fallback
+// backend's when the primary failed.
+func (c *LLMClient) completeAt(ctx context.Context, rendered *prompts.Rendered, schema *JSONSchema, temperature float64) (string, string, error) {
+	if err := c.allow(ctx); err != nil {
+		return "", "", err
+	}
+	req := CompletionRequest{
+		Messages:    toMessages(rendered),
+		Temperature: temperature,
+		MaxTokens:   2000,
+		Schema:      schema,
+	}
+	var content, model string
+	err := c.call(ctx, "complete", func(b *backend) error {
+		attemptReq := c.structuredRequest(b, req)
+		resp, err := b.provider.Complete(ctx, attemptReq)
+		if rejectedSchema(attemptReq, err) {
+			attemptReq = c.schemaFallback(b, attemptReq, err)
+			resp, err = b.provider.Complete(ctx, attemptReq)
+		}
+		if err != nil {
+			return err
+		}
+		content, model = resp.Content, servedModel(b, resp)
+		c.account(ctx, rendered.Name, b, attemptReq, resp.Content, resp.Usage)
+		return nil
+	}, nil)
+	return content, model, err
+}
 
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("LLM API error: %d - %s", resp.StatusCode, string(respBody))
+// servedModel is the model a provider reported for resp, or the backend's
+// configured one.
+func servedModel(b *backend, resp *CompletionResponse) string {
+	if resp != nil && resp.Model != "" {
+		return resp.Model
 	}
+	return b.model
+}
 
-	respBody, err := io.ReadAll(resp.Body)
-	if err != nil {
+// stream streams through the backend chain. Backends that cannot stream are
+// called once and their full answer is delivered as a single chunk. Once a
+// chunk has been delivered the call is not retried. It returns the model of
+// the backend that answered.
+func (c *LLMClient) stream(ctx context.Context, rendered *prompts.Rendered, schema *JSONSchema, onChunk func(string)) (string, error) {
+	if err := c.allow(ctx); err != nil {
 		return "", err
 	}
-
-	var result struct {
-		Choices []struct {
-			Message struct {
-				Content string `json:"content"`
-			} `json:"message"`
-		} `json:"choices"`
+	req := CompletionRequest{
+		Messages:    toMessages(rendered),
+		Temperature: defaultTemperature,
+		MaxTokens:   2000,
+		Schema:      schema,
 	}
-
-	if err := json.Unmarshal(respBody, &result); err != nil {
-		return "", err
-	}
-
-	if len(result.Choices) > 0 {
-		return result.Choices[0].Message.Content, nil
+	var output strings.Builder
+	emit := func(chunk string) {
+		output.WriteString(chunk)
+		if onChunk != nil {
+			onChunk(chunk)
+		}
 	}
-
-	return "", fmt.Errorf("no response from LLM API")
-}
-
-// buildSQLGenerationPrompt builds a prompt for SQL generation
-func (c *LLMClient) buildSQLGenerationPrompt(req *models.SQLGenerateRequest, schemaContext string, memoryContext string) string {
-	prompt := fmt.Sprintf(`You are an expert SQL developer. Use the recent conversation memory to continue the thread (it may include errors from earlier attempts). Based on the following database schema and user request, generate a valid Oracle SQL query.
-
-DATABASE SCHEMA:
-%s
-
-%s
-%s
-
-USER REQUEST:
-%s
-
-Please respond with ONLY the SQL query, without any explanation or markdown formatting. The query should:
-1. Be valid Oracle SQL syntax
-2. Only use SELECT statements
-3. Only reference tables and columns that exist in the schema
-4. Be optimized for performance
-
-When the latest user query is vague, infer intent from the conversation memory. If the schema genuinely lacks required tables, return a concise ERROR explaining the missing data source (in Chinese). If the user's request can be reformulated using context, propose the best-guess SQL instead of failing.
-If you cannot generate a valid query, respond with "ERROR: [reason]"`,
-		schemaContext,
-		formatMemoryContext(memoryContext),
-		formatAdditionalContext(req.Context),
-		req.Query)
-
-	return prompt
-}
-
-// buildDebugPrompt builds a prompt for SQL debugging
-func (c *LLMClient) buildDebugPrompt(req *models.SQLDebugRequest, schemaContext string) string {
-	prompt := fmt.Sprintf(`You are an expert Oracle SQL developer. A user tried to execute the following SQL query but got an error. Please analyze the error and suggest a fixed version.
-
-DATABASE SCHEMA:
-%s
-
-ORIGINAL QUERY:
-%s
-
-ERROR MESSAGE:
-%s
-
-Please respond in JSON format with the following structure:
-{
-  "analysis": "Brief explanation of what went wrong",
-  "suggested_sql": "The corrected SQL query (or empty if query is fundamentally wrong)",
-  "explanation": "Detailed explanation of the fix"
-}
-
-Ensure the suggested SQL:
-1. Is valid Oracle SQL syntax
-2. Only uses SELECT statements
-3. Only references tables and columns that exist in the schema`,
-		schemaContext,
-		req.SQL,
-		req.Error)
-
-	return prompt
+	var model string
+	err := c.call(ctx, "stream", func(b *backend) error {
+		model = b.model
+		attemptReq := c.structuredRequest(b, req)
+		usage, err := b.provider.Stream(ctx, attemptReq, emit)
+		if rejectedSchema(attemptReq, err) {
+			attemptReq = c.schemaFallback(b, attemptReq, err)
+			usage, err = b.provider.Stream(ctx, attemptReq, emit)
+		}
+		if errors.Is(err, ErrStreamingUnsupported) {
+			resp, cerr := b.provider.Complete(ctx, attemptReq)
+			if cerr != nil {
+				return cerr
+			}
+			emit(resp.Content)
+			usage, err = resp.Usage, nil
+			model = servedModel(b, resp)
+		}
+		// A stream that failed mid-way was still billed for what it produced.
+		if err == nil || output.Len() > 0 {
+			c.account(ctx, rendered.Name, b, attemptReq, output.String(), usage)
+		}
+		return err
+	}, func() bool { return output.Len() > 0 })
+	return model, err
 }
 
-// parseSQLGenerationResponse parses LLM response for SQL generation
+// parseSQLGenerationResponse parses the structured LLM reply for SQL generation
 func (c *LLMClient) parseSQLGenerationResponse(response string) *models.SQLGenerateResponse {
-	response = c.cleanResponse(response)
-
-	return &models.SQLGenerateResponse{
-		SQL:       response,
-		Reasoning: "Generated by LLM",
-		Source:    "llm",
+	parsed := ParseStructuredSQL(response)
+	if !parsed.Structured {
+		c.logger.Debug("LLM reply was not structured JSON, parsed as plain SQL")
+	}
+
+	reasoning := parsed.Explanation
+	if reasoning == "" {
+		reasoning = "Generated by LLM"
+	}
+	resp := &models.SQLGenerateResponse{
+		SQL:                parsed.SQL,
+		Reasoning:          reasoning,
+		Explanation:        parsed.Explanation,
+		TablesUsed:         parsed.TablesUsed,
+		Assumptions:        parsed.Assumptions,
+		Confidence:         parsed.Confidence,
+		ClarifyingQuestion: parsed.ClarifyingQuestion,
+		Source:             "llm",
+	}
+	if parsed.SQL == "" && parsed.ClarifyingQuestion != "" {
+		resp.Clarification = &models.Clarification{
+			Question: parsed.ClarifyingQuestion,
+			Type:     parsed.ClarificationType,
+			Options:  parsed.ClarificationOptions,
+		}
+		resp.Reasoning = parsed.ClarifyingQuestion
 	}
+	return resp
 }
 
 // parseDebugResponse parses LLM response for debugging
@@ -437,11 +517,12 @@ func (c *LLMClient) parseDebugResponse(response string) *models.SQLDebugResponse
 		Explanation  string `json:"explanation"`
 	}
 
-	// Try to parse as JSON
-	if err := json.Unmarshal([]byte(response), &debugResp); err != nil {
+	// Try to parse as JSON, tolerating fences or prose around the object
+	obj := extractJSONObject(response)
+	if obj == "" || json.Unmarshal([]byte(obj), &debugResp) != nil {
 		// If not JSON, treat the whole response as analysis
 		return &models.SQLDebugResponse{
-			AnalysisText: response,
+			AnalysisText: strings.TrimSpace(response),
 			SuggestedSQL: "",
 			Explanation:  "",
 		}
@@ -449,132 +530,7 @@ func (c *LLMClient) parseDebugResponse(response string) *models.SQLDebugResponse
 
 	return &models.SQLDebugResponse{
 		AnalysisText: debugResp.Analysis,
-		SuggestedSQL: debugResp.SuggestedSQL,
+		SuggestedSQL: ParseStructuredSQL(debugResp.SuggestedSQL).SQL,
 		Explanation:  debugResp.Explanation,
 	}
 }
-
-// cleanResponse removes markdown and extra formatting
-func (c *LLMClient) cleanResponse(response string) string {
-	// Remove markdown code blocks
-	response = strings.ReplaceAll(response, "```sql\n", "")
-	response = strings.ReplaceAll(response, "```\n", "")
-	response = strings.ReplaceAll(response, "```", "")
-
-	return strings.TrimSpace(response)
-}
-
-// formatAdditionalContext formats additional context for the prompt
-func formatAdditionalContext(context string) string {
-	if context == "" {
-		return ""
-	}
-	return fmt.Sprintf("ADDITIONAL CONTEXT:\n%s\n", context)
-}
-
-func formatMemoryContext(memoryContext string) string {
-	if strings.TrimSpace(memoryContext) == "" {
-		return ""
-	}
-	return fmt.Sprintf("CONVERSATION MEMORY (recent history, auto-compressed; use it to continue the thread even if the latest user query is brief):\n%s\n", memoryContext)
-}
-
-func (c *LLMClient) supportsStreaming() bool {
-	switch strings.ToLower(c.provider) {
-	case "claude":
-		return false
-	default:
-		return true
-	}
-}
-
-func (c *LLMClient) callLLMStream(ctx context.Context, prompt string, onChunk func(string)) error {
-	switch strings.ToLower(c.provider) {
-	case "openai", "custom", "deepseek":
-		return c.callOpenAIStream(ctx, prompt, onChunk)
-	default:
-		return fmt.Errorf("streaming not supported for provider %s", c.provider)
-	}
-}
-
-func (c *LLMClient) callOpenAIStream(ctx context.Context, prompt string, onChunk func(string)) error {
-	url := fmt.Sprintf("%s/chat/completions", c.baseURL)
-
-	payload := map[string]interface{}{
-		"model":       c.model,
-		"messages":    []map[string]string{{"role": "user", "content": prompt}},
-		"temperature": 0.7,
-		"stream":      true,
-	}
-	body, err := json.Marshal(payload)
-	if err != nil {
-		return err
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(body))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("LLM stream error: %d - %s", resp.StatusCode, string(respBody))
-	}
-
-	scanner := bufio.NewScanner(resp.Body)
-	buf := make([]byte, 0, 64*1024)
-	scanner.Buffer(buf, 1024*1024)
-
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line == "" || !strings.HasPrefix(line, "data:") {
-			continue
-		}
-
-		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
-		if data == "[DONE]" {
-			break
-		}
-
-		var chunk struct {
-			Choices []struct {
-				Delta struct {
-					Content string `json:"content"`
-				} `json:"delta"`
-				Message struct {
-					Content string `json:"content"`
-				} `json:"message"`
-			} `json:"choices"`
-		}
-
-		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
-			continue
-		}
-
-		if len(chunk.Choices) == 0 {
-			continue
-		}
-
-		content := chunk.Choices[0].Delta.Content
-		if content == "" {
-			content = chunk.Choices[0].Message.Content
-		}
-		if content != "" && onChunk != nil {
-			onChunk(content)
-		}
-	}
-
-	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
-		return err
-	}
-
-	return nil
-}
@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/internal/models"
+	"github.com/yourusername/db_asst/internal/prompts"
+)
+
+// ExplainSchema describes the JSON reply for SQL explanation.
+var ExplainSchema = &JSONSchema{
+	Name:        "sql_explanation",
+	Description: "Explain an Oracle SQL query step by step in plain language",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"summary":  map[string]interface{}{"type": "string"},
+			"steps":    map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"warnings": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+		"required":             []string{"summary", "steps", "warnings"},
+		"additionalProperties": false,
+	},
+}
+
+// ExplainSQL asks for a plain-language explanation of req.SQL. catalog lists
+// the referenced tables and columns with their comments and findings the
+// static analysis results; both may be empty.
+func (c *LLMClient) ExplainSQL(ctx context.Context, req *models.SQLExplainRequest, catalog, findings string) (*models.SQLExplainResponse, error) {
+	rendered, err := c.renderPrompt(prompts.Explain, prompts.ExplainData{
+		SQL:      strings.TrimSpace(req.SQL),
+		Context:  strings.TrimSpace(req.Context),
+		Catalog:  strings.TrimSpace(catalog),
+		Findings: strings.TrimSpace(findings),
+		Language: strings.TrimSpace(req.Language),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var schema *JSONSchema
+	if c.jsonMode {
+		schema = ExplainSchema
+	}
+	response, err := c.complete(ctx, rendered, schema)
+	if err != nil {
+		c.logger.Error("Failed to call LLM API for SQL explanation", zap.Error(err))
+		return nil, err
+	}
+	resp := parseExplainResponse(response)
+	resp.PromptVersion = rendered.Label()
+	return resp, nil
+}
+
+// parseExplainResponse parses the JSON reply, treating anything else as the
+// summary.
+func parseExplainResponse(response string) *models.SQLExplainResponse {
+	var parsed struct {
+		Summary  string   `json:"summary"`
+		Steps    []string `json:"steps"`
+		Warnings []string `json:"warnings"`
+	}
+	resp := &models.SQLExplainResponse{Steps: []string{}, Warnings: []string{}}
+	obj := extractJSONObject(response)
+	if obj == "" || json.Unmarshal([]byte(obj), &parsed) != nil || (parsed.Summary == "" && len(parsed.Steps) == 0) {
+		resp.Summary = strings.TrimSpace(response)
+		return resp
+	}
+	resp.Summary = strings.TrimSpace(parsed.Summary)
+	for _, step := range parsed.Steps {
+		if step = strings.TrimSpace(step); step != "" {
+			resp.Steps = append(resp.Steps, step)
+		}
+	}
+	for _, warning := range parsed.Warnings {
+		if warning = strings.TrimSpace(warning); warning != "" {
+			resp.Warnings = append(resp.Warnings, warning)
+		}
+	}
+	return resp
+}
@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/config"
+	"github.com/yourusername/db_asst/internal/models"
+	"github.com/yourusername/db_asst/internal/prompts"
+)
+
+// newMockClient builds a client the way the server does, from the
+// environment, with LLM_PROVIDER=mock and the example fixtures.
+func newMockClient(t *testing.T) *LLMClient {
+	t.Helper()
+	t.Setenv("LLM_PROVIDER", "mock")
+	t.Setenv("LLM_MODEL", "mock-sql")
+	t.Setenv("LLM_MOCK_FIXTURES", "../../testdata/mock-fixtures.yaml")
+	t.Setenv("LLM_RETRY_BASE_MS", "1")
+	t.Setenv("LLM_RETRY_MAX_MS", "5")
+	promptSvc, err := prompts.NewService(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewLLMClient(config.LoadConfig(), promptSvc, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestMockGenerateSQL(t *testing.T) {
+	client := newMockClient(t)
+	ctx := context.Background()
+	gc := GenerationContext{Schema: "EMPLOYEES(EMPLOYEE_ID, DEPARTMENT_ID, SALARY)"}
+
+	resp, err := client.GenerateSQL(ctx, &models.SQLGenerateRequest{Query: "How many employees in each department?"}, gc)
+	if err != nil {
+		t.Fatalf("GenerateSQL: %v", err)
+	}
+	if !strings.Contains(resp.SQL, "GROUP BY d.DEPARTMENT_NAME") || resp.Explanation != "Counts employees per department." {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+	if resp.Model != "mock-sql" {
+		t.Errorf("Model = %q, want the model that answered", resp.Model)
+	}
+
+	// The first call fails with a 503 and is retried.
+	resp, err = client.GenerateSQL(ctx, &models.SQLGenerateRequest{Query: "How many open orders are there?"}, gc)
+	if err != nil {
+		t.Fatalf("GenerateSQL after retry: %v", err)
+	}
+	if !strings.Contains(resp.SQL, "OPEN_ORDERS") {
+		t.Errorf("unexpected SQL after retry: %q", resp.SQL)
+	}
+
+	_, err = client.GenerateSQL(ctx, &models.SQLGenerateRequest{Query: "Please drop everything"}, gc)
+	if err == nil || !strings.Contains(err.Error(), "request rejected by policy") {
+		t.Errorf("expected the scripted error, got %v", err)
+	}
+
+	resp, err = client.GenerateSQL(ctx, &models.SQLGenerateRequest{Query: "Something no fixture knows"}, gc)
+	if err != nil || resp.SQL != "SELECT 1 AS MOCK_RESULT FROM DUAL" {
+		t.Errorf("expected the default reply, got %+v, %v", resp, err)
+	}
+}
+
+func TestMockGenerateSQLStream(t *testing.T) {
+	client := newMockClient(t)
+	var chunks []string
+	resp, err := client.GenerateSQLStream(context.Background(), &models.SQLGenerateRequest{Query: "Top 5 products by revenue"}, GenerationContext{}, func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("GenerateSQLStream: %v", err)
+	}
+	want := "SELECT PRODUCT_NAME, SUM(AMOUNT) AS REVENUE FROM SALES GROUP BY PRODUCT_NAME ORDER BY REVENUE DESC FETCH FIRST 5 ROWS ONLY"
+	if resp.SQL != want {
+		t.Errorf("SQL = %q, want %q", resp.SQL, want)
+	}
+	if streamed := strings.Join(chunks, ""); streamed != want || len(chunks) < 2 {
+		t.Errorf("streamed %d chunks %q, want the SQL in several chunks", len(chunks), streamed)
+	}
+}
+
+func TestMockGenerateSQLAgent(t *testing.T) {
+	client := newMockClient(t)
+	columns := AgentTool{
+		Tool: Tool{Name: "get_table_columns", Parameters: map[string]interface{}{"type": "object"}},
+		Run: func(ctx context.Context, arguments string) (string, error) {
+			return "EMPLOYEE_ID NUMBER, SALARY NUMBER", nil
+		},
+	}
+	req := &models.SQLGenerateRequest{Query: "What is the average salary?"}
+
+	var steps []models.AgentStep
+	resp, err := client.GenerateSQLAgent(context.Background(), req, GenerationContext{}, []AgentTool{columns}, 4, func(step models.AgentStep) {
+		steps = append(steps, step)
+	})
+	if err != nil {
+		t.Fatalf("GenerateSQLAgent: %v", err)
+	}
+	if len(steps) != 1 || steps[0].Tool != "get_table_columns" {
+		t.Errorf("steps = %+v, want one get_table_columns call", steps)
+	}
+	if resp.SQL != "SELECT AVG(SALARY) AS AVG_SALARY FROM EMPLOYEES" {
+		t.Errorf("SQL = %q", resp.SQL)
+	}
+
+	// With one step the second turn is the last; it must call submit_sql
+	// although the fixture scripts another exploration.
+	steps = nil
+	resp, err = client.GenerateSQLAgent(context.Background(), req, GenerationContext{}, []AgentTool{columns}, 1, func(step models.AgentStep) {
+		steps = append(steps, step)
+	})
+	if err != nil {
+		t.Fatalf("GenerateSQLAgent with one step: %v", err)
+	}
+	if len(steps) != 1 || resp.SQL != "SELECT AVG(SALARY) AS AVG_SALARY FROM EMPLOYEES" {
+		t.Errorf("forced submit: steps %+v, SQL %q", steps, resp.SQL)
+	}
+}
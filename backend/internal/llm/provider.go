@@ -0,0 +1,290 @@
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrStreamingUnsupported is returned by Provider.Stream when the backend cannot stream.
+	ErrStreamingUnsupported = errors.New("streaming not supported by provider")
+	// ErrEmbeddingsUnsupported is returned by Provider.Embed when the backend has no embeddings API.
+	ErrEmbeddingsUnsupported = errors.New("embeddings not supported by provider")
+)
+
+// Message is one chat message sent to a provider.
+type Message struct {
+	Role    string `json:"role"` // "system", "user", "assistant" or "tool"
+	Content string `json:"content"`
+	// ToolCalls are the calls an assistant message made; ToolCallID links a
+	// "tool" message to the call it answers.
+	ToolCalls  []ToolCall `json:"-"`
+	ToolCallID string     `json:"-"`
+}
+
+// CompletionRequest describes a single chat completion call.
+type CompletionRequest struct {
+	Messages    []Message
+	Temperature float64
+	MaxTokens   int
+	// Schema, when set, requests JSON output matching it. Providers without a
+	// native mode ignore it and rely on the prompt.
+	Schema *JSONSchema
+	// Tools, when set, lets the model call functions instead of answering.
+	// Only providers implementing ToolCaller honour it.
+	Tools []Tool
+	// ToolChoice, when set, names the tool in Tools the model must call.
+	ToolChoice string
+}
+
+// Usage is the token usage reported by a provider. Zero fields mean the
+// provider did not report them.
+type Usage struct {
+	PromptTokens     int  `json:"prompt_tokens"`
+	CompletionTokens int  `json:"completion_tokens"`
+	Estimated        bool `json:"estimated,omitempty"`
+}
+
+// TotalTokens is prompt plus completion tokens.
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// CompletionResponse is the provider-neutral completion result.
+type CompletionResponse struct {
+	Content   string
+	ToolCalls []ToolCall
+	Model     string
+	Usage     Usage
+}
+
+// Provider is implemented by every LLM backend.
+type Provider interface {
+	Name() string
+	Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error)
+	Stream(ctx context.Context, req CompletionRequest, onChunk func(string)) (Usage, error)
+	Embed(ctx context.Context, inputs []string) ([][]float64, error)
+	CountTokens(text string) int
+}
+
+// Pinger is optionally implemented by providers that can check reachability
+// without spending tokens.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// ProviderConfig carries the settings a factory needs to build a provider.
+type ProviderConfig struct {
+	APIKey         string
+	Model          string
+	BaseURL        string
+	APIVersion     string
+	EmbeddingModel string
+	Fixtures       string // mock provider: scripted replies file (YAML or JSON)
+	HTTPClient     *http.Client
+	Logger         *zap.Logger
+}
+
+// Factory builds a provider from its configuration.
+type Factory func(cfg ProviderConfig) (Provider, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a provider factory available under name (case-insensitive).
+// Registering an existing name replaces it.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[strings.ToLower(strings.TrimSpace(name))] = factory
+}
+
+// fallbackProvider serves LLM_PROVIDER names that are not registered, as
+// any unknown name used to reach the generic OpenAI-compatible client.
+const fallbackProvider = "custom"
+
+// NewProvider builds the provider registered under name. Unregistered names
+// (e.g. "qwen" or "proxy") get the OpenAI-compatible "custom" provider.
+func NewProvider(name string, cfg ProviderConfig) (Provider, error) {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = zap.NewNop()
+	}
+	registryMu.RLock()
+	factory, ok := registry[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		if factory, ok = registry[fallbackProvider]; ok {
+			cfg.Logger.Warn("Unregistered LLM provider, using the OpenAI-compatible client",
+				zap.String("provider", name),
+				zap.String("using", fallbackProvider))
+		}
+	}
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown LLM provider %q (registered: %s)", name, strings.Join(RegisteredProviders(), ", "))
+	}
+	return factory(cfg)
+}
+
+// RegisteredProviders lists registered provider names.
+func RegisteredProviders() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// doJSON sends payload as JSON and returns the response when the status is 2xx.
+// label prefixes error messages (e.g. "OpenAI API").
+func doJSON(ctx context.Context, client *http.Client, method, url, label string, headers map[string]string, payload interface{}) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		if value != "" {
+			req.Header.Set(key, value)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, &APIError{
+			Label:      label,
+			StatusCode: resp.StatusCode,
+			Body:       string(respBody),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+	return resp, nil
+}
+
+// APIError is returned for non-2xx provider responses.
+type APIError struct {
+	Label      string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // zero when the server sent no Retry-After
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s error: %d - %s", e.Label, e.StatusCode, e.Body)
+}
+
+// parseRetryAfter accepts both delay-seconds and HTTP-date forms.
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// decodeJSON sends payload and decodes a successful response into out.
+func decodeJSON(ctx context.Context, client *http.Client, url, label string, headers map[string]string, payload, out interface{}) error {
+	resp, err := doJSON(ctx, client, http.MethodPost, url, label, headers, payload)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(respBody, out)
+}
+
+// scanLines calls onLine for each non-empty line of body until it returns false.
+func scanLines(body io.Reader, onLine func(line string) bool) error {
+	scanner := bufio.NewScanner(body)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !onLine(line) {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// estimateTokens approximates the token count without a tokenizer:
+// CJK characters count as one token each, other text as ~4 bytes per token.
+func estimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+	cjk := 0
+	other := 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			cjk++
+			continue
+		}
+		other += len(string(r))
+	}
+	return cjk + (other+3)/4
+}
+
+func defaultMaxTokens(value int) int {
+	if value <= 0 {
+		return 2000
+	}
+	return value
+}
@@ -0,0 +1,267 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const anthropicVersion = "2023-06-01"
+
+func init() {
+	Register("claude", newAnthropicProvider)
+	Register("anthropic", newAnthropicProvider)
+}
+
+// anthropicProvider implements the Anthropic Messages API.
+type anthropicProvider struct {
+	cfg     ProviderConfig
+	baseURL string
+	headers map[string]string
+}
+
+func newAnthropicProvider(cfg ProviderConfig) (Provider, error) {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com/v1"
+	}
+	return &anthropicProvider{
+		cfg:     cfg,
+		baseURL: baseURL,
+		headers: map[string]string{
+			"x-api-key":         cfg.APIKey,
+			"anthropic-version": anthropicVersion,
+		},
+	}, nil
+}
+
+func (p *anthropicProvider) Name() string {
+	return "claude"
+}
+
+// payload converts chat messages to the Messages API shape: system messages
+// move to the top-level "system" field, tool calls become tool_use blocks and
+// consecutive tool results are merged into one user turn.
+func (p *anthropicProvider) payload(req CompletionRequest, stream bool) map[string]interface{} {
+	var system []string
+	messages := make([]map[string]interface{}, 0, len(req.Messages))
+	for _, msg := range req.Messages {
+		switch {
+		case msg.Role == "system":
+			system = append(system, msg.Content)
+		case msg.Role == "tool":
+			result := map[string]interface{}{"type": "tool_result", "tool_use_id": msg.ToolCallID, "content": msg.Content}
+			if n := len(messages); n > 0 && messages[n-1]["role"] == "user" {
+				if blocks, ok := messages[n-1]["content"].([]map[string]interface{}); ok {
+					messages[n-1]["content"] = append(blocks, result)
+					continue
+				}
+			}
+			messages = append(messages, map[string]interface{}{"role": "user", "content": []map[string]interface{}{result}})
+		case len(msg.ToolCalls) > 0:
+			var blocks []map[string]interface{}
+			if msg.Content != "" {
+				blocks = append(blocks, map[string]interface{}{"type": "text", "text": msg.Content})
+			}
+			for _, call := range msg.ToolCalls {
+				blocks = append(blocks, map[string]interface{}{
+					"type":  "tool_use",
+					"id":    call.ID,
+					"name":  call.Name,
+					"input": json.RawMessage(toolArguments(call.Arguments)),
+				})
+			}
+			messages = append(messages, map[string]interface{}{"role": msg.Role, "content": blocks})
+		default:
+			// A user turn right after tool results joins them, keeping roles alternating.
+			if n := len(messages); n > 0 && msg.Role == "user" && messages[n-1]["role"] == "user" {
+				if blocks, ok := messages[n-1]["content"].([]map[string]interface{}); ok {
+					messages[n-1]["content"] = append(blocks, map[string]interface{}{"type": "text", "text": msg.Content})
+					continue
+				}
+			}
+			messages = append(messages, map[string]interface{}{"role": msg.Role, "content": msg.Content})
+		}
+	}
+	payload := map[string]interface{}{
+		"model":       p.cfg.Model,
+		"max_tokens":  defaultMaxTokens(req.MaxTokens),
+		"messages":    messages,
+		"temperature": req.Temperature,
+	}
+	if len(system) > 0 {
+		payload["system"] = strings.Join(system, "\n\n")
+	}
+	if len(req.Tools) > 0 {
+		tools := make([]map[string]interface{}, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			tools = append(tools, map[string]interface{}{
+				"name":         tool.Name,
+				"description":  tool.Description,
+				"input_schema": tool.Parameters,
+			})
+		}
+		payload["tools"] = tools
+		if req.ToolChoice != "" {
+			payload["tool_choice"] = map[string]interface{}{"type": "tool", "name": req.ToolChoice}
+		}
+	} else if req.Schema != nil {
+		// Structured output via a forced tool call: the tool input is the JSON.
+		payload["tools"] = []map[string]interface{}{{
+			"name":         req.Schema.Name,
+			"description":  req.Schema.Description,
+			"input_schema": req.Schema.Schema,
+		}}
+		payload["tool_choice"] = map[string]interface{}{"type": "tool", "name": req.Schema.Name}
+	}
+	if stream {
+		payload["stream"] = true
+	}
+	return payload
+}
+
+func (p *anthropicProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	var result struct {
+		Model   string `json:"model"`
+		Content []struct {
+			Type  string          `json:"type"`
+			ID    string          `json:"id"`
+			Name  string          `json:"name"`
+			Text  string          `json:"text"`
+			Input json.RawMessage `json:"input"`
+		} `json:"content"`
+		Usage anthropicUsage `json:"usage"`
+	}
+	if err := decodeJSON(ctx, p.cfg.HTTPClient, p.baseURL+"/messages", "Claude API", p.headers, p.payload(req, false), &result); err != nil {
+		return nil, err
+	}
+	var builder strings.Builder
+	var calls []ToolCall
+	for _, block := range result.Content {
+		switch {
+		case block.Type == "" || block.Type == "text":
+			builder.WriteString(block.Text)
+		case block.Type == "tool_use" && len(req.Tools) > 0:
+			calls = append(calls, ToolCall{ID: block.ID, Name: block.Name, Arguments: string(block.Input)})
+		case block.Type == "tool_use":
+			// Structured output: the forced tool's input is the answer.
+			builder.Write(block.Input)
+		}
+	}
+	if builder.Len() == 0 && len(calls) == 0 {
+		return nil, fmt.Errorf("no response from Claude API")
+	}
+	return &CompletionResponse{
+		Content:   builder.String(),
+		ToolCalls: calls,
+		Model:     result.Model,
+		Usage:     Usage{PromptTokens: result.Usage.InputTokens, CompletionTokens: result.Usage.OutputTokens},
+	}, nil
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Stream consumes the Messages API server-sent events: text (or tool input
+// JSON in structured mode) arrives in content_block_delta events, the stream
+// ends with message_stop, and failures mid-stream are reported as error
+// events. Input tokens are reported in message_start, cumulative output
+// tokens in message_delta.
+func (p *anthropicProvider) Stream(ctx context.Context, req CompletionRequest, onChunk func(string)) (Usage, error) {
+	resp, err := doJSON(ctx, p.cfg.HTTPClient, http.MethodPost, p.baseURL+"/messages", "Claude API stream", p.headers, p.payload(req, true))
+	if err != nil {
+		return Usage{}, err
+	}
+	defer resp.Body.Close()
+
+	var usage Usage
+	var streamErr error
+	stopped := false
+	if err := scanLines(resp.Body, func(line string) bool {
+		// "event:" lines duplicate the "type" field in the data payload.
+		if !strings.HasPrefix(line, "data:") {
+			return true
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+
+		var event struct {
+			Type  string `json:"type"`
+			Delta struct {
+				Type        string `json:"type"`
+				Text        string `json:"text"`
+				PartialJSON string `json:"partial_json"`
+			} `json:"delta"`
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+			Message struct {
+				Usage anthropicUsage `json:"usage"`
+			} `json:"message"`
+			Usage anthropicUsage `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return true
+		}
+
+		switch event.Type {
+		case "message_start":
+			usage.PromptTokens = event.Message.Usage.InputTokens
+		case "message_delta":
+			if event.Usage.OutputTokens > 0 {
+				usage.CompletionTokens = event.Usage.OutputTokens
+			}
+		case "content_block_delta":
+			chunk := event.Delta.Text
+			if event.Delta.Type == "input_json_delta" {
+				chunk = event.Delta.PartialJSON
+			}
+			if chunk != "" && onChunk != nil {
+				onChunk(chunk)
+			}
+		case "error":
+			streamErr = fmt.Errorf("Claude API stream error: %s - %s", event.Error.Type, event.Error.Message)
+			return false
+		case "message_stop":
+			stopped = true
+			return false
+		}
+		return true
+	}); err != nil {
+		return usage, err
+	}
+	if streamErr != nil {
+		return usage, streamErr
+	}
+	if !stopped {
+		if ctx.Err() != nil {
+			return usage, ctx.Err()
+		}
+		return usage, fmt.Errorf("Claude API stream ended before message_stop")
+	}
+	return usage, nil
+}
+
+func (p *anthropicProvider) SupportsTools() bool {
+	return true
+}
+
+func (p *anthropicProvider) Embed(ctx context.Context, inputs []string) ([][]float64, error) {
+	return nil, ErrEmbeddingsUnsupported
+}
+
+func (p *anthropicProvider) CountTokens(text string) int {
+	return estimateTokens(text)
+}
+
+func (p *anthropicProvider) Ping(ctx context.Context) error {
+	resp, err := doJSON(ctx, p.cfg.HTTPClient, http.MethodGet, p.baseURL+"/models", "Claude API ping", p.headers, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
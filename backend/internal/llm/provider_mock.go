@@ -0,0 +1,323 @@
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func init() {
+	Register("mock", newMockProvider)
+}
+
+// mockEmbeddingDim is the size of the deterministic mock embeddings.
+const mockEmbeddingDim = 16
+
+// mockFixture is one scripted reply. The first fixture whose Match regexp
+// finds the selected message text answers the call.
+type mockFixture struct {
+	Name     string `yaml:"name"`
+	Match    string `yaml:"match"` // regexp; empty matches every call
+	Role     string `yaml:"role"`  // message matched: "user" (last user message, default), "system" or "any"
+	Response string `yaml:"response"`
+	// Streaming: explicit chunks, or Response split into ChunkSize runes.
+	Chunks       []string `yaml:"chunks"`
+	ChunkSize    int      `yaml:"chunk_size"`
+	ChunkDelayMs int      `yaml:"chunk_delay_ms"`
+	DelayMs      int      `yaml:"delay_ms"` // before the reply (or first chunk)
+	// Error fails the call, as an APIError when Status is set (429 and 5xx
+	// are retried by the client). FailTimes limits the error to the first N
+	// matching calls; ErrorAfterChunks makes streams fail mid-way.
+	Error            string `yaml:"error"`
+	Status           int    `yaml:"status"`
+	FailTimes        int    `yaml:"fail_times"`
+	ErrorAfterChunks int    `yaml:"error_after_chunks"`
+	// ToolCalls are returned instead of Response when the request offers
+	// tools, forces none, and no tool result has been sent since the last
+	// user message.
+	ToolCalls []mockToolCall `yaml:"tool_calls"`
+
+	re    *regexp.Regexp
+	calls int
+}
+
+type mockToolCall struct {
+	Name      string                 `yaml:"name"`
+	Arguments map[string]interface{} `yaml:"arguments"`
+}
+
+type mockFixtureFile struct {
+	Fixtures []*mockFixture `yaml:"fixtures"`
+}
+
+// mockProvider answers from a fixtures file (LLM_MOCK_FIXTURES, YAML or JSON)
+// without any network access. Calls no fixture matches get a built-in reply:
+// a trivial SQL object for structured requests, plain text otherwise.
+type mockProvider struct {
+	model    string
+	mu       sync.Mutex
+	fixtures []*mockFixture
+}
+
+func newMockProvider(cfg ProviderConfig) (Provider, error) {
+	p := &mockProvider{model: cfg.Model}
+	if p.model == "" {
+		p.model = "mock"
+	}
+	if cfg.Fixtures == "" {
+		return p, nil
+	}
+	data, err := os.ReadFile(cfg.Fixtures)
+	if err != nil {
+		return nil, fmt.Errorf("mock fixtures: %w", err)
+	}
+	var file mockFixtureFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("mock fixtures %s: %w", cfg.Fixtures, err)
+	}
+	for i, f := range file.Fixtures {
+		if f.Match != "" {
+			if f.re, err = regexp.Compile(f.Match); err != nil {
+				return nil, fmt.Errorf("mock fixture %d (%s): %w", i+1, f.Name, err)
+			}
+		}
+		if f.Response == "" && len(f.Chunks) > 0 {
+			f.Response = strings.Join(f.Chunks, "")
+		}
+	}
+	p.fixtures = file.Fixtures
+	return p, nil
+}
+
+func (p *mockProvider) Name() string {
+	return "mock"
+}
+
+// mockReply is what a fixture decided for one call.
+type mockReply struct {
+	fixture *mockFixture
+	content string
+	err     error // nil when the call succeeds
+}
+
+func (p *mockProvider) reply(req CompletionRequest) mockReply {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, f := range p.fixtures {
+		if f.re != nil && !f.re.MatchString(mockMatchText(req.Messages, f.Role)) {
+			continue
+		}
+		f.calls++
+		r := mockReply{fixture: f, content: f.Response}
+		if f.Error != "" && (f.FailTimes <= 0 || f.calls <= f.FailTimes) {
+			if f.Status != 0 {
+				r.err = &APIError{Label: "Mock LLM", StatusCode: f.Status, Body: f.Error}
+			} else {
+				r.err = errors.New(f.Error)
+			}
+		}
+		return r
+	}
+	return mockReply{fixture: &mockFixture{}, content: mockDefaultReply(req)}
+}
+
+func mockMatchText(messages []Message, role string) string {
+	switch strings.ToLower(role) {
+	case "any":
+		parts := make([]string, len(messages))
+		for i, m := range messages {
+			parts[i] = m.Content
+		}
+		return strings.Join(parts, "\n")
+	case "system":
+		for _, m := range messages {
+			if m.Role == "system" {
+				return m.Content
+			}
+		}
+		return ""
+	default:
+		for i := len(messages) - 1; i >= 0; i-- {
+			if messages[i].Role == "user" {
+				return messages[i].Content
+			}
+		}
+		return ""
+	}
+}
+
+func mockDefaultReply(req CompletionRequest) string {
+	if req.Schema == nil && len(req.Tools) == 0 {
+		return "Mock response."
+	}
+	if req.Schema == ExplainSchema {
+		return `{"summary":"Mock provider default explanation; add a fixture to script this SQL.","steps":[],"warnings":[]}`
+	}
+	confidence := 0.5
+	data, _ := json.Marshal(StructuredSQL{
+		SQL:         "SELECT 1 AS MOCK_RESULT FROM DUAL",
+		Explanation: "Mock provider default reply; add a fixture to script this question.",
+		Confidence:  &confidence,
+	})
+	return string(data)
+}
+
+func (p *mockProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	r := p.reply(req)
+	if err := mockSleep(ctx, r.fixture.DelayMs); err != nil {
+		return nil, err
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if len(req.Tools) > 0 && req.ToolChoice == "" && len(r.fixture.ToolCalls) > 0 && !mockAnsweredTools(req.Messages) {
+		calls := make([]ToolCall, len(r.fixture.ToolCalls))
+		for i, call := range r.fixture.ToolCalls {
+			args, err := json.Marshal(call.Arguments)
+			if err != nil {
+				return nil, fmt.Errorf("mock fixture %s: tool %s arguments: %w", r.fixture.Name, call.Name, err)
+			}
+			calls[i] = ToolCall{ID: fmt.Sprintf("call_%d", i+1), Name: call.Name, Arguments: string(args)}
+		}
+		return &CompletionResponse{ToolCalls: calls, Model: p.model, Usage: p.usage(req, "")}, nil
+	}
+	if req.ToolChoice != "" {
+		// A forced tool gets the reply as its arguments, as real models do.
+		call := ToolCall{ID: "call_1", Name: req.ToolChoice, Arguments: r.content}
+		return &CompletionResponse{ToolCalls: []ToolCall{call}, Model: p.model, Usage: p.usage(req, r.content)}, nil
+	}
+	return &CompletionResponse{
+		Content: r.content,
+		Model:   p.model,
+		Usage:   p.usage(req, r.content),
+	}, nil
+}
+
+// mockAnsweredTools reports whether a tool result follows the last user message.
+func mockAnsweredTools(messages []Message) bool {
+	for i := len(messages) - 1; i >= 0; i-- {
+		switch messages[i].Role {
+		case "tool":
+			return true
+		case "user":
+			return false
+		}
+	}
+	return false
+}
+
+func (p *mockProvider) Stream(ctx context.Context, req CompletionRequest, onChunk func(string)) (Usage, error) {
+	r := p.reply(req)
+	if err := mockSleep(ctx, r.fixture.DelayMs); err != nil {
+		return Usage{}, err
+	}
+	if r.err != nil && r.fixture.ErrorAfterChunks <= 0 {
+		return Usage{}, r.err
+	}
+	chunks := r.fixture.Chunks
+	if len(chunks) == 0 {
+		chunks = splitRunes(r.content, r.fixture.ChunkSize)
+	}
+	var sent strings.Builder
+	for i, chunk := range chunks {
+		if r.err != nil && i == r.fixture.ErrorAfterChunks {
+			return p.usage(req, sent.String()), r.err
+		}
+		if i > 0 {
+			if err := mockSleep(ctx, r.fixture.ChunkDelayMs); err != nil {
+				return p.usage(req, sent.String()), err
+			}
+		}
+		sent.WriteString(chunk)
+		onChunk(chunk)
+	}
+	if r.err != nil {
+		return p.usage(req, sent.String()), r.err
+	}
+	return p.usage(req, sent.String()), nil
+}
+
+// Embed returns unit vectors derived from a hash of each input, so equal
+// texts embed identically.
+func (p *mockProvider) Embed(_ context.Context, inputs []string) ([][]float64, error) {
+	vectors := make([][]float64, len(inputs))
+	for i, input := range inputs {
+		sum := sha256.Sum256([]byte(input))
+		vec := make([]float64, mockEmbeddingDim)
+		var norm float64
+		for j := range vec {
+			v := float64(int16(binary.BigEndian.Uint16(sum[(j*2)%len(sum):]))) / math.MaxInt16
+			vec[j] = v
+			norm += v * v
+		}
+		if norm = math.Sqrt(norm); norm > 0 {
+			for j := range vec {
+				vec[j] /= norm
+			}
+		}
+		vectors[i] = vec
+	}
+	return vectors, nil
+}
+
+func (p *mockProvider) CountTokens(text string) int {
+	return estimateTokens(text)
+}
+
+func (p *mockProvider) SupportsTools() bool {
+	return true
+}
+
+func (p *mockProvider) Ping(context.Context) error {
+	return nil
+}
+
+func (p *mockProvider) usage(req CompletionRequest, completion string) Usage {
+	var u Usage
+	for _, m := range req.Messages {
+		u.PromptTokens += estimateTokens(m.Content)
+	}
+	u.CompletionTokens = estimateTokens(completion)
+	return u
+}
+
+func splitRunes(text string, size int) []string {
+	if size <= 0 {
+		size = 8
+	}
+	runes := []rune(text)
+	chunks := make([]string, 0, len(runes)/size+1)
+	for start := 0; start < len(runes); start += size {
+		end := start + size
+		if end > len(runes) {
+			end = len(runes)
+		}
+		chunks = append(chunks, string(runes[start:end]))
+	}
+	return chunks
+}
+
+func mockSleep(ctx context.Context, ms int) error {
+	if ms <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(time.Duration(ms) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+func init() {
+	Register("ollama", newOllamaProvider)
+}
+
+// ollamaProvider implements the native Ollama chat API (/api/chat), which
+// streams newline-delimited JSON rather than SSE.
+type ollamaProvider struct {
+	cfg     ProviderConfig
+	baseURL string
+}
+
+func newOllamaProvider(cfg ProviderConfig) (Provider, error) {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	if cfg.EmbeddingModel == "" {
+		cfg.EmbeddingModel = "nomic-embed-text"
+	}
+	return &ollamaProvider{cfg: cfg, baseURL: baseURL}, nil
+}
+
+func (p *ollamaProvider) Name() string {
+	return "ollama"
+}
+
+func (p *ollamaProvider) payload(req CompletionRequest, stream bool) map[string]interface{} {
+	payload := map[string]interface{}{
+		"model":    p.cfg.Model,
+		"messages": req.Messages,
+		"stream":   stream,
+		"options": map[string]interface{}{
+			"temperature": req.Temperature,
+			"num_predict": defaultMaxTokens(req.MaxTokens),
+		},
+	}
+	if req.Schema != nil {
+		// Ollama accepts a JSON schema as "format" (structured outputs).
+		payload["format"] = req.Schema.Schema
+	}
+	return payload
+}
+
+type ollamaChatChunk struct {
+	Model   string `json:"model"`
+	Message struct {
+		Content string `json:"content"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	Error           string `json:"error"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+func (c ollamaChatChunk) usage() Usage {
+	return Usage{PromptTokens: c.PromptEvalCount, CompletionTokens: c.EvalCount}
+}
+
+func (p *ollamaProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	var result ollamaChatChunk
+	if err := decodeJSON(ctx, p.cfg.HTTPClient, p.baseURL+"/api/chat", "Ollama API", nil, p.payload(req, false), &result); err != nil {
+		return nil, err
+	}
+	if result.Error != "" {
+		return nil, fmt.Errorf("Ollama API error: %s", result.Error)
+	}
+	return &CompletionResponse{
+		Content: result.Message.Content,
+		Model:   result.Model,
+		Usage:   result.usage(),
+	}, nil
+}
+
+// Stream reads NDJSON chunks; token counts arrive on the final done chunk.
+func (p *ollamaProvider) Stream(ctx context.Context, req CompletionRequest, onChunk func(string)) (Usage, error) {
+	resp, err := doJSON(ctx, p.cfg.HTTPClient, http.MethodPost, p.baseURL+"/api/chat", "Ollama API stream", nil, p.payload(req, true))
+	if err != nil {
+		return Usage{}, err
+	}
+	defer resp.Body.Close()
+
+	var usage Usage
+	var streamErr error
+	if err := scanLines(resp.Body, func(line string) bool {
+		var chunk ollamaChatChunk
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return true
+		}
+		if chunk.Error != "" {
+			streamErr = fmt.Errorf("Ollama API stream error: %s", chunk.Error)
+			return false
+		}
+		if chunk.Message.Content != "" && onChunk != nil {
+			onChunk(chunk.Message.Content)
+		}
+		if chunk.Done {
+			usage = chunk.usage()
+		}
+		return !chunk.Done
+	}); err != nil {
+		return usage, err
+	}
+	return usage, streamErr
+}
+
+func (p *ollamaProvider) Embed(ctx context.Context, inputs []string) ([][]float64, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	var result struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	payload := map[string]interface{}{
+		"model": p.cfg.EmbeddingModel,
+		"input": inputs,
+	}
+	if err := decodeJSON(ctx, p.cfg.HTTPClient, p.baseURL+"/api/embed", "Ollama API embeddings", nil, payload, &result); err != nil {
+		return nil, err
+	}
+	return result.Embeddings, nil
+}
+
+func (p *ollamaProvider) CountTokens(text string) int {
+	return estimateTokens(text)
+}
+
+func (p *ollamaProvider) Ping(ctx context.Context) error {
+	resp, err := doJSON(ctx, p.cfg.HTTPClient, http.MethodGet, p.baseURL+"/api/tags", "Ollama API ping", nil, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,387 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// JSON output modes of OpenAI-compatible APIs.
+const (
+	jsonModeNone   = ""            // prompt only
+	jsonModeObject = "json_object" // valid JSON, schema not enforced
+	jsonModeSchema = "json_schema" // strict structured outputs
+)
+
+func init() {
+	Register("openai", newOpenAICompatible("openai", jsonModeSchema))
+	// DeepSeek and most proxies/gateways speak the OpenAI chat completions API.
+	Register("deepseek", newOpenAICompatible("deepseek", jsonModeObject))
+	Register("custom", newOpenAICompatible("custom", jsonModeNone))
+	Register("azure", newAzureOpenAIProvider)
+}
+
+// openAIProvider implements the OpenAI-compatible chat completions API. Azure
+// OpenAI reuses it with different URLs and auth header.
+type openAIProvider struct {
+	name           string
+	label          string
+	cfg            ProviderConfig
+	chatURL        string
+	embeddingsURL  string
+	modelsURL      string
+	headers        map[string]string
+	sendModelField bool
+	jsonMode       string
+	// streamUsage asks streams for a final usage chunk (stream_options).
+	streamUsage bool
+}
+
+func newOpenAICompatible(name, jsonMode string) Factory {
+	return func(cfg ProviderConfig) (Provider, error) {
+		return newOpenAIProvider(name, jsonMode, cfg), nil
+	}
+}
+
+func newOpenAIProvider(name, jsonMode string, cfg ProviderConfig) *openAIProvider {
+	baseURL := strings.TrimRight(cfg.BaseURL, "/")
+	if baseURL == "" {
+		baseURL = "https://api.openai.com/v1"
+	}
+	if cfg.EmbeddingModel == "" {
+		cfg.EmbeddingModel = "text-embedding-3-small"
+	}
+	headers := map[string]string{}
+	if cfg.APIKey != "" {
+		headers["Authorization"] = fmt.Sprintf("Bearer %s", cfg.APIKey)
+	}
+	return &openAIProvider{
+		name:           name,
+		label:          "OpenAI API",
+		cfg:            cfg,
+		chatURL:        baseURL + "/chat/completions",
+		embeddingsURL:  baseURL + "/embeddings",
+		modelsURL:      baseURL + "/models",
+		headers:        headers,
+		sendModelField: true,
+		jsonMode:       jsonMode,
+		streamUsage:    true,
+	}
+}
+
+// newAzureOpenAIProvider expects BaseURL to be the resource endpoint
+// (https://<resource>.openai.azure.com) and Model to be the deployment name.
+func newAzureOpenAIProvider(cfg ProviderConfig) (Provider, error) {
+	endpoint := strings.TrimRight(cfg.BaseURL, "/")
+	if endpoint == "" {
+		return nil, fmt.Errorf("azure provider requires LLM_BASE_URL (resource endpoint)")
+	}
+	if cfg.Model == "" {
+		return nil, fmt.Errorf("azure provider requires LLM_MODEL (deployment name)")
+	}
+	apiVersion := cfg.APIVersion
+	if apiVersion == "" {
+		apiVersion = "2024-10-21"
+	}
+	embeddingDeployment := cfg.EmbeddingModel
+	if embeddingDeployment == "" {
+		embeddingDeployment = "text-embedding-3-small"
+	}
+	deploymentURL := func(deployment, op string) string {
+		return fmt.Sprintf("%s/openai/deployments/%s/%s?api-version=%s", endpoint, deployment, op, apiVersion)
+	}
+	return &openAIProvider{
+		name:          "azure",
+		label:         "Azure OpenAI API",
+		cfg:           cfg,
+		chatURL:       deploymentURL(cfg.Model, "chat/completions"),
+		embeddingsURL: deploymentURL(embeddingDeployment, "embeddings"),
+		modelsURL:     fmt.Sprintf("%s/openai/models?api-version=%s", endpoint, apiVersion),
+		headers:       map[string]string{"api-key": cfg.APIKey},
+		jsonMode:      jsonModeSchema,
+		streamUsage:   azureSupportsStreamUsage(apiVersion),
+	}, nil
+}
+
+// azureSupportsStreamUsage reports whether an Azure api-version accepts
+// stream_options; older versions reject the field with a 400. Versions are
+// dates, so they compare as strings.
+func azureSupportsStreamUsage(apiVersion string) bool {
+	return apiVersion >= "2024-09-01"
+}
+
+func (p *openAIProvider) Name() string {
+	return p.name
+}
+
+func (p *openAIProvider) payload(req CompletionRequest, stream bool) map[string]interface{} {
+	payload := map[string]interface{}{
+		"messages": openAIMessages(req.Messages),
+	}
+	if isReasoningModel(p.cfg.Model) {
+		// Reasoning models reject max_tokens and any non-default temperature.
+		payload["max_completion_tokens"] = defaultMaxTokens(req.MaxTokens)
+	} else {
+		payload["temperature"] = req.Temperature
+		payload["max_tokens"] = defaultMaxTokens(req.MaxTokens)
+	}
+	if p.sendModelField {
+		payload["model"] = p.cfg.Model
+	}
+	if req.Schema != nil {
+		switch p.jsonModeFor(p.cfg.Model) {
+		case jsonModeSchema:
+			payload["response_format"] = map[string]interface{}{
+				"type": "json_schema",
+				"json_schema": map[string]interface{}{
+					"name":   req.Schema.Name,
+					"schema": req.Schema.Schema,
+					"strict": true,
+				},
+			}
+		case jsonModeObject:
+			payload["response_format"] = map[string]interface{}{"type": "json_object"}
+		}
+	}
+	if len(req.Tools) > 0 {
+		tools := make([]map[string]interface{}, 0, len(req.Tools))
+		for _, tool := range req.Tools {
+			tools = append(tools, map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        tool.Name,
+					"description": tool.Description,
+					"parameters":  tool.Parameters,
+				},
+			})
+		}
+		payload["tools"] = tools
+		if req.ToolChoice != "" {
+			payload["tool_choice"] = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]interface{}{"name": req.ToolChoice},
+			}
+		}
+	}
+	if stream {
+		payload["stream"] = true
+		if p.streamUsage {
+			// Ask for a final usage chunk; OpenAI-compatible servers that
+			// don't know the option ignore it.
+			payload["stream_options"] = map[string]interface{}{"include_usage": true}
+		}
+	}
+	return payload
+}
+
+// jsonModeFor downgrades json_schema to json_object for OpenAI models that
+// predate structured outputs (e.g. gpt-3.5-turbo, gpt-4-turbo), which reject
+// it with a 400. Azure deployment names say nothing about the model, so they
+// keep json_schema and rely on the client remembering a rejection.
+func (p *openAIProvider) jsonModeFor(model string) string {
+	if p.jsonMode != jsonModeSchema || p.name != "openai" || supportsJSONSchema(model) {
+		return p.jsonMode
+	}
+	return jsonModeObject
+}
+
+// supportsJSONSchema reports whether an OpenAI model accepts strict
+// json_schema response formats.
+func supportsJSONSchema(model string) bool {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" || model == "gpt-4o-2024-05-13" {
+		return false
+	}
+	for _, prefix := range []string{"gpt-4o", "chatgpt-4o", "gpt-4.1", "gpt-4.5", "gpt-5", "o1", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isReasoningModel reports whether an OpenAI model (or an Azure deployment
+// named after one) is a reasoning model, which takes max_completion_tokens
+// instead of max_tokens and no temperature.
+func isReasoningModel(model string) bool {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if strings.HasPrefix(model, "gpt-5-chat") {
+		return false
+	}
+	for _, prefix := range []string{"gpt-5", "o1", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// openAIToolCall is the wire form of a function call.
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// openAIMessages adds the tool-calling fields to messages that carry them.
+func openAIMessages(messages []Message) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(messages))
+	for _, msg := range messages {
+		m := map[string]interface{}{"role": msg.Role, "content": msg.Content}
+		if msg.Role == "tool" {
+			m["tool_call_id"] = msg.ToolCallID
+		}
+		if len(msg.ToolCalls) > 0 {
+			calls := make([]openAIToolCall, len(msg.ToolCalls))
+			for i, call := range msg.ToolCalls {
+				calls[i].ID = call.ID
+				calls[i].Type = "function"
+				calls[i].Function.Name = call.Name
+				calls[i].Function.Arguments = toolArguments(call.Arguments)
+			}
+			m["tool_calls"] = calls
+			if msg.Content == "" {
+				m["content"] = nil
+			}
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func (p *openAIProvider) SupportsTools() bool {
+	return true
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+func (u *openAIUsage) toUsage() Usage {
+	if u == nil {
+		return Usage{}
+	}
+	return Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}
+}
+
+func (p *openAIProvider) Complete(ctx context.Context, req CompletionRequest) (*CompletionResponse, error) {
+	var result struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content   string           `json:"content"`
+				ToolCalls []openAIToolCall `json:"tool_calls"`
+			} `json:"message"`
+		} `json:"choices"`
+		Usage *openAIUsage `json:"usage"`
+	}
+	if err := decodeJSON(ctx, p.cfg.HTTPClient, p.chatURL, p.label, p.headers, p.payload(req, false), &result); err != nil {
+		return nil, err
+	}
+	if len(result.Choices) == 0 {
+		return nil, fmt.Errorf("no response from %s", p.label)
+	}
+	message := result.Choices[0].Message
+	resp := &CompletionResponse{
+		Content: message.Content,
+		Model:   result.Model,
+		Usage:   result.Usage.toUsage(),
+	}
+	for _, call := range message.ToolCalls {
+		resp.ToolCalls = append(resp.ToolCalls, ToolCall{ID: call.ID, Name: call.Function.Name, Arguments: call.Function.Arguments})
+	}
+	return resp, nil
+}
+
+func (p *openAIProvider) Stream(ctx context.Context, req CompletionRequest, onChunk func(string)) (Usage, error) {
+	resp, err := doJSON(ctx, p.cfg.HTTPClient, http.MethodPost, p.chatURL, p.label+" stream", p.headers, p.payload(req, true))
+	if err != nil {
+		return Usage{}, err
+	}
+	defer resp.Body.Close()
+
+	var usage Usage
+	err = scanLines(resp.Body, func(line string) bool {
+		if !strings.HasPrefix(line, "data:") {
+			return true
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			return false
+		}
+
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+				Message struct {
+					Content string `json:"content"`
+				} `json:"message"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return true
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.toUsage()
+		}
+		if len(chunk.Choices) == 0 {
+			return true
+		}
+		content := chunk.Choices[0].Delta.Content
+		if content == "" {
+			content = chunk.Choices[0].Message.Content
+		}
+		if content != "" && onChunk != nil {
+			onChunk(content)
+		}
+		return true
+	})
+	return usage, err
+}
+
+func (p *openAIProvider) Embed(ctx context.Context, inputs []string) ([][]float64, error) {
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	payload := map[string]interface{}{"input": inputs}
+	if p.sendModelField {
+		payload["model"] = p.cfg.EmbeddingModel
+	}
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := decodeJSON(ctx, p.cfg.HTTPClient, p.embeddingsURL, p.label+" embeddings", p.headers, payload, &result); err != nil {
+		return nil, err
+	}
+	vectors := make([][]float64, len(inputs))
+	for _, item := range result.Data {
+		if item.Index >= 0 && item.Index < len(vectors) {
+			vectors[item.Index] = item.Embedding
+		}
+	}
+	return vectors, nil
+}
+
+func (p *openAIProvider) CountTokens(text string) int {
+	return estimateTokens(text)
+}
+
+func (p *openAIProvider) Ping(ctx context.Context) error {
+	resp, err := doJSON(ctx, p.cfg.HTTPClient, http.MethodGet, p.modelsURL, p.label+" ping", p.headers, nil)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
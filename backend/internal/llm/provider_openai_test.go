@@ -0,0 +1,52 @@
+package llm
+
+import "testing"
+
+func TestAzureStreamOptions(t *testing.T) {
+	tests := []struct {
+		apiVersion string
+		want       bool
+	}{
+		{"", true},
+		{"2024-06-01", false},
+		{"2024-08-01-preview", false},
+		{"2024-09-01-preview", true},
+		{"2024-10-21", true},
+	}
+	for _, tt := range tests {
+		p, err := newAzureOpenAIProvider(ProviderConfig{BaseURL: "https://example.openai.azure.com", Model: "gpt-4o", APIVersion: tt.apiVersion})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, got := p.(*openAIProvider).payload(CompletionRequest{}, true)["stream_options"]
+		if got != tt.want {
+			t.Errorf("api-version %q: stream_options sent = %v, want %v", tt.apiVersion, got, tt.want)
+		}
+	}
+}
+
+func TestReasoningModelPayload(t *testing.T) {
+	tests := []struct {
+		model     string
+		reasoning bool
+	}{
+		{"gpt-4o", false},
+		{"gpt-3.5-turbo", false},
+		{"gpt-5-chat-latest", false},
+		{"o1-mini", true},
+		{"o3", true},
+		{"o4-mini", true},
+		{"gpt-5", true},
+		{"GPT-5-mini", true},
+	}
+	for _, tt := range tests {
+		payload := newOpenAIProvider("openai", jsonModeSchema, ProviderConfig{Model: tt.model}).payload(CompletionRequest{Temperature: 0.1, MaxTokens: 500}, false)
+		_, temperature := payload["temperature"]
+		_, maxTokens := payload["max_tokens"]
+		_, maxCompletionTokens := payload["max_completion_tokens"]
+		if temperature == tt.reasoning || maxTokens == tt.reasoning || maxCompletionTokens != tt.reasoning {
+			t.Errorf("%s: temperature %v, max_tokens %v, max_completion_tokens %v; reasoning %v",
+				tt.model, temperature, maxTokens, maxCompletionTokens, tt.reasoning)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCircuitOpen is returned when a backend's circuit breaker rejects the call.
+var ErrCircuitOpen = errors.New("llm circuit breaker open")
+
+// RetryPolicy controls per-backend retries with exponential backoff.
+type RetryPolicy struct {
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// backoff returns the delay before retry n (0-based), with up to 25% jitter.
+func (p RetryPolicy) backoff(n int) time.Duration {
+	delay := p.BaseDelay << uint(n)
+	if delay <= 0 || delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay > 0 {
+		delay += time.Duration(rand.Int63n(int64(delay)/4 + 1))
+	}
+	return delay
+}
+
+// Attempt describes one provider call made on behalf of an LLMClient method.
+type Attempt struct {
+	Operation  string // "complete" or "stream"
+	Provider   string
+	Model      string
+	Number     int  // 1-based, per backend
+	Fallback   bool // true when served by a non-primary backend
+	Duration   time.Duration
+	StatusCode int
+	Err        error
+}
+
+// AttemptObserver receives every attempt, successful or not.
+type AttemptObserver func(Attempt)
+
+// backend is one provider/model in the fallback chain.
+type backend struct {
+	provider Provider
+	model    string
+	breaker  *circuitBreaker
+	// schemaRejected is set once the backend has rejected native structured
+	// output, so later calls send the prompt alone.
+	schemaRejected atomic.Bool
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// circuitBreaker opens after threshold consecutive failures and lets a single
+// probe through once cooldown has elapsed.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	state     breakerState
+	openedAt  time.Time
+	probing   bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown}
+}
+
+func (b *circuitBreaker) allow() bool {
+	if b == nil || b.threshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = breakerHalfOpen
+		b.probing = true
+		return true
+	case breakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *circuitBreaker) record(success bool) {
+	if b == nil || b.threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if success {
+		b.failures = 0
+		b.state = breakerClosed
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || b.failures >= b.threshold {
+		b.state = breakerOpen
+		b.openedAt = time.Now()
+	}
+}
+
+// release ends a half-open probe without changing the breaker's verdict, for
+// failures that say nothing about backend health (e.g. 400/401).
+func (b *circuitBreaker) release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+func (b *circuitBreaker) State() string {
+	if b == nil {
+		return breakerClosed.String()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state.String()
+}
+
+// retryable reports whether err is worth retrying: throttling, server errors
+// and transport failures. Caller cancellation is not.
+func retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests ||
+			apiErr.StatusCode == http.StatusRequestTimeout ||
+			apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, context.DeadlineExceeded)
+}
+
+// countsAgainstBreaker excludes client-side mistakes (bad request, auth) so
+// that a malformed prompt does not open the circuit for everyone.
+func countsAgainstBreaker(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrStreamingUnsupported) || errors.Is(err, ErrToolsUnsupported) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return retryable(err)
+	}
+	return true
+}
+
+func statusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// sleepCtx waits for d or until ctx is done.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryDelay prefers the server's Retry-After over computed backoff, and
+// gives up when the wait would outlive the caller's deadline.
+func (c *LLMClient) retryDelay(ctx context.Context, err error, n int) (time.Duration, bool) {
+	delay := c.retry.backoff(n)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+		delay = apiErr.RetryAfter
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return 0, false
+	}
+	return delay, true
+}
+
+// call runs fn against each backend in order, retrying retryable errors and
+// skipping backends whose breaker is open. emitted reports whether fn has
+// already delivered output, in which case retrying would duplicate it.
+func (c *LLMClient) call(ctx context.Context, op string, fn func(*backend) error, emitted func() bool) error {
+	var lastErr error
+	for i, b := range c.backends {
+		for n := 0; n <= c.retry.MaxRetries; n++ {
+			if !b.breaker.allow() {
+				lastErr = fmt.Errorf("%w: %s/%s", ErrCircuitOpen, b.provider.Name(), b.model)
+				c.observe(Attempt{Operation: op, Provider: b.provider.Name(), Model: b.model, Number: n + 1, Fallback: i > 0, Err: lastErr})
+				break
+			}
+			start := time.Now()
+			err := fn(b)
+			if err == nil || countsAgainstBreaker(err) {
+				b.breaker.record(err == nil)
+			} else {
+				b.breaker.release()
+			}
+			c.observe(Attempt{
+				Operation:  op,
+				Provider:   b.provider.Name(),
+				Model:      b.model,
+				Number:     n + 1,
+				Fallback:   i > 0,
+				Duration:   time.Since(start),
+				StatusCode: statusCode(err),
+				Err:        err,
+			})
+			if err == nil {
+				return nil
+			}
+			lastErr = err
+			if ctx.Err() != nil || (emitted != nil && emitted()) {
+				return err
+			}
+			if !retryable(err) {
+				break
+			}
+			if n == c.retry.MaxRetries {
+				break
+			}
+			delay, ok := c.retryDelay(ctx, err, n)
+			if !ok {
+				break
+			}
+			if err := sleepCtx(ctx, delay); err != nil {
+				return lastErr
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return lastErr
+}
+
+func (c *LLMClient) observe(attempt Attempt) {
+	if c.observer != nil {
+		c.observer(attempt)
+	}
+}
+
+// BreakerStates reports the circuit state per backend ("provider/model").
+func (c *LLMClient) BreakerStates() map[string]string {
+	states := make(map[string]string, len(c.backends))
+	for _, b := range c.backends {
+		states[b.provider.Name()+"/"+b.model] = b.breaker.State()
+	}
+	return states
+}
@@ -0,0 +1,329 @@
+package llm
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// JSONSchema asks the provider to constrain its output to a JSON schema,
+// using the backend's native JSON/tool mode where one exists.
+type JSONSchema struct {
+	Name        string
+	Description string
+	Schema      map[string]interface{}
+}
+
+// StructuredSQL is the JSON contract for SQL generation.
+type StructuredSQL struct {
+	SQL                string   `json:"sql"`
+	Explanation        string   `json:"explanation"`
+	TablesUsed         []string `json:"tables_used"`
+	Assumptions        []string `json:"assumptions"`
+	Confidence         *float64 `json:"confidence"`
+	ClarifyingQuestion string   `json:"clarifying_question"`
+	// ClarificationType is what is missing: time_range, metric, table, filter or other.
+	ClarificationType    string   `json:"clarification_type"`
+	ClarificationOptions []string `json:"clarification_options"`
+	// Structured is false when the reply was not JSON and was parsed as plain SQL.
+	Structured bool `json:"-"`
+}
+
+// SQLGenerationSchema describes StructuredSQL. All properties are required and
+// no others are allowed, as strict schema modes demand.
+var SQLGenerationSchema = &JSONSchema{
+	Name:        "sql_generation",
+	Description: "Return the generated Oracle SQL with its explanation",
+	Schema: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"sql":                 map[string]interface{}{"type": "string"},
+			"explanation":         map[string]interface{}{"type": "string"},
+			"tables_used":         map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"assumptions":         map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+			"confidence":          map[string]interface{}{"type": "number"},
+			"clarifying_question": map[string]interface{}{"type": "string"},
+			"clarification_type": map[string]interface{}{
+				"type": "string",
+				"enum": []string{"", "time_range", "metric", "table", "filter", "other"},
+			},
+			"clarification_options": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		},
+		"required": []string{"sql", "explanation", "tables_used", "assumptions", "confidence",
+			"clarifying_question", "clarification_type", "clarification_options"},
+		"additionalProperties": false,
+	},
+}
+
+var sqlFencePattern = regexp.MustCompile("(?is)```(?:sql)?\\s*\\n?(.*?)```")
+
+// ParseStructuredSQL parses a generation reply. It accepts the JSON contract
+// (bare, fenced or surrounded by prose) and falls back to treating the reply
+// as SQL: the first ```sql block if present, otherwise the whole text.
+func ParseStructuredSQL(reply string) *StructuredSQL {
+	reply = strings.TrimSpace(reply)
+	if obj := extractJSONObject(reply); obj != "" {
+		var out StructuredSQL
+		if err := json.Unmarshal([]byte(obj), &out); err == nil && (out.SQL != "" || out.Explanation != "" || out.ClarifyingQuestion != "") {
+			out.Structured = true
+			out.normalize()
+			return &out
+		}
+	}
+
+	out := &StructuredSQL{}
+	if match := sqlFencePattern.FindStringSubmatch(reply); match != nil {
+		out.SQL = match[1]
+		out.Explanation = strings.TrimSpace(sqlFencePattern.ReplaceAllString(reply, ""))
+	} else {
+		out.SQL = reply
+	}
+	out.normalize()
+	return out
+}
+
+func (s *StructuredSQL) normalize() {
+	s.SQL = strings.TrimSpace(s.SQL)
+	if m := sqlFencePattern.FindStringSubmatch(s.SQL); m != nil {
+		s.SQL = strings.TrimSpace(m[1])
+	}
+	s.SQL = strings.TrimSpace(strings.TrimSuffix(s.SQL, ";"))
+	s.Explanation = strings.TrimSpace(s.Explanation)
+	s.ClarifyingQuestion = strings.TrimSpace(s.ClarifyingQuestion)
+	s.ClarificationType = strings.ToLower(strings.TrimSpace(s.ClarificationType))
+	s.ClarificationOptions = compactStrings(s.ClarificationOptions, false)
+	s.TablesUsed = compactStrings(s.TablesUsed, true)
+	s.Assumptions = compactStrings(s.Assumptions, false)
+	if s.Confidence != nil {
+		c := *s.Confidence
+		if c > 1 && c <= 100 {
+			c /= 100 // some models answer in percent
+		}
+		if c < 0 {
+			c = 0
+		}
+		if c > 1 {
+			c = 1
+		}
+		s.Confidence = &c
+	}
+}
+
+func compactStrings(values []string, upper bool) []string {
+	var result []string
+	for _, v := range values {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if upper {
+			v = strings.ToUpper(v)
+		}
+		result = append(result, v)
+	}
+	return result
+}
+
+// extractJSONObject returns the first balanced {...} object in text, or "".
+func extractJSONObject(text string) string {
+	start := strings.Index(text, "{")
+	for start >= 0 {
+		depth := 0
+		inString := false
+		escaped := false
+		for i := start; i < len(text); i++ {
+			ch := text[i]
+			if inString {
+				switch {
+				case escaped:
+					escaped = false
+				case ch == '\\':
+					escaped = true
+				case ch == '"':
+					inString = false
+				}
+				continue
+			}
+			switch ch {
+			case '"':
+				inString = true
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					candidate := text[start : i+1]
+					if json.Valid([]byte(candidate)) {
+						return candidate
+					}
+					i = len(text)
+				}
+			}
+		}
+		next := strings.Index(text[start+1:], "{")
+		if next < 0 {
+			return ""
+		}
+		start += next + 1
+	}
+	return ""
+}
+
+// sqlFieldStreamer receives raw JSON chunks of a StructuredSQL reply and
+// emits only the decoded value of the "sql" field as it arrives, so streaming
+// clients see SQL rather than JSON. If the reply turns out not to be JSON the
+// raw text is passed through.
+type sqlFieldStreamer struct {
+	emit  func(string)
+	buf   strings.Builder
+	state int // 0 detecting, 1 seeking sql value, 2 in sql value, 3 done, 4 passthrough, 5 in fenced plain text
+	pos   int // scan offset into buf
+}
+
+func newSQLFieldStreamer(emit func(string)) *sqlFieldStreamer {
+	return &sqlFieldStreamer{emit: emit}
+}
+
+var sqlKeyPattern = regexp.MustCompile(`"sql"\s*:\s*"`)
+
+func (s *sqlFieldStreamer) Write(chunk string) {
+	if s.emit == nil {
+		return
+	}
+	s.buf.WriteString(chunk)
+	text := s.buf.String()
+
+	if s.state == 0 {
+		trimmed := strings.TrimLeft(text, " \t\r\n")
+		if trimmed == "" {
+			return
+		}
+		switch {
+		case strings.HasPrefix(trimmed, "{"):
+			s.state = 1
+		case strings.HasPrefix("```", trimmed):
+			return // may still open a fence
+		case strings.HasPrefix(trimmed, "```"):
+			body, json, ok := fenceBody(trimmed)
+			if !ok {
+				return
+			}
+			if json {
+				s.state = 1
+			} else {
+				s.state = 5
+				s.pos = len(text) - len(trimmed) + body
+			}
+		default:
+			s.state = 4
+			s.emit(text)
+			s.pos = len(text)
+			return
+		}
+	}
+
+	switch s.state {
+	case 1:
+		loc := sqlKeyPattern.FindStringIndex(text)
+		if loc == nil {
+			return
+		}
+		s.state = 2
+		s.pos = loc[1]
+		fallthrough
+	case 2:
+		s.pos = s.decode(text, s.pos)
+	case 4:
+		s.emit(text[s.pos:])
+		s.pos = len(text)
+	case 5:
+		body := text[s.pos:]
+		if end := strings.Index(body, "```"); end >= 0 {
+			if end > 0 {
+				s.emit(body[:end])
+			}
+			s.pos += end
+			s.state = 3
+			return
+		}
+		// Hold back backticks that may start the closing fence.
+		keep := len(body) - len(strings.TrimRight(body, "`"))
+		if out := body[:len(body)-keep]; out != "" {
+			s.emit(out)
+			s.pos += len(out)
+		}
+	}
+}
+
+// fenceBody inspects a reply starting with a code fence. It returns the
+// offset of the fenced body and whether the fence holds JSON (```json, or a
+// body starting with "{"); ok is false until enough has arrived to tell.
+func fenceBody(text string) (body int, json bool, ok bool) {
+	nl := strings.IndexByte(text, '\n')
+	if nl < 0 {
+		return 0, false, false
+	}
+	info := strings.TrimSpace(text[3:nl])
+	if info != "" {
+		return nl + 1, strings.EqualFold(info, "json"), true
+	}
+	rest := strings.TrimLeft(text[nl+1:], " \t\r\n")
+	if rest == "" {
+		return 0, false, false
+	}
+	return nl + 1, strings.HasPrefix(rest, "{"), true
+}
+
+// decode emits the JSON string content of text[from:] up to the closing quote
+// and returns the offset it stopped at (before any incomplete escape).
+func (s *sqlFieldStreamer) decode(text string, from int) int {
+	var out strings.Builder
+	i := from
+loop:
+	for i < len(text) {
+		ch := text[i]
+		if ch == '"' {
+			s.state = 3
+			i++
+			break
+		}
+		if ch != '\\' {
+			if !utf8.FullRuneInString(text[i:]) {
+				break
+			}
+			_, size := utf8.DecodeRuneInString(text[i:])
+			out.WriteString(text[i : i+size])
+			i += size
+			continue
+		}
+		if i+1 >= len(text) {
+			break
+		}
+		switch text[i+1] {
+		case 'n':
+			out.WriteByte('\n')
+		case 't':
+			out.WriteByte('\t')
+		case 'r':
+			out.WriteByte('\r')
+		case 'u':
+			if i+6 > len(text) {
+				break loop
+			}
+			if code, err := strconv.ParseUint(text[i+2:i+6], 16, 32); err == nil {
+				out.WriteRune(rune(code))
+			}
+			i += 6
+			continue
+		default:
+			out.WriteByte(text[i+1])
+		}
+		i += 2
+	}
+	if out.Len() > 0 {
+		s.emit(out.String())
+	}
+	return i
+}
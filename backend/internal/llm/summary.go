@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yourusername/db_asst/internal/models"
+	"github.com/yourusername/db_asst/internal/prompts"
+)
+
+const maxSummaryCellRunes = 80
+
+// SummarizeResult streams a natural-language answer to query based on the
+// first maxRows rows of result, and returns the full text with the prompt
+// version used. onChunk may be nil.
+func (c *LLMClient) SummarizeResult(ctx context.Context, query, sql string, result *models.SQLExecuteResponse, maxRows int, onChunk func(string)) (string, string, error) {
+	table, shown := FormatResultTable(result, maxRows)
+	rendered, err := c.renderPrompt(prompts.ResultSummary, prompts.ResultSummaryData{
+		Query:     query,
+		SQL:       strings.TrimSpace(sql),
+		Result:    table,
+		RowCount:  len(result.Rows),
+		Shown:     shown,
+		Truncated: shown < len(result.Rows) || result.HasMore,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	var builder strings.Builder
+	_, err = c.stream(ctx, rendered, nil, func(chunk string) {
+		builder.WriteString(chunk)
+		if onChunk != nil {
+			onChunk(chunk)
+		}
+	})
+	return strings.TrimSpace(builder.String()), rendered.Label(), err
+}
+
+// SummarizeSession folds turns into the previous rolling summary of a
+// conversation and returns the new summary with the prompt version used.
+func (c *LLMClient) SummarizeSession(ctx context.Context, previous, turns string, maxChars int) (string, string, error) {
+	rendered, err := c.renderPrompt(prompts.SessionSummary, prompts.SessionSummaryData{
+		Previous: strings.TrimSpace(previous),
+		Turns:    turns,
+		MaxChars: maxChars,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	content, err := c.complete(ctx, rendered, nil)
+	if err != nil {
+		return "", rendered.Label(), err
+	}
+	return strings.TrimSpace(content), rendered.Label(), nil
+}
+
+// FormatResultTable renders up to maxRows rows as a pipe-separated table
+// for prompts, truncating long cells, and returns the number of rows shown.
+func FormatResultTable(result *models.SQLExecuteResponse, maxRows int) (string, int) {
+	if result == nil || len(result.Columns) == 0 {
+		return "(no columns)", 0
+	}
+	if len(result.Rows) == 0 {
+		return strings.Join(result.Columns, " | ") + "\n(no rows)", 0
+	}
+	shown := len(result.Rows)
+	if maxRows > 0 && shown > maxRows {
+		shown = maxRows
+	}
+	var builder strings.Builder
+	builder.WriteString(strings.Join(result.Columns, " | "))
+	for _, row := range result.Rows[:shown] {
+		builder.WriteString("\n")
+		for i, v := range row {
+			if i > 0 {
+				builder.WriteString(" | ")
+			}
+			cell := "NULL"
+			if v != nil {
+				cell = strings.ReplaceAll(fmt.Sprint(v), "\n", " ")
+			}
+			if runes := []rune(cell); len(runes) > maxSummaryCellRunes {
+				cell = string(runes[:maxSummaryCellRunes]) + "..."
+			}
+			builder.WriteString(cell)
+		}
+	}
+	return builder.String(), shown
+}
@@ -0,0 +1,42 @@
+package llm
+
+import "errors"
+
+// ErrToolsUnsupported is returned for tool calls on a backend without
+// function calling.
+var ErrToolsUnsupported = errors.New("tool calling not supported by provider")
+
+// Tool is a function the model may call. Parameters is the JSON schema of
+// its arguments object.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+}
+
+// ToolCall is one function call requested by the model. Arguments is the
+// raw JSON object the model produced; it may be malformed.
+type ToolCall struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// ToolCaller is implemented by providers whose Complete honours
+// CompletionRequest.Tools and reports the model's calls in ToolCalls.
+type ToolCaller interface {
+	SupportsTools() bool
+}
+
+func supportsTools(p Provider) bool {
+	tc, ok := p.(ToolCaller)
+	return ok && tc.SupportsTools()
+}
+
+// toolArguments returns args as a JSON object, "{}" when empty.
+func toolArguments(args string) string {
+	if args == "" {
+		return "{}"
+	}
+	return args
+}
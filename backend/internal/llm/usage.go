@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBudgetExceeded is returned (wrapped) when a Meter rejects a call.
+var ErrBudgetExceeded = errors.New("llm budget exceeded")
+
+// CallScope attributes LLM usage to the user, session and request that caused it.
+type CallScope struct {
+	UserID    string
+	Username  string
+	SessionID string
+	RequestID string
+}
+
+type callScopeKey struct{}
+
+// WithCallScope attaches scope to ctx for metering.
+func WithCallScope(ctx context.Context, scope CallScope) context.Context {
+	return context.WithValue(ctx, callScopeKey{}, scope)
+}
+
+// CallScopeFromContext returns the scope attached by WithCallScope.
+func CallScopeFromContext(ctx context.Context) (CallScope, bool) {
+	scope, ok := ctx.Value(callScopeKey{}).(CallScope)
+	return scope, ok
+}
+
+// UsageRecord is one successful LLM call with its token usage.
+type UsageRecord struct {
+	CallScope
+	Operation string // prompt template name, e.g. "sql_generation"
+	Provider  string
+	Model     string
+	Usage     Usage
+}
+
+// Meter enforces budgets before a call and accounts usage after it.
+type Meter interface {
+	Allow(ctx context.Context, scope CallScope) error
+	Record(ctx context.Context, rec UsageRecord)
+}
+
+// SetMeter installs the usage meter. Call it before serving requests.
+func (c *LLMClient) SetMeter(meter Meter) {
+	c.meter = meter
+}
+
+func (c *LLMClient) allow(ctx context.Context) error {
+	if c.meter == nil {
+		return nil
+	}
+	scope, _ := CallScopeFromContext(ctx)
+	return c.meter.Allow(ctx, scope)
+}
+
+// account fills in estimated token counts when the provider reported none and
+// hands the record to the meter.
+func (c *LLMClient) account(ctx context.Context, operation string, b *backend, req CompletionRequest, completion string, usage Usage) {
+	if c.meter == nil {
+		return
+	}
+	if usage.PromptTokens == 0 {
+		for _, msg := range req.Messages {
+			usage.PromptTokens += b.provider.CountTokens(msg.Content)
+		}
+		usage.Estimated = true
+	}
+	if usage.CompletionTokens == 0 && completion != "" {
+		usage.CompletionTokens = b.provider.CountTokens(completion)
+		usage.Estimated = true
+	}
+	scope, _ := CallScopeFromContext(ctx)
+	c.meter.Record(ctx, UsageRecord{
+		CallScope: scope,
+		Operation: operation,
+		Provider:  b.provider.Name(),
+		Model:     b.model,
+		Usage:     usage,
+	})
+}
@@ -12,6 +12,7 @@ import (
 
 // Entry 表示一次 NL 到 SQL 的往返记录
 type Entry struct {
+	ID        string    `json:"id,omitempty"`
 	Query     string    `json:"query"`
 	SQL       string    `json:"sql"`
 	Reasoning string    `json:"reasoning"`
@@ -19,6 +20,16 @@ type Entry struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
+// Summary 是会话的滚动摘要，概括了 LastEntryID（含）之前的记忆
+type Summary struct {
+	Summary       string    `json:"summary"`
+	LastEntryID   string    `json:"last_entry_id"`
+	CoveredUntil  time.Time `json:"covered_until"`
+	Turns         int       `json:"turns"`
+	PromptVersion string    `json:"prompt_version,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
 // Store 负责管理记忆数据（存储于 MySQL）
 type Store struct {
 	db     *sql.DB
@@ -34,6 +45,9 @@ func NewStore(db *sql.DB, driver string) (*Store, error) {
 	if err := store.ensureTable(); err != nil {
 		return nil, err
 	}
+	if err := store.ensureSummaryTable(); err != nil {
+		return nil, err
+	}
 	return store, nil
 }
 
@@ -86,10 +100,50 @@ CREATE TABLE CONVERSATION_MEMORY (
 	}
 }
 
-// Append 向指定会话追加记忆
-func (s *Store) Append(userID, sessionID string, entry Entry) error {
+func (s *Store) ensureSummaryTable() error {
+	if s.driver == "oracle" {
+		const check = `SELECT COUNT(*) FROM USER_TABLES WHERE TABLE_NAME = 'CONVERSATION_SUMMARY'`
+		var count int
+		if err := s.db.QueryRow(check).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		_, err := s.db.Exec(`
+CREATE TABLE CONVERSATION_SUMMARY (
+    USER_ID VARCHAR2(64) NOT NULL,
+    SESSION_ID VARCHAR2(128) NOT NULL,
+    SUMMARY CLOB NOT NULL,
+    LAST_ENTRY_ID VARCHAR2(36) NOT NULL,
+    COVERED_UNTIL TIMESTAMP NOT NULL,
+    TURNS NUMBER(10) DEFAULT 0,
+    PROMPT_VERSION VARCHAR2(64),
+    UPDATED_AT TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (USER_ID, SESSION_ID)
+)`)
+		return err
+	}
+	_, err := s.db.Exec(`
+CREATE TABLE IF NOT EXISTS conversation_summary (
+    user_id VARCHAR(64) NOT NULL,
+    session_id VARCHAR(128) NOT NULL,
+    summary LONGTEXT NOT NULL,
+    last_entry_id VARCHAR(36) NOT NULL,
+    covered_until DATETIME NOT NULL,
+    turns INT NOT NULL DEFAULT 0,
+    prompt_version VARCHAR(64),
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, session_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+`)
+	return err
+}
+
+// Append 向指定会话追加记忆，返回新记忆的 ID
+func (s *Store) Append(userID, sessionID string, entry Entry) (string, error) {
 	if userID == "" {
-		return errors.New("userID is required for memory")
+		return "", errors.New("userID is required for memory")
 	}
 	if sessionID == "" {
 		sessionID = "default"
@@ -103,13 +157,13 @@ func (s *Store) Append(userID, sessionID string, entry Entry) error {
 			(ID, USER_ID, SESSION_ID, QUERY_TEXT, SQL_TEXT, REASONING, SOURCE, CREATED_AT)
 			VALUES (:1, :2, :3, :4, :5, :6, :7, :8)`,
 			id, userID, sessionID, entry.Query, entry.SQL, entry.Reasoning, entry.Source, entry.CreatedAt)
-		return err
+		return id, err
 	}
 	_, err := s.db.Exec(`INSERT INTO conversation_memory 
 		(id, user_id, session_id, query_text, sql_text, reasoning, source, created_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
 		id, userID, sessionID, entry.Query, entry.SQL, entry.Reasoning, entry.Source, entry.CreatedAt)
-	return err
+	return id, err
 }
 
 // GetRecent 返回指定会话最近的 N 条记忆
@@ -120,7 +174,7 @@ func (s *Store) GetRecent(userID, sessionID string, limit int) []Entry {
 	if sessionID == "" {
 		sessionID = "default"
 	}
-	query := `SELECT query_text, sql_text, reasoning, source, created_at
+	query := `SELECT id, query_text, sql_text, reasoning, source, created_at
 		FROM conversation_memory
 		WHERE user_id = ? AND session_id = ?
 		ORDER BY created_at DESC
@@ -128,8 +182,8 @@ func (s *Store) GetRecent(userID, sessionID string, limit int) []Entry {
 	var rows *sql.Rows
 	var err error
 	if s.driver == "oracle" {
-		query = `SELECT query_text, sql_text, reasoning, source, created_at FROM (
-			SELECT query_text, sql_text, reasoning, source, created_at
+		query = `SELECT id, query_text, sql_text, reasoning, source, created_at FROM (
+			SELECT id, query_text, sql_text, reasoning, source, created_at
 			FROM CONVERSATION_MEMORY
 			WHERE user_id = :1 AND session_id = :2
 			ORDER BY created_at DESC
@@ -146,7 +200,7 @@ func (s *Store) GetRecent(userID, sessionID string, limit int) []Entry {
 	var tmp []Entry
 	for rows.Next() {
 		var e Entry
-		if err := rows.Scan(&e.Query, &e.SQL, &e.Reasoning, &e.Source, &e.CreatedAt); err != nil {
+		if err := rows.Scan(&e.ID, &e.Query, &e.SQL, &e.Reasoning, &e.Source, &e.CreatedAt); err != nil {
 			continue
 		}
 		tmp = append(tmp, e)
@@ -158,19 +212,57 @@ func (s *Store) GetRecent(userID, sessionID string, limit int) []Entry {
 	return tmp
 }
 
+// GetSince 按时间顺序返回 created_at 不早于 after 的最早 N 条记忆
+func (s *Store) GetSince(userID, sessionID string, after time.Time, limit int) []Entry {
+	if limit <= 0 {
+		return nil
+	}
+	if sessionID == "" {
+		sessionID = "default"
+	}
+	query := `SELECT id, query_text, sql_text, reasoning, source, created_at
+		FROM conversation_memory
+		WHERE user_id = ? AND session_id = ? AND created_at >= ?
+		ORDER BY created_at ASC, id ASC
+		LIMIT ?`
+	if s.driver == "oracle" {
+		query = `SELECT id, query_text, sql_text, reasoning, source, created_at FROM (
+			SELECT id, query_text, sql_text, reasoning, source, created_at
+			FROM CONVERSATION_MEMORY
+			WHERE user_id = :1 AND session_id = :2 AND created_at >= :3
+			ORDER BY created_at ASC, id ASC
+		) WHERE ROWNUM <= :4`
+	}
+	rows, err := s.db.Query(query, userID, sessionID, after, limit)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var e Entry
+		if err := rows.Scan(&e.ID, &e.Query, &e.SQL, &e.Reasoning, &e.Source, &e.CreatedAt); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
 // GetSession 返回整个会话的记忆
 func (s *Store) GetSession(userID, sessionID string) []Entry {
 	if sessionID == "" {
 		sessionID = "default"
 	}
-	query := `SELECT query_text, sql_text, reasoning, source, created_at
+	query := `SELECT id, query_text, sql_text, reasoning, source, created_at
 		FROM conversation_memory
 		WHERE user_id = ? AND session_id = ?
 		ORDER BY created_at ASC`
 	var rows *sql.Rows
 	var err error
 	if s.driver == "oracle" {
-		query = `SELECT query_text, sql_text, reasoning, source, created_at
+		query = `SELECT id, query_text, sql_text, reasoning, source, created_at
 			FROM CONVERSATION_MEMORY
 			WHERE user_id = :1 AND session_id = :2
 			ORDER BY created_at ASC`
@@ -186,7 +278,7 @@ func (s *Store) GetSession(userID, sessionID string) []Entry {
 	var entries []Entry
 	for rows.Next() {
 		var e Entry
-		if err := rows.Scan(&e.Query, &e.SQL, &e.Reasoning, &e.Source, &e.CreatedAt); err != nil {
+		if err := rows.Scan(&e.ID, &e.Query, &e.SQL, &e.Reasoning, &e.Source, &e.CreatedAt); err != nil {
 			continue
 		}
 		entries = append(entries, e)
@@ -194,6 +286,75 @@ func (s *Store) GetSession(userID, sessionID string) []Entry {
 	return entries
 }
 
+// Get 返回用户的一条记忆
+func (s *Store) Get(userID, id string) (*Entry, bool) {
+	query := `SELECT id, query_text, sql_text, reasoning, source, created_at
+		FROM conversation_memory WHERE user_id = ? AND id = ?`
+	if s.driver == "oracle" {
+		query = `SELECT id, query_text, sql_text, reasoning, source, created_at
+			FROM CONVERSATION_MEMORY WHERE user_id = :1 AND id = :2`
+	}
+	var e Entry
+	var reasoning, source sql.NullString
+	if err := s.db.QueryRow(query, userID, id).Scan(&e.ID, &e.Query, &e.SQL, &reasoning, &source, &e.CreatedAt); err != nil {
+		return nil, false
+	}
+	e.Reasoning = reasoning.String
+	e.Source = source.String
+	return &e, true
+}
+
+// GetSummary 返回会话的滚动摘要
+func (s *Store) GetSummary(userID, sessionID string) (*Summary, bool) {
+	if sessionID == "" {
+		sessionID = "default"
+	}
+	query := `SELECT summary, last_entry_id, covered_until, turns, prompt_version, updated_at
+		FROM conversation_summary WHERE user_id = ? AND session_id = ?`
+	if s.driver == "oracle" {
+		query = `SELECT summary, last_entry_id, covered_until, turns, prompt_version, updated_at
+			FROM CONVERSATION_SUMMARY WHERE user_id = :1 AND session_id = :2`
+	}
+	var sum Summary
+	var version sql.NullString
+	if err := s.db.QueryRow(query, userID, sessionID).Scan(&sum.Summary, &sum.LastEntryID, &sum.CoveredUntil, &sum.Turns, &version, &sum.UpdatedAt); err != nil {
+		return nil, false
+	}
+	sum.PromptVersion = version.String
+	return &sum, true
+}
+
+// SaveSummary 写入或替换会话的滚动摘要
+func (s *Store) SaveSummary(userID, sessionID string, sum Summary) error {
+	if userID == "" {
+		return errors.New("userID is required for memory")
+	}
+	if sessionID == "" {
+		sessionID = "default"
+	}
+	if sum.UpdatedAt.IsZero() {
+		sum.UpdatedAt = time.Now()
+	}
+	if s.driver == "oracle" {
+		_, err := s.db.Exec(`MERGE INTO CONVERSATION_SUMMARY t
+            USING (SELECT :1 AS USER_ID, :2 AS SESSION_ID FROM dual) s
+            ON (t.USER_ID = s.USER_ID AND t.SESSION_ID = s.SESSION_ID)
+            WHEN MATCHED THEN UPDATE SET SUMMARY=:3, LAST_ENTRY_ID=:4, COVERED_UNTIL=:5, TURNS=:6, PROMPT_VERSION=:7, UPDATED_AT=:8
+            WHEN NOT MATCHED THEN INSERT (USER_ID, SESSION_ID, SUMMARY, LAST_ENTRY_ID, COVERED_UNTIL, TURNS, PROMPT_VERSION, UPDATED_AT)
+            VALUES (:1, :2, :3, :4, :5, :6, :7, :8)`,
+			userID, sessionID, sum.Summary, sum.LastEntryID, sum.CoveredUntil, sum.Turns, sum.PromptVersion, sum.UpdatedAt)
+		return err
+	}
+	_, err := s.db.Exec(`INSERT INTO conversation_summary
+		(user_id, session_id, summary, last_entry_id, covered_until, turns, prompt_version, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE summary = VALUES(summary), last_entry_id = VALUES(last_entry_id),
+			covered_until = VALUES(covered_until), turns = VALUES(turns),
+			prompt_version = VALUES(prompt_version), updated_at = VALUES(updated_at)`,
+		userID, sessionID, sum.Summary, sum.LastEntryID, sum.CoveredUntil, sum.Turns, sum.PromptVersion, sum.UpdatedAt)
+	return err
+}
+
 // ListSessions 返回用户所有 session 的最近一次交互时间
 func (s *Store) ListSessions(userID string) map[string]time.Time {
 	query := `SELECT session_id, MAX(created_at) AS last_used
@@ -231,9 +392,15 @@ func (s *Store) ListSessions(userID string) map[string]time.Time {
 // CleanupSessions 用于删除超时数据（可选）
 func (s *Store) CleanupSessions(ctx context.Context, cutoff time.Time) error {
 	if s.driver == "oracle" {
-		_, err := s.db.ExecContext(ctx, `DELETE FROM CONVERSATION_MEMORY WHERE CREATED_AT < :1`, cutoff)
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM CONVERSATION_MEMORY WHERE CREATED_AT < :1`, cutoff); err != nil {
+			return err
+		}
+		_, err := s.db.ExecContext(ctx, `DELETE FROM CONVERSATION_SUMMARY WHERE UPDATED_AT < :1`, cutoff)
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM conversation_memory WHERE created_at < ?`, cutoff); err != nil {
 		return err
 	}
-	_, err := s.db.ExecContext(ctx, `DELETE FROM conversation_memory WHERE created_at < ?`, cutoff)
+	_, err := s.db.ExecContext(ctx, `DELETE FROM conversation_summary WHERE updated_at < ?`, cutoff)
 	return err
 }
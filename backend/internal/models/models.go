@@ -8,11 +8,28 @@ type User struct {
 	Username  string    `json:"username"`
 	Email     string    `json:"email"`
 	Role      string    `json:"role"`
-	Password  string    `json:"-"` // Never expose password
+	Password  string    `json:"-"`                // Never expose password
+	Locale    string    `json:"locale,omitempty"` // preferred UI and answer language; empty follows Accept-Language
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// UpdateProfileRequest changes the caller's profile. An empty locale clears
+// the preference.
+type UpdateProfileRequest struct {
+	Locale *string `json:"locale"`
+}
+
+// ProfileResponse is the caller's profile with the locale in effect. Token is
+// set after an update, since the locale travels in the token.
+type ProfileResponse struct {
+	User      User     `json:"user"`
+	Locale    string   `json:"locale"`
+	Locales   []string `json:"locales"`
+	Token     string   `json:"token,omitempty"`
+	ExpiresIn int      `json:"expires_in,omitempty"`
+}
+
 // LoginRequest is the request body for login
 type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
@@ -59,24 +76,155 @@ type SQLGenerateRequest struct {
 	TableNames string `json:"table_names"`
 	SessionID  string `json:"session_id"`
 	RequestID  string `json:"request_id"`
+	// AutoRepair overrides SQL_AUTO_REPAIR: dry-run the SQL and let the LLM fix errors.
+	AutoRepair      *bool `json:"auto_repair,omitempty"`
+	MaxRepairRounds int   `json:"max_repair_rounds,omitempty"`
+	// Candidates > 1 samples several generations and returns the one whose
+	// result most candidates agree on.
+	Candidates int `json:"candidates,omitempty"`
+	// ClarificationAnswer answers the clarifying question returned for Query;
+	// the question is taken from session memory when not sent back.
+	ClarificationAnswer   string `json:"clarification_answer,omitempty"`
+	ClarificationQuestion string `json:"clarification_question,omitempty"`
+	// AllowClarification overrides SQL_CLARIFY for this request.
+	AllowClarification *bool `json:"allow_clarification,omitempty"`
+	// Agent overrides SQL_AGENT: let the LLM explore the schema with tools
+	// instead of receiving it up front.
+	Agent *bool `json:"agent,omitempty"`
+	// Mode "ask" on the WebSocket runs the SQL and streams a summary of the result.
+	Mode string `json:"mode,omitempty"`
+}
+
+// AskRequest generates SQL for a question, runs it and summarizes the result.
+type AskRequest struct {
+	SQLGenerateRequest
+	PageSize int `json:"page_size"`
+}
+
+// AskResponse is the answer to a question with the SQL and rows behind it.
+type AskResponse struct {
+	RequestID     string               `json:"request_id"`
+	Generation    *SQLGenerateResponse `json:"generation"`
+	Result        *SQLExecuteResponse  `json:"result,omitempty"`
+	Summary       string               `json:"summary"`
+	PromptVersion string               `json:"prompt_version,omitempty"` // summary prompt
+}
+
+// Clarification asks the user to narrow an underspecified question before
+// SQL is generated. Options are suggested answers the UI can offer.
+type Clarification struct {
+	Question string   `json:"question"`
+	Type     string   `json:"type,omitempty"` // time_range, metric, table, filter or other
+	Options  []string `json:"options,omitempty"`
+}
+
+// Definition is a glossary term as applied to a generation.
+type Definition struct {
+	ID            string `json:"id"`
+	Term          string `json:"term"`
+	Definition    string `json:"definition,omitempty"`
+	SQLExpression string `json:"sql_expression,omitempty"`
+}
+
+// GlossaryTermRequest creates or updates a glossary term.
+type GlossaryTermRequest struct {
+	Term          string   `json:"term" binding:"required"`
+	Synonyms      []string `json:"synonyms"`
+	Definition    string   `json:"definition"`
+	SQLExpression string   `json:"sql_expression"`
+	Tables        []string `json:"tables"`
+}
+
+// FeedbackRequest rates a generated SQL by its request ID.
+type FeedbackRequest struct {
+	RequestID    string `json:"request_id" binding:"required"`
+	Rating       string `json:"rating" binding:"required"` // "up" or "down"
+	CorrectedSQL string `json:"corrected_sql"`
+	Comment      string `json:"comment"`
+}
+
+// PromoteExampleRequest promotes a saved report or memory entry to a verified example.
+type PromoteExampleRequest struct {
+	Source   string `json:"source" binding:"required"` // "report" or "memory"
+	ID       string `json:"id" binding:"required"`
+	Question string `json:"question"` // defaults to the report title or the remembered question
+}
+
+// SQLCandidate is one sampled generation and how it fared in the vote.
+type SQLCandidate struct {
+	SQL         string   `json:"sql"`
+	Explanation string   `json:"explanation,omitempty"`
+	Confidence  *float64 `json:"confidence,omitempty"`
+	Temperature float64  `json:"temperature"`
+	Votes       int      `json:"votes"`               // candidates returning the same result, itself included
+	Signature   string   `json:"signature,omitempty"` // hash of the sampled result
+	RowCount    int      `json:"row_count"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// RepairAttempt is one check of generated SQL in the execute-and-repair loop.
+type RepairAttempt struct {
+	Round      int    `json:"round"` // 0 is the originally generated SQL
+	SQL        string `json:"sql"`
+	Stage      string `json:"stage"` // "validate" or "dry_run"
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	OracleCode string `json:"oracle_code,omitempty"`
+	Analysis   string `json:"analysis,omitempty"` // DebugSQL analysis that produced the next round
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// AgentStep is one tool call made while the agent explored the database.
+type AgentStep struct {
+	Step       int    `json:"step"`
+	Tool       string `json:"tool"`
+	Arguments  string `json:"arguments"`
+	Result     string `json:"result,omitempty"` // truncated preview of what the model saw
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
 }
 
 // SQLGenerateResponse is the response after SQL generation
 type SQLGenerateResponse struct {
-	SQL        string `json:"sql"`
-	Reasoning  string `json:"reasoning"`
-	Source     string `json:"source"`
-	TemplateID string `json:"template_id,omitempty"`
+	SQL                string   `json:"sql"`
+	Reasoning          string   `json:"reasoning"`
+	Explanation        string   `json:"explanation,omitempty"`
+	TablesUsed         []string `json:"tables_used,omitempty"`
+	Assumptions        []string `json:"assumptions,omitempty"`
+	Confidence         *float64 `json:"confidence,omitempty"`
+	ClarifyingQuestion string   `json:"clarifying_question,omitempty"`
+	// Clarification is set, with SQL empty, when the question must be narrowed first.
+	Clarification *Clarification `json:"clarification,omitempty"`
+	Source        string         `json:"source"`
+	TemplateID    string         `json:"template_id,omitempty"`
+	PromptVersion string         `json:"prompt_version,omitempty"`
+	// Model is the LLM model that answered, a fallback's when the primary failed.
+	Model      string `json:"model,omitempty"`
 	UsedMemory bool   `json:"used_memory"`
 	RequestID  string `json:"request_id"`
+	// Validated is true when the final SQL passed the repair loop's dry run.
+	Validated bool            `json:"validated,omitempty"`
+	Attempts  []RepairAttempt `json:"attempts,omitempty"`
+	// DefinitionsUsed lists the glossary terms injected into the prompt.
+	DefinitionsUsed []Definition `json:"definitions_used,omitempty"`
+	// ExamplesUsed lists the IDs of the few-shot examples in the prompt.
+	ExamplesUsed []string `json:"examples_used,omitempty"`
+	// Votes and Alternates are set by multi-candidate generation.
+	Votes      int            `json:"votes,omitempty"`
+	Alternates []SQLCandidate `json:"alternates,omitempty"`
+	// AgentSteps lists the tool calls of agent-mode generation.
+	AgentSteps []AgentStep `json:"agent_steps,omitempty"`
 }
 
 // SQLExecuteRequest is the request to execute SQL
 type SQLExecuteRequest struct {
-	SQL      string `json:"sql" binding:"required"`
-	Timeout  int    `json:"timeout"` // seconds
-	Page     int    `json:"page"`
-	PageSize int    `json:"page_size"`
+	SQL       string `json:"sql" binding:"required"`
+	Timeout   int    `json:"timeout"` // seconds
+	Page      int    `json:"page"`
+	PageSize  int    `json:"page_size"`
+	RequestID string `json:"request_id"`
+	// Chart asks for a chart recommendation with the result.
+	Chart bool `json:"chart"`
 }
 
 // SQLExportRequest describes a SQL export job
@@ -99,6 +247,100 @@ type SQLExecuteResponse struct {
 	PageSize      int             `json:"page_size"`
 	HasMore       bool            `json:"has_more"`
 	MaskedColumns []string        `json:"masked_columns,omitempty"`
+	// Chart is the recommended visualization, when one was requested.
+	Chart *ChartRecommendation `json:"chart,omitempty"`
+	// ExecutionID identifies the buffered rows for POST /api/sql/transform.
+	ExecutionID string `json:"execution_id,omitempty"`
+	// Partial marks a transform computed over a source page that had more
+	// rows in the database.
+	Partial bool `json:"partial,omitempty"`
+}
+
+// ChartColumn profiles a result column. Type is a Vega-Lite field type:
+// quantitative, temporal, ordinal or nominal.
+type ChartColumn struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Distinct int    `json:"distinct"`
+	Nulls    int    `json:"nulls"`
+}
+
+// ChartRecommendation is a suggested visualization of a result. X is the
+// category or time axis, Y the measures and Series an optional column that
+// splits them by colour. Spec is a Vega-Lite v5 spec with the data inline;
+// it is omitted for tables.
+type ChartRecommendation struct {
+	Type   string   `json:"type"` // bar, line, pie or table
+	X      string   `json:"x,omitempty"`
+	Y      []string `json:"y,omitempty"`
+	Series string   `json:"series,omitempty"`
+	Title  string   `json:"title,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+	// Source is "heuristic" or "llm".
+	Source  string                 `json:"source"`
+	Columns []ChartColumn          `json:"columns"`
+	Spec    map[string]interface{} `json:"spec,omitempty"`
+	// Truncated is set when the spec holds only the first rows.
+	Truncated     bool   `json:"truncated,omitempty"`
+	PromptVersion string `json:"prompt_version,omitempty"`
+}
+
+// ChartRequest asks for a chart for a result, given inline or as SQL to run.
+type ChartRequest struct {
+	Question string          `json:"question"`
+	SQL      string          `json:"sql"`
+	Columns  []string        `json:"columns"`
+	Rows     [][]interface{} `json:"rows"`
+	// Refine overrides CHART_LLM_REFINE for this request.
+	Refine *bool `json:"refine,omitempty"`
+}
+
+// TransformRequest applies operations to a previous execution, or to columns
+// and rows sent inline, without querying the database again.
+type TransformRequest struct {
+	ExecutionID string          `json:"execution_id"`
+	Columns     []string        `json:"columns"`
+	Rows        [][]interface{} `json:"rows"`
+	Operations  []TransformOp   `json:"operations" binding:"required"`
+	Page        int             `json:"page"`
+	PageSize    int             `json:"page_size"`
+	// Chart asks for a chart recommendation for the transformed result.
+	Chart bool `json:"chart"`
+}
+
+// TransformOp is one step of a transform, selected by Op:
+//   - filter: Column Operator Value, Operator one of = != > >= < <= in
+//     not_in contains starts_with is_null not_null;
+//   - group_by: Columns and Aggregates;
+//   - pivot: Index rows by Column values, aggregating Values with Func;
+//   - sort: Sort keys in order;
+//   - limit: keep the first Limit rows.
+type TransformOp struct {
+	Op         string          `json:"op" binding:"required"`
+	Column     string          `json:"column,omitempty"`
+	Operator   string          `json:"operator,omitempty"`
+	Value      interface{}     `json:"value,omitempty"`
+	Columns    []string        `json:"columns,omitempty"`
+	Aggregates []TransformAgg  `json:"aggregates,omitempty"`
+	Index      []string        `json:"index,omitempty"`
+	Values     string          `json:"values,omitempty"`
+	Func       string          `json:"func,omitempty"`
+	Sort       []TransformSort `json:"sort,omitempty"`
+	Limit      int             `json:"limit,omitempty"`
+}
+
+// TransformAgg aggregates Column with Func (count, count_distinct, sum, avg,
+// min, max) into a column named As, FUNC_COLUMN by default.
+type TransformAgg struct {
+	Column string `json:"column"`
+	Func   string `json:"func"`
+	As     string `json:"as,omitempty"`
+}
+
+// TransformSort is a sort key.
+type TransformSort struct {
+	Column string `json:"column"`
+	Desc   bool   `json:"desc"`
 }
 
 // SQLHistoryRecord represents a saved SQL query
@@ -150,6 +392,46 @@ type SQLDebugResponse struct {
 	Explanation  string `json:"explanation"`
 }
 
+// SQLExplainRequest is the request to explain existing SQL in plain language
+type SQLExplainRequest struct {
+	SQL string `json:"sql" binding:"required"`
+	// Context is an optional note from the user, e.g. where the SQL came from.
+	Context string `json:"context"`
+	// Language of the explanation, e.g. "zh-CN" or "en"; defaults to the
+	// user's locale.
+	Language string `json:"language"`
+}
+
+// SQLFinding is a risky pattern found by static analysis of SQL
+type SQLFinding struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ExplainedTable is a table referenced by explained SQL with the referenced
+// columns and their catalog comments. Found is false when the table is not
+// in the catalog or not available to the assistant.
+type ExplainedTable struct {
+	Name    string       `json:"name"`
+	Aliases []string     `json:"aliases,omitempty"`
+	Comment string       `json:"comment,omitempty"`
+	Found   bool         `json:"found"`
+	Columns []ColumnInfo `json:"columns"`
+}
+
+// SQLExplainResponse is a step-by-step explanation of SQL
+type SQLExplainResponse struct {
+	StatementType string   `json:"statement_type"`
+	Summary       string   `json:"summary"`
+	Steps         []string `json:"steps"`
+	// Warnings are risky patterns explained in the user's language.
+	Warnings []string `json:"warnings"`
+	// Findings are the patterns detected by static analysis.
+	Findings      []SQLFinding     `json:"findings"`
+	Tables        []ExplainedTable `json:"tables"`
+	PromptVersion string           `json:"prompt_version,omitempty"`
+}
+
 // MCPRequest is the base request for MCP service
 type MCPRequest struct {
 	Method string                 `json:"method"`
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"mime"
 	"net/smtp"
 	"sort"
 	"strings"
@@ -16,6 +17,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/yourusername/db_asst/config"
+	"github.com/yourusername/db_asst/internal/i18n"
 )
 
 // Event represents a single metric row stored in DB.
@@ -315,11 +317,13 @@ func (m *Monitor) sendAlert(eventType string, duration time.Duration, extra map[
 			extraText = fmt.Sprintf("%v", extra)
 		}
 	}
+	// Alerts go to operators, so they use the server's default locale.
+	locale := i18n.Default()
 	if extraText == "" {
-		extraText = "(none)"
+		extraText = i18n.T(locale, "(none)")
 	}
-	subject := fmt.Sprintf("[DB Assistant] %s failed", eventType)
-	body := fmt.Sprintf("Event: %s\nDuration: %s\nTime: %s\nExtra:\n%s",
+	subject := i18n.T(locale, "[DB Assistant] %s failed", eventType)
+	body := i18n.T(locale, "Event: %s\nDuration: %s\nTime: %s\nExtra:\n%s",
 		eventType,
 		duration,
 		time.Now().Format(time.RFC3339),
@@ -333,10 +337,10 @@ func (m *Monitor) sendAlert(eventType string, duration time.Duration, extra map[
 	if len(recipients) == 0 {
 		return
 	}
-	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s",
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: text/plain; charset=UTF-8\r\n\r\n%s",
 		m.cfg.EmailSMTPUser,
 		strings.Join(recipients, ","),
-		subject,
+		mime.QEncoding.Encode("UTF-8", subject),
 		body)
 	auth := smtp.PlainAuth("", m.cfg.EmailSMTPUser, m.cfg.EmailSMTPPassword, m.cfg.EmailSMTPHost)
 	addr := fmt.Sprintf("%s:%d", m.cfg.EmailSMTPHost, m.cfg.EmailSMTPPort)
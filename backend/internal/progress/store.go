@@ -60,13 +60,13 @@ func (s *Store) Complete(id, message string) {
 	}
 }
 
-func (s *Store) Fail(id, message string) {
+func (s *Store) Fail(id, message, errMsg string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if entry, ok := s.items[id]; ok {
 		entry.Stage = "failed"
-		entry.Message = "请求失败"
-		entry.Error = message
+		entry.Message = message
+		entry.Error = errMsg
 		entry.Done = true
 		entry.Success = false
 		entry.UpdatedAt = time.Now()
@@ -0,0 +1,400 @@
+package prompts
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"path"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Built-in template names.
+const (
+	SQLGeneration  = "sql_generation"
+	Debug          = "sql_debug"
+	Guidance       = "guidance"
+	ResultSummary  = "result_summary"
+	SQLAgent       = "sql_agent"
+	Explain        = "sql_explain"
+	Chart          = "chart_recommendation"
+	SessionSummary = "session_summary"
+)
+
+var builtinNames = []string{SQLGeneration, Debug, Guidance, ResultSummary, SQLAgent, Explain, Chart, SessionSummary}
+
+// dataTypes is the data each built-in template is rendered with.
+var dataTypes = map[string]reflect.Type{
+	SQLGeneration:  reflect.TypeOf(SQLGenerationData{}),
+	SQLAgent:       reflect.TypeOf(SQLGenerationData{}),
+	Debug:          reflect.TypeOf(DebugData{}),
+	Guidance:       reflect.TypeOf(GuidanceData{}),
+	ResultSummary:  reflect.TypeOf(ResultSummaryData{}),
+	Explain:        reflect.TypeOf(ExplainData{}),
+	Chart:          reflect.TypeOf(ChartData{}),
+	SessionSummary: reflect.TypeOf(SessionSummaryData{}),
+}
+
+// DefaultDatasource is the fallback datasource key for stored templates that
+// apply to every datasource.
+const DefaultDatasource = "default"
+
+//go:embed defaults
+var defaultFS embed.FS
+
+// Template is one version of a prompt, split into the system instructions,
+// the injected context (schema, memory, ...) and the user turn.
+type Template struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Datasource  string    `json:"datasource"`
+	Version     int       `json:"version"`
+	Description string    `json:"description,omitempty"`
+	System      string    `json:"system"`
+	Context     string    `json:"context"`
+	User        string    `json:"user"`
+	Active      bool      `json:"active"`
+	Builtin     bool      `json:"builtin"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitempty"`
+}
+
+// Message is one rendered chat message.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Rendered is a template resolved and executed against request data.
+type Rendered struct {
+	TemplateID string    `json:"template_id,omitempty"`
+	Name       string    `json:"name"`
+	Datasource string    `json:"datasource"`
+	Version    int       `json:"version"`
+	Builtin    bool      `json:"builtin"`
+	Messages   []Message `json:"messages"`
+}
+
+// Label identifies the template version used, e.g. "sql_generation@v3".
+func (r *Rendered) Label() string {
+	if r == nil {
+		return ""
+	}
+	if r.Builtin {
+		return r.Name + "@builtin"
+	}
+	return fmt.Sprintf("%s@v%d", r.Name, r.Version)
+}
+
+// SQLGenerationData is the template data for SQLGeneration and SQLAgent. In
+// agent mode Schema holds only the tables the user selected, if any.
+type SQLGenerationData struct {
+	Schema            string
+	Memory            string
+	Glossary          string // business terms matched in the query
+	Examples          string // verified question/SQL pairs similar to the query
+	AdditionalContext string
+	Query             string
+	// AllowClarification lets the model ask instead of guessing.
+	AllowClarification bool
+	// Clarification is the question asked last turn and the user's answer.
+	Clarification string
+}
+
+// DebugData is the template data for Debug.
+type DebugData struct {
+	Schema string
+	SQL    string
+	Error  string
+}
+
+// GuidanceData is the template data for Guidance.
+type GuidanceData struct {
+	Query    string
+	Schema   string
+	Issue    string
+	Language string // empty means Chinese
+}
+
+// ResultSummaryData is the template data for ResultSummary.
+type ResultSummaryData struct {
+	Query     string
+	SQL       string
+	Result    string // rendered table of the rows shown
+	RowCount  int
+	Shown     int
+	Truncated bool
+}
+
+// ExplainData is the template data for Explain.
+type ExplainData struct {
+	SQL      string
+	Context  string // optional note from the user
+	Catalog  string // referenced tables and columns with their comments
+	Findings string // static analysis findings, one per line
+	Language string // empty means the user's language
+}
+
+// ChartData is the template data for Chart.
+type ChartData struct {
+	Query     string
+	SQL       string
+	Columns   string // one profiled column per line
+	Sample    string // rendered table of the first rows
+	RowCount  int
+	Suggested string // the heuristic recommendation
+}
+
+// SessionSummaryData is the template data for SessionSummary.
+type SessionSummaryData struct {
+	Previous string // the summary so far, empty for a new session
+	Turns    string // numbered turns to fold into the summary
+	MaxChars int
+}
+
+// Service resolves and renders prompt templates. Without a store only the
+// built-in templates are available.
+type Service struct {
+	store    *Store
+	builtins map[string]*Template
+}
+
+// NewService loads the built-in templates; store may be nil.
+func NewService(store *Store) (*Service, error) {
+	svc := &Service{store: store, builtins: make(map[string]*Template)}
+	for _, name := range builtinNames {
+		tpl := &Template{Name: name, Datasource: DefaultDatasource, Builtin: true, Active: true}
+		for part, dst := range map[string]*string{"system": &tpl.System, "context": &tpl.Context, "user": &tpl.User} {
+			data, err := defaultFS.ReadFile(path.Join("defaults", name, part+".tmpl"))
+			if err != nil {
+				return nil, fmt.Errorf("load builtin prompt %s/%s: %w", name, part, err)
+			}
+			*dst = string(data)
+		}
+		if err := Validate(tpl); err != nil {
+			return nil, err
+		}
+		svc.builtins[name] = tpl
+	}
+	return svc, nil
+}
+
+// Names lists the built-in template names.
+func (s *Service) Names() []string {
+	return append([]string(nil), builtinNames...)
+}
+
+// Builtin returns the embedded template for name.
+func (s *Service) Builtin(name string) (*Template, error) {
+	tpl, ok := s.builtins[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown prompt template %q", name)
+	}
+	copy := *tpl
+	return &copy, nil
+}
+
+// Resolve picks the template for name: the active version for datasource,
+// then the active "default" version, then the built-in.
+func (s *Service) Resolve(name, datasource string) (*Template, error) {
+	builtin, err := s.Builtin(name)
+	if err != nil {
+		return nil, err
+	}
+	if s.store == nil {
+		return builtin, nil
+	}
+	candidates := []string{DefaultDatasource}
+	if datasource != "" && datasource != DefaultDatasource {
+		candidates = []string{datasource, DefaultDatasource}
+	}
+	for _, ds := range candidates {
+		tpl, err := s.store.GetActive(name, ds)
+		if err != nil {
+			return builtin, err
+		}
+		if tpl != nil {
+			return tpl, nil
+		}
+	}
+	return builtin, nil
+}
+
+// Render resolves the template for name/datasource and executes it. When the
+// store lookup fails, or a stored template fails to render, the built-in is
+// rendered and the error is returned alongside so callers can log it.
+func (s *Service) Render(name, datasource string, data interface{}) (*Rendered, error) {
+	tpl, resolveErr := s.Resolve(name, datasource)
+	if tpl == nil {
+		return nil, resolveErr
+	}
+	rendered, err := RenderTemplate(tpl, data)
+	if err != nil && !tpl.Builtin {
+		resolveErr = err
+		tpl, _ = s.Builtin(name)
+		rendered, err = RenderTemplate(tpl, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if rendered.Datasource == "" {
+		rendered.Datasource = datasource
+	}
+	return rendered, resolveErr
+}
+
+// Get returns a stored template version.
+func (s *Service) Get(id string) (*Template, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("prompt store not configured")
+	}
+	return s.store.Get(id)
+}
+
+// List returns the built-in templates followed by stored versions.
+func (s *Service) List(name string) ([]*Template, error) {
+	result := make([]*Template, 0)
+	for _, n := range s.Names() {
+		if name != "" && name != n {
+			continue
+		}
+		tpl, _ := s.Builtin(n)
+		result = append(result, tpl)
+	}
+	if s.store == nil {
+		return result, nil
+	}
+	stored, err := s.store.List(name)
+	if err != nil {
+		return nil, err
+	}
+	return append(result, stored...), nil
+}
+
+// CreateVersion validates tpl and stores it as the next version.
+func (s *Service) CreateVersion(tpl *Template, activate bool) error {
+	if s.store == nil {
+		return fmt.Errorf("prompt store not configured")
+	}
+	if _, ok := s.builtins[tpl.Name]; !ok {
+		return fmt.Errorf("unknown prompt template %q", tpl.Name)
+	}
+	tpl.Datasource = strings.TrimSpace(tpl.Datasource)
+	if tpl.Datasource == "" {
+		tpl.Datasource = DefaultDatasource
+	}
+	tpl.Builtin = false
+	if err := Validate(tpl); err != nil {
+		return err
+	}
+	return s.store.Insert(tpl, activate)
+}
+
+// Activate switches the active version for the template's name/datasource.
+func (s *Service) Activate(id string) (*Template, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("prompt store not configured")
+	}
+	return s.store.Activate(id)
+}
+
+// Reset deactivates all stored versions so the built-in applies again.
+func (s *Service) Reset(name, datasource string) error {
+	if s.store == nil {
+		return nil
+	}
+	if datasource == "" {
+		datasource = DefaultDatasource
+	}
+	return s.store.Deactivate(name, datasource)
+}
+
+// Validate checks that every section parses as a text/template and that the
+// user section is present. Templates of a known name are also executed
+// against their data type, once empty and once with every field set, so
+// that misspelt fields are caught before the template is used.
+func Validate(tpl *Template) error {
+	if strings.TrimSpace(tpl.User) == "" {
+		return fmt.Errorf("prompt template %s: user section is required", tpl.Name)
+	}
+	for part, text := range map[string]string{"system": tpl.System, "context": tpl.Context, "user": tpl.User} {
+		t, err := template.New(part).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return fmt.Errorf("prompt template %s/%s: %w", tpl.Name, part, err)
+		}
+		typ, ok := dataTypes[tpl.Name]
+		if !ok {
+			continue
+		}
+		for _, data := range []interface{}{reflect.Zero(typ).Interface(), filledData(typ)} {
+			var buf bytes.Buffer
+			if err := t.Execute(&buf, data); err != nil {
+				return fmt.Errorf("prompt template %s/%s: %w", tpl.Name, part, err)
+			}
+		}
+	}
+	return nil
+}
+
+// filledData returns a value of the struct type typ with every string,
+// number and bool field set, so that conditional sections are executed.
+func filledData(typ reflect.Type) interface{} {
+	v := reflect.New(typ).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		switch f := v.Field(i); f.Kind() {
+		case reflect.String:
+			f.SetString("x")
+		case reflect.Int:
+			f.SetInt(1)
+		case reflect.Bool:
+			f.SetBool(true)
+		}
+	}
+	return v.Interface()
+}
+
+// RenderTemplate executes tpl against data. System and context render as
+// separate system messages so providers can cache the stable instructions;
+// empty sections are skipped.
+func RenderTemplate(tpl *Template, data interface{}) (*Rendered, error) {
+	rendered := &Rendered{
+		TemplateID: tpl.ID,
+		Name:       tpl.Name,
+		Datasource: tpl.Datasource,
+		Version:    tpl.Version,
+		Builtin:    tpl.Builtin,
+	}
+	sections := []struct {
+		part string
+		role string
+		text string
+	}{
+		{"system", "system", tpl.System},
+		{"context", "system", tpl.Context},
+		{"user", "user", tpl.User},
+	}
+	for _, section := range sections {
+		if strings.TrimSpace(section.text) == "" {
+			continue
+		}
+		t, err := template.New(section.part).Option("missingkey=zero").Parse(section.text)
+		if err != nil {
+			return nil, fmt.Errorf("prompt template %s/%s: %w", tpl.Name, section.part, err)
+		}
+		var buf bytes.Buffer
+		if err := t.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("render prompt %s/%s: %w", tpl.Name, section.part, err)
+		}
+		content := strings.TrimSpace(buf.String())
+		if content == "" {
+			continue
+		}
+		rendered.Messages = append(rendered.Messages, Message{Role: section.role, Content: content})
+	}
+	if len(rendered.Messages) == 0 {
+		return nil, fmt.Errorf("prompt template %s rendered empty", tpl.Name)
+	}
+	return rendered, nil
+}
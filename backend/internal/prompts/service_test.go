@@ -0,0 +1,32 @@
+package prompts
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tpl     Template
+		wantErr bool
+	}{
+		{"valid", Template{Name: SQLGeneration, User: "{{.Query}}"}, false},
+		{"missing user", Template{Name: SQLGeneration, System: "x"}, true},
+		{"parse error", Template{Name: SQLGeneration, User: "{{.Query"}, true},
+		{"unknown field", Template{Name: SQLGeneration, User: "{{.Qeury}}"}, true},
+		{"unknown field in branch", Template{Name: SQLGeneration, User: "{{if .Memory}}{{.Memroy}}{{end}}{{.Query}}"}, true},
+		{"field of another template", Template{Name: Debug, User: "{{.Query}}"}, true},
+		{"unknown name parses only", Template{Name: "custom", User: "{{.Anything}}"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(&tt.tpl); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuiltinsValidate(t *testing.T) {
+	if _, err := NewService(nil); err != nil {
+		t.Fatal(err)
+	}
+}
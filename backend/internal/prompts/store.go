@@ -0,0 +1,265 @@
+package prompts
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store persists prompt template versions in the app database.
+type Store struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewStore creates the prompt template store and ensures its table exists.
+func NewStore(db *sql.DB, driver string) (*Store, error) {
+	if db == nil {
+		return nil, errors.New("prompt store requires db handle")
+	}
+	s := &Store{db: db, driver: driver}
+	if err := s.ensureTable(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) ensureTable() error {
+	switch s.driver {
+	case "mysql":
+		const ddl = `CREATE TABLE IF NOT EXISTS prompt_templates (
+            id CHAR(36) PRIMARY KEY,
+            name VARCHAR(64) NOT NULL,
+            datasource VARCHAR(128) NOT NULL,
+            version INT NOT NULL,
+            description TEXT,
+            system_text LONGTEXT,
+            context_text LONGTEXT,
+            user_text LONGTEXT,
+            is_active TINYINT(1) NOT NULL DEFAULT 0,
+            created_by VARCHAR(64),
+            created_at DATETIME NOT NULL,
+            UNIQUE KEY idx_prompt_version (name, datasource, version)
+        ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`
+		_, err := s.db.Exec(ddl)
+		return err
+	case "oracle":
+		const check = `SELECT COUNT(*) FROM USER_TABLES WHERE TABLE_NAME = 'PROMPT_TEMPLATES'`
+		var count int
+		if err := s.db.QueryRow(check).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		const ddl = `CREATE TABLE PROMPT_TEMPLATES (
+            ID VARCHAR2(36) PRIMARY KEY,
+            NAME VARCHAR2(64) NOT NULL,
+            DATASOURCE VARCHAR2(128) NOT NULL,
+            VERSION NUMBER NOT NULL,
+            DESCRIPTION CLOB,
+            SYSTEM_TEXT CLOB,
+            CONTEXT_TEXT CLOB,
+            USER_TEXT CLOB,
+            IS_ACTIVE NUMBER(1) DEFAULT 0 NOT NULL,
+            CREATED_BY VARCHAR2(64),
+            CREATED_AT TIMESTAMP NOT NULL
+        )`
+		if _, err := s.db.Exec(ddl); err != nil {
+			return err
+		}
+		_, err := s.db.Exec(`CREATE UNIQUE INDEX IDX_PROMPT_VERSION ON PROMPT_TEMPLATES (NAME, DATASOURCE, VERSION)`)
+		return err
+	default:
+		return fmt.Errorf("unsupported driver %s", s.driver)
+	}
+}
+
+const selectColumns = `id, name, datasource, version, description, system_text, context_text, user_text, is_active, created_by, created_at`
+
+func scanTemplate(scanner interface{ Scan(...interface{}) error }) (*Template, error) {
+	tpl := &Template{}
+	var description, system, context, user, createdBy sql.NullString
+	var active int
+	if err := scanner.Scan(&tpl.ID, &tpl.Name, &tpl.Datasource, &tpl.Version, &description, &system, &context, &user, &active, &createdBy, &tpl.CreatedAt); err != nil {
+		return nil, err
+	}
+	tpl.Description = description.String
+	tpl.System = system.String
+	tpl.Context = context.String
+	tpl.User = user.String
+	tpl.Active = active == 1
+	tpl.CreatedBy = createdBy.String
+	return tpl, nil
+}
+
+// List returns all stored versions, newest first. An empty name lists every template.
+func (s *Store) List(name string) ([]*Template, error) {
+	query := `SELECT ` + selectColumns + ` FROM prompt_templates`
+	if s.driver == "oracle" {
+		query = `SELECT ` + selectColumns + ` FROM PROMPT_TEMPLATES`
+	}
+	var args []interface{}
+	if name != "" {
+		if s.driver == "oracle" {
+			query += ` WHERE name = :1`
+		} else {
+			query += ` WHERE name = ?`
+		}
+		args = append(args, name)
+	}
+	query += ` ORDER BY name, datasource, version DESC`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []*Template
+	for rows.Next() {
+		tpl, err := scanTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, tpl)
+	}
+	return result, rows.Err()
+}
+
+// Get returns one stored version by ID.
+func (s *Store) Get(id string) (*Template, error) {
+	query := `SELECT ` + selectColumns + ` FROM prompt_templates WHERE id = ?`
+	if s.driver == "oracle" {
+		query = `SELECT ` + selectColumns + ` FROM PROMPT_TEMPLATES WHERE id = :1`
+	}
+	tpl, err := scanTemplate(s.db.QueryRow(query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("prompt template not found")
+	}
+	return tpl, err
+}
+
+// GetActive returns the active version for name/datasource, or nil when none is active.
+func (s *Store) GetActive(name, datasource string) (*Template, error) {
+	query := `SELECT ` + selectColumns + ` FROM prompt_templates
+        WHERE name = ? AND datasource = ? AND is_active = 1
+        ORDER BY version DESC LIMIT 1`
+	if s.driver == "oracle" {
+		query = `SELECT ` + selectColumns + ` FROM (
+            SELECT ` + selectColumns + ` FROM PROMPT_TEMPLATES
+            WHERE name = :1 AND datasource = :2 AND is_active = 1
+            ORDER BY version DESC
+        ) WHERE ROWNUM = 1`
+	}
+	tpl, err := scanTemplate(s.db.QueryRow(query, name, datasource))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return tpl, err
+}
+
+// Insert stores tpl as the next version for its name/datasource. When
+// activate is true it becomes the only active version.
+func (s *Store) Insert(tpl *Template, activate bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	maxQuery := `SELECT COALESCE(MAX(version), 0) FROM prompt_templates WHERE name = ? AND datasource = ?`
+	if s.driver == "oracle" {
+		maxQuery = `SELECT COALESCE(MAX(version), 0) FROM PROMPT_TEMPLATES WHERE name = :1 AND datasource = :2`
+	}
+	var current int
+	if err := tx.QueryRow(maxQuery, tpl.Name, tpl.Datasource).Scan(&current); err != nil {
+		return err
+	}
+
+	tpl.ID = uuid.New().String()
+	tpl.Version = current + 1
+	tpl.CreatedAt = time.Now()
+	tpl.Active = activate
+
+	if activate {
+		if err := deactivate(tx, s.driver, tpl.Name, tpl.Datasource); err != nil {
+			return err
+		}
+	}
+
+	if s.driver == "oracle" {
+		_, err = tx.Exec(`INSERT INTO PROMPT_TEMPLATES
+            (ID, NAME, DATASOURCE, VERSION, DESCRIPTION, SYSTEM_TEXT, CONTEXT_TEXT, USER_TEXT, IS_ACTIVE, CREATED_BY, CREATED_AT)
+            VALUES (:1, :2, :3, :4, :5, :6, :7, :8, :9, :10, :11)`,
+			tpl.ID, tpl.Name, tpl.Datasource, tpl.Version, tpl.Description, tpl.System, tpl.Context, tpl.User, boolToInt(activate), tpl.CreatedBy, tpl.CreatedAt)
+	} else {
+		_, err = tx.Exec(`INSERT INTO prompt_templates
+            (id, name, datasource, version, description, system_text, context_text, user_text, is_active, created_by, created_at)
+            VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			tpl.ID, tpl.Name, tpl.Datasource, tpl.Version, tpl.Description, tpl.System, tpl.Context, tpl.User, boolToInt(activate), tpl.CreatedBy, tpl.CreatedAt)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Activate makes the version with id the only active one for its name/datasource.
+func (s *Store) Activate(id string) (*Template, error) {
+	tpl, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if err := deactivate(tx, s.driver, tpl.Name, tpl.Datasource); err != nil {
+		return nil, err
+	}
+	if s.driver == "oracle" {
+		_, err = tx.Exec(`UPDATE PROMPT_TEMPLATES SET IS_ACTIVE = 1 WHERE ID = :1`, id)
+	} else {
+		_, err = tx.Exec(`UPDATE prompt_templates SET is_active = 1 WHERE id = ?`, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tpl.Active = true
+	return tpl, tx.Commit()
+}
+
+// Deactivate turns off every stored version for name/datasource so the
+// built-in template applies again.
+func (s *Store) Deactivate(name, datasource string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := deactivate(tx, s.driver, name, datasource); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func deactivate(tx *sql.Tx, driver, name, datasource string) error {
+	if driver == "oracle" {
+		_, err := tx.Exec(`UPDATE PROMPT_TEMPLATES SET IS_ACTIVE = 0 WHERE NAME = :1 AND DATASOURCE = :2`, name, datasource)
+		return err
+	}
+	_, err := tx.Exec(`UPDATE prompt_templates SET is_active = 0 WHERE name = ? AND datasource = ?`, name, datasource)
+	return err
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,101 @@
+// Package results buffers executed query results in memory so they can be
+// regrouped, pivoted, sorted and filtered without querying Oracle again.
+package results
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+// ErrNotFound is returned for unknown, expired or other users' executions.
+var ErrNotFound = errors.New("execution not found or expired")
+
+// Entry is one buffered execution.
+type Entry struct {
+	ID       string
+	UserID   string
+	SQL      string
+	Result   *models.SQLExecuteResponse
+	StoredAt time.Time
+}
+
+// Cache keeps the most recent executions for ttl, up to maxEntries, evicting
+// the oldest first.
+type Cache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	items      map[string]*Entry
+}
+
+// NewCache creates a cache; a non-positive maxEntries disables it.
+func NewCache(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{ttl: ttl, maxEntries: maxEntries, items: make(map[string]*Entry)}
+}
+
+// Put buffers result for userID and returns its execution ID, or "" when the
+// cache is disabled. The stored copy shares the rows, which are not modified
+// afterwards, but not the chart.
+func (c *Cache) Put(userID, sql string, result *models.SQLExecuteResponse) string {
+	if c == nil || c.maxEntries <= 0 || result == nil {
+		return ""
+	}
+	stored := *result
+	stored.Chart = nil
+	entry := &Entry{ID: uuid.New().String(), UserID: userID, SQL: sql, Result: &stored, StoredAt: time.Now()}
+	stored.ExecutionID = entry.ID
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.expireLocked()
+	for len(c.items) >= c.maxEntries {
+		var oldest *Entry
+		for _, e := range c.items {
+			if oldest == nil || e.StoredAt.Before(oldest.StoredAt) {
+				oldest = e
+			}
+		}
+		delete(c.items, oldest.ID)
+	}
+	c.items[entry.ID] = entry
+	return entry.ID
+}
+
+// Enabled reports whether Put buffers results.
+func (c *Cache) Enabled() bool {
+	return c != nil && c.maxEntries > 0
+}
+
+// Get returns userID's execution id.
+func (c *Cache) Get(userID, id string) (*Entry, error) {
+	if c == nil {
+		return nil, ErrNotFound
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.items[id]
+	if !ok || entry.UserID != userID {
+		return nil, ErrNotFound
+	}
+	if c.ttl > 0 && time.Since(entry.StoredAt) > c.ttl {
+		delete(c.items, id)
+		return nil, ErrNotFound
+	}
+	return entry, nil
+}
+
+func (c *Cache) expireLocked() {
+	if c.ttl <= 0 {
+		return
+	}
+	for id, e := range c.items {
+		if time.Since(e.StoredAt) > c.ttl {
+			delete(c.items, id)
+		}
+	}
+}
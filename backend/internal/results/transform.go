@@ -0,0 +1,571 @@
+package results
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yourusername/db_asst/internal/cellvalue"
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+// MaxPivotColumns caps the distinct values a pivot may turn into columns.
+const MaxPivotColumns = 100
+
+// ErrPartialSource is returned for aggregating operations over a source that
+// holds only some of its rows, whose totals would silently be those of the
+// rows held.
+var ErrPartialSource = errors.New("the result has more rows than were fetched; aggregate in SQL instead")
+
+// Apply runs ops over result in order and returns a new, unpaged result.
+// The source result is not modified. Aggregates over masked columns stay
+// masked, except counts. group_by and pivot need all of the source's rows;
+// over a partial source (more rows, or a page after the first) they fail
+// with ErrPartialSource.
+func Apply(result *models.SQLExecuteResponse, ops []models.TransformOp) (*models.SQLExecuteResponse, error) {
+	t := &table{
+		columns: append([]string(nil), result.Columns...),
+		rows:    append([][]interface{}(nil), result.Rows...),
+		masked:  make(map[string]bool),
+	}
+	for _, name := range result.MaskedColumns {
+		t.masked[strings.ToUpper(name)] = true
+	}
+	partial := result.HasMore || result.Partial || result.Page > 1
+	for i, op := range ops {
+		var err error
+		switch strings.ToLower(strings.TrimSpace(op.Op)) {
+		case "filter":
+			err = t.filter(op)
+		case "group_by", "groupby", "group":
+			if partial {
+				err = ErrPartialSource
+			} else {
+				err = t.groupBy(op)
+			}
+		case "pivot":
+			if partial {
+				err = ErrPartialSource
+			} else {
+				err = t.pivot(op)
+			}
+		case "sort", "order_by":
+			err = t.sort(op)
+		case "limit":
+			if op.Limit <= 0 {
+				err = fmt.Errorf("limit must be positive")
+			} else if op.Limit < len(t.rows) {
+				t.rows = t.rows[:op.Limit]
+			}
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Op)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i+1, op.Op, err)
+		}
+	}
+
+	out := &models.SQLExecuteResponse{
+		Success:  true,
+		Columns:  t.columns,
+		Rows:     t.rows,
+		RowCount: len(t.rows),
+		Partial:  partial,
+	}
+	for _, name := range t.columns {
+		if t.masked[strings.ToUpper(name)] {
+			out.MaskedColumns = append(out.MaskedColumns, name)
+		}
+	}
+	return out, nil
+}
+
+type table struct {
+	columns []string
+	rows    [][]interface{}
+	masked  map[string]bool
+}
+
+// index finds a column case-insensitively, since Oracle reports upper case.
+func (t *table) index(name string) (int, error) {
+	name = strings.TrimSpace(name)
+	for i, col := range t.columns {
+		if strings.EqualFold(col, name) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("unknown column %q", name)
+}
+
+func (t *table) indexes(names []string) ([]int, error) {
+	idx := make([]int, len(names))
+	for i, name := range names {
+		var err error
+		if idx[i], err = t.index(name); err != nil {
+			return nil, err
+		}
+	}
+	return idx, nil
+}
+
+func (t *table) filter(op models.TransformOp) error {
+	col, err := t.index(op.Column)
+	if err != nil {
+		return err
+	}
+	match, err := predicate(strings.ToLower(strings.TrimSpace(op.Operator)), op.Value)
+	if err != nil {
+		return err
+	}
+	kept := t.rows[:0:0]
+	for _, row := range t.rows {
+		if match(cell(row, col)) {
+			kept = append(kept, row)
+		}
+	}
+	t.rows = kept
+	return nil
+}
+
+func predicate(operator string, value interface{}) (func(interface{}) bool, error) {
+	switch operator {
+	case "=", "==", "eq":
+		return func(v interface{}) bool { return v != nil && compare(v, value) == 0 }, nil
+	case "!=", "<>", "ne":
+		return func(v interface{}) bool { return v != nil && compare(v, value) != 0 }, nil
+	case ">", "gt":
+		return func(v interface{}) bool { return v != nil && compare(v, value) > 0 }, nil
+	case ">=", "gte":
+		return func(v interface{}) bool { return v != nil && compare(v, value) >= 0 }, nil
+	case "<", "lt":
+		return func(v interface{}) bool { return v != nil && compare(v, value) < 0 }, nil
+	case "<=", "lte":
+		return func(v interface{}) bool { return v != nil && compare(v, value) <= 0 }, nil
+	case "in", "not_in":
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s needs a list value", operator)
+		}
+		want := operator == "in"
+		return func(v interface{}) bool {
+			if v == nil {
+				return false
+			}
+			for _, item := range list {
+				if compare(v, item) == 0 {
+					return want
+				}
+			}
+			return !want
+		}, nil
+	case "contains", "starts_with":
+		needle := strings.ToLower(text(value))
+		return func(v interface{}) bool {
+			if v == nil {
+				return false
+			}
+			s := strings.ToLower(text(v))
+			if operator == "contains" {
+				return strings.Contains(s, needle)
+			}
+			return strings.HasPrefix(s, needle)
+		}, nil
+	case "is_null":
+		return func(v interface{}) bool { return v == nil }, nil
+	case "not_null":
+		return func(v interface{}) bool { return v != nil }, nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", operator)
+}
+
+func (t *table) groupBy(op models.TransformOp) error {
+	keys, err := t.indexes(op.Columns)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 && len(op.Aggregates) == 0 {
+		return fmt.Errorf("group_by needs columns or aggregates")
+	}
+	aggCols := make([]int, len(op.Aggregates))
+	funcs := make([]string, len(op.Aggregates))
+	columns := make([]string, 0, len(keys)+len(op.Aggregates))
+	masked := make(map[string]bool)
+	for _, k := range keys {
+		columns = append(columns, t.columns[k])
+		masked[strings.ToUpper(t.columns[k])] = t.masked[strings.ToUpper(t.columns[k])]
+	}
+	for i, agg := range op.Aggregates {
+		if funcs[i], err = aggregateFunc(agg.Func); err != nil {
+			return err
+		}
+		aggCols[i] = -1
+		source := "*"
+		if c := strings.TrimSpace(agg.Column); c != "" && c != "*" {
+			if aggCols[i], err = t.index(c); err != nil {
+				return err
+			}
+			source = t.columns[aggCols[i]]
+		} else if funcs[i] != "count" {
+			return fmt.Errorf("%s needs a column", funcs[i])
+		}
+		name := strings.TrimSpace(agg.As)
+		if name == "" && source == "*" {
+			name = "COUNT"
+		} else if name == "" {
+			name = strings.ToUpper(funcs[i]) + "_" + source
+		}
+		columns = append(columns, name)
+		masked[strings.ToUpper(name)] = aggCols[i] >= 0 && t.masked[strings.ToUpper(source)] && !strings.HasPrefix(funcs[i], "count")
+	}
+
+	type group struct {
+		key  []interface{}
+		accs []*accumulator
+	}
+	var order []*group
+	groups := make(map[string]*group)
+	for _, row := range t.rows {
+		key := make([]interface{}, len(keys))
+		for i, k := range keys {
+			key[i] = cell(row, k)
+		}
+		id := groupKey(key)
+		g, ok := groups[id]
+		if !ok {
+			g = &group{key: key, accs: make([]*accumulator, len(funcs))}
+			for i, fn := range funcs {
+				g.accs[i] = newAccumulator(fn)
+			}
+			groups[id] = g
+			order = append(order, g)
+		}
+		for i, acc := range g.accs {
+			if aggCols[i] < 0 {
+				acc.addRow()
+			} else {
+				acc.add(cell(row, aggCols[i]))
+			}
+		}
+	}
+	// A global aggregate over no rows still yields one row, as in SQL.
+	if len(keys) == 0 && len(order) == 0 {
+		g := &group{accs: make([]*accumulator, len(funcs))}
+		for i, fn := range funcs {
+			g.accs[i] = newAccumulator(fn)
+		}
+		order = append(order, g)
+	}
+
+	rows := make([][]interface{}, len(order))
+	for i, g := range order {
+		row := append(make([]interface{}, 0, len(columns)), g.key...)
+		for _, acc := range g.accs {
+			row = append(row, acc.result())
+		}
+		rows[i] = row
+	}
+	t.columns, t.rows, t.masked = columns, rows, masked
+	return nil
+}
+
+func (t *table) pivot(op models.TransformOp) error {
+	index, err := t.indexes(op.Index)
+	if err != nil {
+		return err
+	}
+	pivotCol, err := t.index(op.Column)
+	if err != nil {
+		return err
+	}
+	fn := "sum"
+	if strings.TrimSpace(op.Func) != "" {
+		if fn, err = aggregateFunc(op.Func); err != nil {
+			return err
+		}
+	}
+	valueCol := -1
+	if strings.TrimSpace(op.Values) != "" {
+		if valueCol, err = t.index(op.Values); err != nil {
+			return err
+		}
+	} else if fn != "count" {
+		return fmt.Errorf("pivot with %s needs values", fn)
+	}
+
+	var pivotValues []interface{}
+	seen := make(map[string]int)
+	for _, row := range t.rows {
+		v := cell(row, pivotCol)
+		id := groupKey([]interface{}{v})
+		if _, ok := seen[id]; !ok {
+			if len(pivotValues) == MaxPivotColumns {
+				return fmt.Errorf("column %q has more than %d distinct values", t.columns[pivotCol], MaxPivotColumns)
+			}
+			seen[id] = len(pivotValues)
+			pivotValues = append(pivotValues, v)
+		}
+	}
+	sort.SliceStable(pivotValues, func(i, j int) bool { return compare(pivotValues[i], pivotValues[j]) < 0 })
+	for i, v := range pivotValues {
+		seen[groupKey([]interface{}{v})] = i
+	}
+
+	columns := make([]string, 0, len(index)+len(pivotValues))
+	masked := make(map[string]bool)
+	used := make(map[string]bool)
+	for _, k := range index {
+		columns = append(columns, t.columns[k])
+		masked[strings.ToUpper(t.columns[k])] = t.masked[strings.ToUpper(t.columns[k])]
+		used[strings.ToUpper(t.columns[k])] = true
+	}
+	valueMasked := valueCol >= 0 && t.masked[strings.ToUpper(t.columns[valueCol])] && !strings.HasPrefix(fn, "count")
+	for _, v := range pivotValues {
+		name := "NULL"
+		if v != nil {
+			name = text(v)
+		}
+		name = uniqueName(name, used)
+		columns = append(columns, name)
+		masked[strings.ToUpper(name)] = valueMasked || t.masked[strings.ToUpper(t.columns[pivotCol])]
+	}
+
+	type group struct {
+		key  []interface{}
+		accs []*accumulator
+	}
+	var order []*group
+	groups := make(map[string]*group)
+	for _, row := range t.rows {
+		key := make([]interface{}, len(index))
+		for i, k := range index {
+			key[i] = cell(row, k)
+		}
+		id := groupKey(key)
+		g, ok := groups[id]
+		if !ok {
+			g = &group{key: key, accs: make([]*accumulator, len(pivotValues))}
+			groups[id] = g
+			order = append(order, g)
+		}
+		slot := seen[groupKey([]interface{}{cell(row, pivotCol)})]
+		if g.accs[slot] == nil {
+			g.accs[slot] = newAccumulator(fn)
+		}
+		if valueCol < 0 {
+			g.accs[slot].addRow()
+		} else {
+			g.accs[slot].add(cell(row, valueCol))
+		}
+	}
+
+	rows := make([][]interface{}, len(order))
+	for i, g := range order {
+		row := append(make([]interface{}, 0, len(columns)), g.key...)
+		for _, acc := range g.accs {
+			if acc == nil {
+				row = append(row, nil)
+			} else {
+				row = append(row, acc.result())
+			}
+		}
+		rows[i] = row
+	}
+	t.columns, t.rows, t.masked = columns, rows, masked
+	return nil
+}
+
+// uniqueName returns name, or name with the first free "_2", "_3", ...
+// suffix when a column of that name (case-insensitively, like index) is
+// already in used, and marks the result as used. Pivot values can collide
+// with each other (SQL NULL and the text "NULL", 1 and "1") or with an index
+// column.
+func uniqueName(name string, used map[string]bool) string {
+	unique := name
+	for n := 2; used[strings.ToUpper(unique)]; n++ {
+		unique = name + "_" + strconv.Itoa(n)
+	}
+	used[strings.ToUpper(unique)] = true
+	return unique
+}
+
+func (t *table) sort(op models.TransformOp) error {
+	keys := op.Sort
+	if len(keys) == 0 && strings.TrimSpace(op.Column) != "" {
+		keys = []models.TransformSort{{Column: op.Column}}
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("sort needs at least one key")
+	}
+	cols := make([]int, len(keys))
+	for i, key := range keys {
+		var err error
+		if cols[i], err = t.index(key.Column); err != nil {
+			return err
+		}
+	}
+	rows := append([][]interface{}(nil), t.rows...)
+	sort.SliceStable(rows, func(i, j int) bool {
+		for k, col := range cols {
+			c := compare(cell(rows[i], col), cell(rows[j], col))
+			if c == 0 {
+				continue
+			}
+			if keys[k].Desc {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+	t.rows = rows
+	return nil
+}
+
+func aggregateFunc(name string) (string, error) {
+	fn := strings.ToLower(strings.TrimSpace(name))
+	switch fn {
+	case "count", "count_distinct", "sum", "avg", "min", "max":
+		return fn, nil
+	case "mean":
+		return "avg", nil
+	case "":
+		return "", fmt.Errorf("aggregate function is required")
+	}
+	return "", fmt.Errorf("unknown aggregate function %q", name)
+}
+
+// accumulator folds one group's values for an aggregate function. Like SQL,
+// it ignores NULLs, and sum and avg of no numbers are NULL.
+type accumulator struct {
+	fn       string
+	count    int
+	numbers  int
+	sum      float64
+	min, max interface{}
+	distinct map[string]bool
+}
+
+func newAccumulator(fn string) *accumulator {
+	acc := &accumulator{fn: fn}
+	if fn == "count_distinct" {
+		acc.distinct = make(map[string]bool)
+	}
+	return acc
+}
+
+// addRow counts a row for COUNT(*).
+func (a *accumulator) addRow() { a.count++ }
+
+func (a *accumulator) add(v interface{}) {
+	if v == nil {
+		return
+	}
+	a.count++
+	if a.distinct != nil {
+		a.distinct[groupKey([]interface{}{v})] = true
+	}
+	if n, ok := cellvalue.Number(v); ok {
+		a.numbers++
+		a.sum += n
+	}
+	if a.min == nil || compare(v, a.min) < 0 {
+		a.min = v
+	}
+	if a.max == nil || compare(v, a.max) > 0 {
+		a.max = v
+	}
+}
+
+func (a *accumulator) result() interface{} {
+	switch a.fn {
+	case "count":
+		return a.count
+	case "count_distinct":
+		return len(a.distinct)
+	case "sum":
+		if a.numbers == 0 {
+			return nil
+		}
+		return a.sum
+	case "avg":
+		if a.numbers == 0 {
+			return nil
+		}
+		return a.sum / float64(a.numbers)
+	case "min":
+		return a.min
+	case "max":
+		return a.max
+	}
+	return nil
+}
+
+func cell(row []interface{}, i int) interface{} {
+	if i < 0 || i >= len(row) {
+		return nil
+	}
+	return row[i]
+}
+
+// groupKey identifies a tuple of values; numbers compare by value so 1 and
+// "1" from different drivers land in the same group.
+func groupKey(values []interface{}) string {
+	var b strings.Builder
+	for _, v := range values {
+		switch {
+		case v == nil:
+			b.WriteString("\x01")
+		default:
+			if n, ok := cellvalue.Number(v); ok {
+				b.WriteString("n" + strconv.FormatFloat(n, 'g', -1, 64))
+			} else {
+				b.WriteString("s" + text(v))
+			}
+		}
+		b.WriteString("\x00")
+	}
+	return b.String()
+}
+
+// compare orders values with NULLs first, numbers numerically, times
+// chronologically and anything else by its text.
+func compare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+	if x, ok := cellvalue.Number(a); ok {
+		if y, ok := cellvalue.Number(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	if x, ok := a.(time.Time); ok {
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+	return strings.Compare(text(a), text(b))
+}
+
+func text(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case time.Time:
+		return s.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
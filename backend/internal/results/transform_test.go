@@ -0,0 +1,148 @@
+package results
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/yourusername/db_asst/internal/models"
+)
+
+func salesResult() *models.SQLExecuteResponse {
+	return &models.SQLExecuteResponse{
+		Success: true,
+		Columns: []string{"REGION", "PRODUCT", "AMOUNT", "OWNER"},
+		Rows: [][]interface{}{
+			{"north", "apple", 10, "****"},
+			{"north", "pear", "5", "****"},
+			{"south", "apple", 7.5, "****"},
+			{"south", "apple", nil, "****"},
+			{"east", "pear", 2, "****"},
+		},
+		MaskedColumns: []string{"OWNER"},
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		ops     []models.TransformOp
+		columns []string
+		rows    [][]interface{}
+		masked  []string
+	}{
+		{
+			name:    "filter and sort",
+			ops:     []models.TransformOp{{Op: "filter", Column: "product", Operator: "=", Value: "apple"}, {Op: "sort", Sort: []models.TransformSort{{Column: "AMOUNT", Desc: true}}}},
+			columns: []string{"REGION", "PRODUCT", "AMOUNT", "OWNER"},
+			rows:    [][]interface{}{{"north", "apple", 10, "****"}, {"south", "apple", 7.5, "****"}, {"south", "apple", nil, "****"}},
+			masked:  []string{"OWNER"},
+		},
+		{
+			name: "group by ignores NULLs like SQL",
+			ops: []models.TransformOp{{Op: "group_by", Columns: []string{"REGION"}, Aggregates: []models.TransformAgg{
+				{Func: "count"}, {Column: "AMOUNT", Func: "sum"}, {Column: "AMOUNT", Func: "avg", As: "MEAN"},
+			}}},
+			columns: []string{"REGION", "COUNT", "SUM_AMOUNT", "MEAN"},
+			rows:    [][]interface{}{{"north", 2, 15.0, 7.5}, {"south", 2, 7.5, 7.5}, {"east", 1, 2.0, 2.0}},
+		},
+		{
+			name: "aggregates of masked columns stay masked except counts",
+			ops: []models.TransformOp{{Op: "group_by", Aggregates: []models.TransformAgg{
+				{Column: "OWNER", Func: "max"}, {Column: "OWNER", Func: "count_distinct"},
+			}}},
+			columns: []string{"MAX_OWNER", "COUNT_DISTINCT_OWNER"},
+			rows:    [][]interface{}{{"****", 1}},
+			masked:  []string{"MAX_OWNER"},
+		},
+		{
+			name:    "pivot",
+			ops:     []models.TransformOp{{Op: "pivot", Index: []string{"REGION"}, Column: "PRODUCT", Values: "AMOUNT"}},
+			columns: []string{"REGION", "apple", "pear"},
+			rows:    [][]interface{}{{"north", 10.0, 5.0}, {"south", 7.5, nil}, {"east", nil, 2.0}},
+		},
+		{
+			name:    "limit",
+			ops:     []models.TransformOp{{Op: "limit", Limit: 2}},
+			columns: []string{"REGION", "PRODUCT", "AMOUNT", "OWNER"},
+			rows:    [][]interface{}{{"north", "apple", 10, "****"}, {"north", "pear", "5", "****"}},
+			masked:  []string{"OWNER"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := salesResult()
+			out, err := Apply(source, tt.ops)
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !reflect.DeepEqual(out.Columns, tt.columns) {
+				t.Errorf("Columns = %v, want %v", out.Columns, tt.columns)
+			}
+			if !reflect.DeepEqual(out.Rows, tt.rows) {
+				t.Errorf("Rows = %v, want %v", out.Rows, tt.rows)
+			}
+			if !reflect.DeepEqual(out.MaskedColumns, tt.masked) {
+				t.Errorf("MaskedColumns = %v, want %v", out.MaskedColumns, tt.masked)
+			}
+			if out.Partial {
+				t.Error("a complete source gave a partial result")
+			}
+			if !reflect.DeepEqual(source, salesResult()) {
+				t.Error("the source was modified")
+			}
+		})
+	}
+}
+
+func TestApplyPivotColumnNames(t *testing.T) {
+	source := &models.SQLExecuteResponse{
+		Columns: []string{"DEPT", "K", "V"},
+		Rows:    [][]interface{}{{"a", nil, 1}, {"a", "NULL", 2}, {"a", "dept", 3}, {"b", "x", 4}},
+	}
+	out, err := Apply(source, []models.TransformOp{{Op: "pivot", Index: []string{"DEPT"}, Column: "K", Values: "V"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"DEPT", "NULL", "NULL_2", "dept_2", "x"}
+	if !reflect.DeepEqual(out.Columns, want) {
+		t.Errorf("Columns = %v, want %v", out.Columns, want)
+	}
+}
+
+func TestApplyPartialSource(t *testing.T) {
+	groupBy := []models.TransformOp{{Op: "group_by", Columns: []string{"REGION"}}}
+	pivot := []models.TransformOp{{Op: "pivot", Index: []string{"REGION"}, Column: "PRODUCT", Func: "count"}}
+	for name, mark := range map[string]func(*models.SQLExecuteResponse){
+		"has more":   func(r *models.SQLExecuteResponse) { r.HasMore = true },
+		"later page": func(r *models.SQLExecuteResponse) { r.Page = 3 },
+		"partial":    func(r *models.SQLExecuteResponse) { r.Partial = true },
+	} {
+		source := salesResult()
+		mark(source)
+		for _, ops := range [][]models.TransformOp{groupBy, pivot} {
+			if _, err := Apply(source, ops); !errors.Is(err, ErrPartialSource) {
+				t.Errorf("%s, %s: err = %v, want ErrPartialSource", name, ops[0].Op, err)
+			}
+		}
+		out, err := Apply(source, []models.TransformOp{{Op: "sort", Column: "AMOUNT"}})
+		if err != nil || !out.Partial {
+			t.Errorf("%s: sort = %v, partial %v; want a partial result", name, err, out != nil && out.Partial)
+		}
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	for _, op := range []models.TransformOp{
+		{Op: "explode"},
+		{Op: "filter", Column: "MISSING", Operator: "=", Value: 1},
+		{Op: "group_by"},
+		{Op: "group_by", Aggregates: []models.TransformAgg{{Column: "AMOUNT", Func: "median"}}},
+		{Op: "pivot", Index: []string{"REGION"}, Column: "PRODUCT"},
+		{Op: "limit"},
+	} {
+		if _, err := Apply(salesResult(), []models.TransformOp{op}); err == nil {
+			t.Errorf("%+v: no error", op)
+		}
+	}
+}
@@ -0,0 +1,695 @@
+// Package sqlparse is a lightweight Oracle SQL scanner. It does not build a
+// full syntax tree; it finds the tables and columns a statement refers to
+// and flags risky patterns, which is enough to annotate and explain SQL.
+package sqlparse
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TableRef is a table in a FROM or JOIN clause. Name is upper-cased unless
+// it was quoted and may carry an owner ("HR.EMPLOYEES").
+type TableRef struct {
+	Name  string `json:"name"`
+	Alias string `json:"alias,omitempty"`
+}
+
+// ColumnRef is a possible column reference. Qualifier is the table, alias or
+// owner.table before the column name, if any. Unqualified names include
+// anything that looks like an identifier, so callers should resolve them
+// against the catalog.
+type ColumnRef struct {
+	Qualifier string `json:"qualifier,omitempty"`
+	Name      string `json:"name"`
+}
+
+// Finding is a risky pattern found in the statement.
+type Finding struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Finding codes.
+const (
+	FindingModifiesData    = "modifies_data"
+	FindingNoWhere         = "no_where_clause"
+	FindingCartesian       = "cartesian_product"
+	FindingMissingJoin     = "missing_join_condition"
+	FindingSelectStar      = "select_star"
+	FindingNotInSubquery   = "not_in_subquery"
+	FindingLeadingWildcard = "leading_wildcard"
+	FindingMixedAndOr      = "mixed_and_or"
+	FindingRownumOrder     = "rownum_before_order_by"
+	FindingMultipleStmts   = "multiple_statements"
+)
+
+// Statement is what Parse found in one SQL text.
+type Statement struct {
+	// Type is the leading keyword: SELECT, WITH, UPDATE, ...
+	Type     string      `json:"type"`
+	Tables   []TableRef  `json:"tables"`
+	Columns  []ColumnRef `json:"columns"`
+	Findings []Finding   `json:"findings"`
+}
+
+// IsQuery reports whether the statement only reads data.
+func (s *Statement) IsQuery() bool {
+	return s.Type == "SELECT" || s.Type == "WITH" || s.Type == "("
+}
+
+type tokenKind int
+
+const (
+	tokWord   tokenKind = iota // identifier or keyword, possibly dotted
+	tokString                  // '...' literal
+	tokNumber
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string // words upper-cased except quoted parts, which keep their quotes
+	// quoted is true when any part of a word was a quoted identifier.
+	quoted bool
+}
+
+func (t token) is(text string) bool {
+	return (t.kind == tokWord && !t.quoted || t.kind == tokSymbol) && t.text == text
+}
+
+// tokenize splits sql into tokens, dropping comments. Dotted names such as
+// o.ID or "hr"."emp" become a single word token.
+func tokenize(sql string) []token {
+	var tokens []token
+	src := []rune(sql)
+	n := len(src)
+	for i := 0; i < n; {
+		r := src[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			i++
+		case r == '-' && i+1 < n && src[i+1] == '-':
+			for i < n && src[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < n && src[i+1] == '*':
+			i += 2
+			for i+1 < n && !(src[i] == '*' && src[i+1] == '/') {
+				i++
+			}
+			i += 2
+		case r == '\'':
+			start := i
+			i++
+			for i < n {
+				if src[i] == '\'' {
+					if i+1 < n && src[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			i++
+			if i > n {
+				i = n
+			}
+			tokens = append(tokens, token{kind: tokString, text: string(src[start:i])})
+		case r >= '0' && r <= '9':
+			start := i
+			for i < n && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, text: string(src[start:i])})
+		case isIdentStart(r) || r == '"':
+			var parts []string
+			quoted := false
+			for {
+				part, next, q := readIdentPart(src, i)
+				if q {
+					quoted = true
+				}
+				parts = append(parts, part)
+				i = next
+				if i+1 < n && src[i] == '.' && (isIdentStart(src[i+1]) || src[i+1] == '"' || src[i+1] == '*') {
+					if src[i+1] == '*' {
+						parts = append(parts, "*")
+						i += 2
+						break
+					}
+					i++
+					continue
+				}
+				break
+			}
+			tokens = append(tokens, token{kind: tokWord, text: strings.Join(parts, "."), quoted: quoted})
+		default:
+			text := string(r)
+			if i+1 < n {
+				switch pair := string(src[i : i+2]); pair {
+				case "<=", ">=", "<>", "!=", "||":
+					text = pair
+				}
+			}
+			i += len([]rune(text))
+			tokens = append(tokens, token{kind: tokSymbol, text: text})
+		}
+	}
+	return tokens
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 127
+}
+
+func isIdentRune(r rune) bool {
+	return isIdentStart(r) || r >= '0' && r <= '9' || r == '$' || r == '#'
+}
+
+// readIdentPart reads one plain or quoted identifier starting at i.
+func readIdentPart(src []rune, i int) (string, int, bool) {
+	if src[i] == '"' {
+		j := i + 1
+		for j < len(src) && src[j] != '"' {
+			j++
+		}
+		if j < len(src) {
+			j++
+		}
+		return string(src[i:j]), j, true
+	}
+	j := i
+	for j < len(src) && isIdentRune(src[j]) {
+		j++
+	}
+	return strings.ToUpper(string(src[i:j])), j, false
+}
+
+// keywords are words never taken for a table alias or column.
+var keywords = toSet(`SELECT FROM WHERE AND OR NOT IN IS NULL LIKE BETWEEN EXISTS AS ON USING
+	JOIN INNER LEFT RIGHT FULL OUTER CROSS NATURAL GROUP BY ORDER HAVING ASC DESC NULLS FIRST LAST
+	DISTINCT UNIQUE ALL ANY SOME UNION INTERSECT MINUS EXCEPT WITH CASE WHEN THEN ELSE END ROWNUM ROWID
+	SYSDATE SYSTIMESTAMP CURRENT_DATE CURRENT_TIMESTAMP DUAL FETCH NEXT ROWS ROW ONLY OFFSET
+	CONNECT PRIOR START LEVEL OVER PARTITION INTERVAL DATE TIMESTAMP ESCAPE TRUE FALSE
+	INSERT UPDATE DELETE MERGE INTO VALUES SET DROP CREATE ALTER TRUNCATE GRANT REVOKE
+	PIVOT UNPIVOT FOR LATERAL APPLY SIBLINGS NOCYCLE DAY MONTH YEAR HOUR MINUTE SECOND TIES PERCENT`)
+
+func toSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+func isName(t token) bool {
+	return t.kind == tokWord && (t.quoted || !keywords[t.text])
+}
+
+// block is one SELECT (query block) being scanned.
+type block struct {
+	clause string
+	// sources are the FROM list entries joined by commas, by alias or name.
+	sources []string
+	// links are pairs of qualifiers compared with = in WHERE.
+	links [][2]string
+	// unqualifiedEq is set when WHERE compares two unqualified columns,
+	// which may or may not join sources.
+	unqualifiedEq bool
+	hasWhere      bool
+	hasAnd        bool
+	hasOr         bool
+	usesRownum    bool
+	hasOrderBy    bool
+	afterBetween  bool
+}
+
+// frame is one level of parentheses.
+type frame struct {
+	block *block
+	// source is set for a parenthesised FROM entry (an inline view); from is
+	// set when it was in the comma-separated FROM list.
+	source bool
+	from   bool
+}
+
+type parser struct {
+	tokens   []token
+	stmt     *Statement
+	frames   []*frame
+	ctes     map[string]bool
+	seenTbl  map[TableRef]bool
+	seenCol  map[ColumnRef]bool
+	seenFind map[string]bool
+	// expectSource is set after FROM, JOIN and commas in a FROM list.
+	expectSource bool
+	fromList     bool
+	joinKind     string
+	// pendingJoin is set after a JOIN source until ON or USING is seen.
+	pendingJoin bool
+}
+
+// Parse scans sql. It never fails; unparseable input yields fewer results.
+func Parse(sql string) *Statement {
+	p := &parser{
+		tokens:   tokenize(sql),
+		stmt:     &Statement{Tables: []TableRef{}, Columns: []ColumnRef{}, Findings: []Finding{}},
+		frames:   []*frame{{}},
+		ctes:     make(map[string]bool),
+		seenTbl:  make(map[TableRef]bool),
+		seenCol:  make(map[ColumnRef]bool),
+		seenFind: make(map[string]bool),
+	}
+	if len(p.tokens) > 0 {
+		p.stmt.Type = p.tokens[0].text
+	}
+	p.scan()
+	if p.pendingJoin {
+		// The statement ended right after a JOIN source.
+		p.missingJoin()
+	}
+	p.statementFindings()
+	// CTE names were only known once their definitions were read.
+	tables := p.stmt.Tables[:0]
+	for _, t := range p.stmt.Tables {
+		if !p.ctes[t.Name] {
+			tables = append(tables, t)
+		}
+	}
+	p.stmt.Tables = tables
+	return p.stmt
+}
+
+func (p *parser) missingJoin() {
+	p.find(FindingMissingJoin, "A JOIN has no ON or USING condition.")
+}
+
+func (p *parser) top() *frame { return p.frames[len(p.frames)-1] }
+
+// current is the innermost query block, which owns conditions written in
+// plain parentheses.
+func (p *parser) current() *block {
+	for i := len(p.frames) - 1; i >= 0; i-- {
+		if p.frames[i].block != nil {
+			return p.frames[i].block
+		}
+	}
+	return nil
+}
+
+func (p *parser) peek(i int) token {
+	if i < len(p.tokens) {
+		return p.tokens[i]
+	}
+	return token{kind: tokSymbol}
+}
+
+func (p *parser) scan() {
+	for i := 0; i < len(p.tokens); i++ {
+		t := p.tokens[i]
+		b := p.current()
+
+		if p.pendingJoin {
+			p.pendingJoin = false
+			if !t.is("ON") && !t.is("USING") {
+				p.missingJoin()
+			}
+		}
+
+		switch {
+		case t.is("("):
+			f := &frame{}
+			if p.expectSource {
+				f.source, f.from = true, p.fromList
+				p.expectSource = false
+			}
+			p.frames = append(p.frames, f)
+			continue
+		case t.is(")"):
+			if len(p.frames) == 1 {
+				continue
+			}
+			f := p.top()
+			p.finish(f.block)
+			p.frames = p.frames[:len(p.frames)-1]
+			if f.source {
+				i = p.afterSource(i+1, "", f.from) - 1
+			}
+			continue
+		case t.is("SELECT"):
+			p.finish(p.top().block)
+			p.top().block = &block{clause: "SELECT"}
+			p.expectSource = false
+			continue
+		case t.is("UNION") || t.is("INTERSECT") || t.is("MINUS") || t.is("EXCEPT"):
+			p.finish(p.top().block)
+			p.top().block = nil
+			continue
+		case t.is(";"):
+			if i+1 < len(p.tokens) {
+				p.find(FindingMultipleStmts, "The text contains more than one statement; only the first was analysed.")
+			}
+			return
+		}
+
+		// WITH name AS ( ... ) and , name AS ( ... ) define CTEs.
+		if isName(t) && p.peek(i+1).is("AS") && p.peek(i+2).is("(") && i > 0 &&
+			(p.tokens[i-1].is("WITH") || p.tokens[i-1].is(",")) && b == nil {
+			p.ctes[t.text] = true
+			continue
+		}
+
+		if b == nil {
+			// DML outside a query block: UPDATE t SET ..., DELETE FROM t ...
+			if t.is("UPDATE") || t.is("INTO") || (t.is("FROM") && p.stmt.Type == "DELETE") {
+				if next := p.peek(i + 1); isName(next) {
+					p.addTable(next.text, "")
+					i++
+				}
+			} else if isName(t) {
+				p.addColumn(t, i)
+			}
+			continue
+		}
+
+		if t.kind == tokWord && !t.quoted {
+			switch t.text {
+			case "FROM":
+				if p.top().block == b {
+					b.clause = "FROM"
+					p.expectSource, p.fromList = true, true
+					continue
+				}
+			case "JOIN":
+				if (b.clause == "FROM" || b.clause == "ON") && p.top().block == b {
+					b.clause = "FROM"
+					p.joinKind = ""
+					if prev := p.tokens[i-1]; prev.is("CROSS") || prev.is("NATURAL") {
+						p.joinKind = prev.text
+					}
+					if p.joinKind == "CROSS" {
+						p.find(FindingCartesian, "CROSS JOIN pairs every row of one table with every row of the other.")
+					}
+					p.expectSource, p.fromList = true, false
+					continue
+				}
+			case "ON", "USING":
+				if b.clause == "FROM" && p.top().block == b {
+					b.clause = "ON"
+					continue
+				}
+			case "WHERE":
+				if p.top().block == b {
+					b.clause, b.hasWhere = "WHERE", true
+					continue
+				}
+			case "GROUP", "HAVING", "CONNECT", "START":
+				if p.top().block == b {
+					b.clause = t.text
+					continue
+				}
+			case "ORDER":
+				if p.top().block == b && p.peek(i+1).is("BY") {
+					b.clause, b.hasOrderBy = "ORDER", true
+					continue
+				}
+			}
+		}
+
+		// A comma after an ON condition starts another FROM list entry.
+		if b.clause == "ON" && p.top().block == b && t.is(",") {
+			b.clause = "FROM"
+			p.expectSource, p.fromList = true, true
+			continue
+		}
+
+		if p.expectSource {
+			if isName(t) && !p.peek(i+1).is("(") {
+				p.expectSource = false
+				i = p.afterSource(i+1, t.text, p.fromList) - 1
+				continue
+			}
+			if t.kind == tokWord && !t.quoted && (t.text == "LATERAL" || t.text == "TABLE") {
+				continue
+			}
+			p.expectSource = false
+		}
+
+		if b.clause == "FROM" && t.is(",") && p.top().block == b {
+			p.expectSource, p.fromList = true, true
+			continue
+		}
+
+		p.inspect(b, i)
+	}
+	for len(p.frames) > 0 {
+		p.finish(p.top().block)
+		p.frames = p.frames[:len(p.frames)-1]
+	}
+}
+
+// afterSource records a FROM entry named name (empty for an inline view)
+// whose optional alias starts at i, and returns the index after it.
+func (p *parser) afterSource(i int, name string, fromList bool) int {
+	alias := ""
+	if p.peek(i).is("AS") {
+		i++
+	}
+	if next := p.peek(i); isName(next) && !strings.Contains(next.text, ".") {
+		alias = next.text
+		i++
+	}
+	if name != "" {
+		p.addTable(name, alias)
+	}
+	b := p.current()
+	if b == nil {
+		return i
+	}
+	ref := alias
+	if ref == "" {
+		ref = lastPart(name)
+	}
+	if fromList {
+		if ref == "" {
+			ref = fmt.Sprintf("(subquery %d)", len(b.sources)+1)
+		}
+		b.sources = append(b.sources, ref)
+	} else if p.joinKind != "CROSS" && p.joinKind != "NATURAL" {
+		p.pendingJoin = true
+	}
+	return i
+}
+
+// inspect looks at a token inside a query block for columns and findings.
+func (p *parser) inspect(b *block, i int) {
+	t := p.tokens[i]
+	own := p.top().block == b
+
+	if t.kind == tokWord && strings.HasSuffix(t.text, "*") || t.is("*") {
+		if b.clause == "SELECT" && i > 0 {
+			if prev := p.tokens[i-1]; prev.is("SELECT") || prev.is(",") || prev.is("DISTINCT") {
+				p.find(FindingSelectStar, "SELECT * returns every column; the result changes when the table does.")
+			}
+		}
+		return
+	}
+	if t.is("ROWNUM") {
+		b.usesRownum = true
+	}
+	if t.is("NOT") && p.peek(i+1).is("IN") && p.peek(i+2).is("(") && p.peek(i+3).is("SELECT") {
+		p.find(FindingNotInSubquery, "NOT IN (subquery) returns no rows at all if the subquery yields a NULL.")
+	}
+	if t.is("LIKE") && p.peek(i+1).kind == tokString && strings.HasPrefix(p.peek(i+1).text, "'%") {
+		p.find(FindingLeadingWildcard, "LIKE with a leading % cannot use an index and scans the whole table.")
+	}
+	if b.clause == "WHERE" && own {
+		switch {
+		case t.is("BETWEEN"):
+			b.afterBetween = true
+		case t.is("AND"):
+			if b.afterBetween {
+				b.afterBetween = false
+			} else {
+				b.hasAnd = true
+			}
+		case t.is("OR"):
+			b.hasOr = true
+		}
+	}
+	if b.clause == "WHERE" && t.is("=") && i > 0 {
+		left, lok := p.operand(i - 1)
+		right, rok := p.operand(i + 1)
+		if lok && rok {
+			lq, rq := qualifierOf(left), qualifierOf(right)
+			if lq == "" || rq == "" {
+				b.unqualifiedEq = true
+			} else if lq != rq {
+				b.links = append(b.links, [2]string{lq, rq})
+			}
+		}
+	}
+	if t.kind == tokWord && (t.quoted || isName(t)) {
+		p.addColumn(t, i)
+	}
+}
+
+// operand returns the column at i if it is one, allowing Oracle's (+) after
+// the right-hand column and before the = on the left.
+func (p *parser) operand(i int) (string, bool) {
+	if i >= 2 && p.peek(i).is(")") && p.peek(i-1).is("+") && p.peek(i-2).is("(") {
+		i -= 3
+	}
+	if i < 0 {
+		return "", false
+	}
+	t := p.peek(i)
+	if !isName(t) {
+		return "", false
+	}
+	if next := p.peek(i + 1); next.is("(") && !(p.peek(i+2).is("+") && p.peek(i+3).is(")")) {
+		return "", false
+	}
+	return t.text, true
+}
+
+func qualifierOf(name string) string {
+	if idx := strings.LastIndex(name, "."); idx > 0 {
+		return lastPart(name[:idx])
+	}
+	return ""
+}
+
+func lastPart(name string) string {
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		return name[idx+1:]
+	}
+	return name
+}
+
+// finish checks a completed query block for missing join conditions and
+// ROWNUM/ORDER BY misuse.
+func (p *parser) finish(b *block) {
+	if b == nil {
+		return
+	}
+	if b.usesRownum && b.hasOrderBy {
+		p.find(FindingRownumOrder, "ROWNUM is applied before ORDER BY in the same query block, so the rows kept are not the top rows of the sort.")
+	}
+	if b.hasAnd && b.hasOr {
+		p.find(FindingMixedAndOr, "WHERE mixes AND and OR; AND binds tighter, so check the parentheses match the intent.")
+	}
+	if len(b.sources) < 2 {
+		return
+	}
+	if !b.hasWhere {
+		p.find(FindingMissingJoin, fmt.Sprintf("%s are listed in FROM with no WHERE clause, producing a Cartesian product.", strings.Join(b.sources, ", ")))
+		return
+	}
+	if b.unqualifiedEq {
+		return
+	}
+	groups := make(map[string]string, len(b.sources))
+	for _, s := range b.sources {
+		groups[s] = s
+	}
+	var root func(string) string
+	root = func(s string) string {
+		for groups[s] != s {
+			s = groups[s]
+		}
+		return s
+	}
+	for _, link := range b.links {
+		if _, ok := groups[link[0]]; !ok {
+			continue
+		}
+		if _, ok := groups[link[1]]; !ok {
+			continue
+		}
+		groups[root(link[0])] = root(link[1])
+	}
+	components := make(map[string][]string)
+	for _, s := range b.sources {
+		r := root(s)
+		components[r] = append(components[r], s)
+	}
+	if len(components) < 2 {
+		return
+	}
+	var parts []string
+	for _, members := range components {
+		parts = append(parts, strings.Join(members, "+"))
+	}
+	sort.Strings(parts)
+	p.find(FindingMissingJoin, fmt.Sprintf("No join condition connects %s; their rows are combined as a Cartesian product.", strings.Join(parts, " and ")))
+}
+
+// statementFindings flags statements that change data.
+func (p *parser) statementFindings() {
+	switch p.stmt.Type {
+	case "", "SELECT", "WITH", "(":
+		return
+	case "UPDATE", "DELETE":
+		p.find(FindingModifiesData, p.stmt.Type+" changes data; it cannot be run from this assistant.")
+		if !p.hasTopLevel("WHERE") {
+			p.find(FindingNoWhere, p.stmt.Type+" without WHERE affects every row of the table.")
+		}
+	default:
+		p.find(FindingModifiesData, p.stmt.Type+" is not a query; it cannot be run from this assistant.")
+	}
+}
+
+func (p *parser) hasTopLevel(word string) bool {
+	depth := 0
+	for _, t := range p.tokens {
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			depth--
+		case depth == 0 && t.is(word):
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) addTable(name, alias string) {
+	ref := TableRef{Name: name, Alias: alias}
+	if !p.seenTbl[ref] {
+		p.seenTbl[ref] = true
+		p.stmt.Tables = append(p.stmt.Tables, ref)
+	}
+}
+
+// addColumn records the word at i unless it is a function name or an alias
+// being defined.
+func (p *parser) addColumn(t token, i int) {
+	if p.peek(i+1).is("(") && !(p.peek(i+2).is("+") && p.peek(i+3).is(")")) {
+		return
+	}
+	if i > 0 && p.tokens[i-1].is("AS") {
+		return
+	}
+	ref := ColumnRef{Name: lastPart(t.text)}
+	if q := t.text[:len(t.text)-len(ref.Name)]; q != "" {
+		ref.Qualifier = strings.TrimSuffix(q, ".")
+	}
+	if !p.seenCol[ref] {
+		p.seenCol[ref] = true
+		p.stmt.Columns = append(p.stmt.Columns, ref)
+	}
+}
+
+func (p *parser) find(code, message string) {
+	key := code + "\x00" + message
+	if p.seenFind[key] {
+		return
+	}
+	p.seenFind[key] = true
+	p.stmt.Findings = append(p.stmt.Findings, Finding{Code: code, Message: message})
+}
@@ -0,0 +1,172 @@
+package sqlparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		typ      string
+		tables   []TableRef
+		findings []string
+	}{
+		{
+			name:   "inner join on",
+			sql:    "select a.x from a join b on a.id = b.id",
+			typ:    "SELECT",
+			tables: []TableRef{{Name: "A"}, {Name: "B"}},
+		},
+		{
+			name:   "outer join on parenthesised condition, then using",
+			sql:    "select a.x from a left join b on (a.id = b.id) inner join c using (id)",
+			typ:    "SELECT",
+			tables: []TableRef{{Name: "A"}, {Name: "B"}, {Name: "C"}},
+		},
+		{
+			name:     "join without condition",
+			sql:      "select a.x from a join b where a.id = 1",
+			typ:      "SELECT",
+			tables:   []TableRef{{Name: "A"}, {Name: "B"}},
+			findings: []string{FindingMissingJoin},
+		},
+		{
+			name:     "join at the end of the statement",
+			sql:      "select a.x from a join b",
+			typ:      "SELECT",
+			tables:   []TableRef{{Name: "A"}, {Name: "B"}},
+			findings: []string{FindingMissingJoin},
+		},
+		{
+			name:     "cross join",
+			sql:      "select x from a cross join b",
+			typ:      "SELECT",
+			tables:   []TableRef{{Name: "A"}, {Name: "B"}},
+			findings: []string{FindingCartesian},
+		},
+		{
+			name:   "natural join",
+			sql:    "select x from a natural join b",
+			typ:    "SELECT",
+			tables: []TableRef{{Name: "A"}, {Name: "B"}},
+		},
+		{
+			name:     "comma join without where",
+			sql:      "select x from a, b",
+			typ:      "SELECT",
+			tables:   []TableRef{{Name: "A"}, {Name: "B"}},
+			findings: []string{FindingMissingJoin},
+		},
+		{
+			name:   "comma join with condition",
+			sql:    "select a.x from a, b where a.id = b.id",
+			typ:    "SELECT",
+			tables: []TableRef{{Name: "A"}, {Name: "B"}},
+		},
+		{
+			name:     "comma join with unrelated where",
+			sql:      "select a.x from a, b where a.id = 1",
+			typ:      "SELECT",
+			tables:   []TableRef{{Name: "A"}, {Name: "B"}},
+			findings: []string{FindingMissingJoin},
+		},
+		{
+			name:   "join of an inline view",
+			sql:    "select v.x from (select x from t) v join u on v.x = u.x",
+			typ:    "SELECT",
+			tables: []TableRef{{Name: "T"}, {Name: "U"}},
+		},
+		{
+			name:   "cte names are not tables",
+			sql:    "with c as (select id from t) select c.id from c join u on c.id = u.id",
+			typ:    "WITH",
+			tables: []TableRef{{Name: "T"}, {Name: "U"}},
+		},
+		{
+			name:     "owner, alias, leading wildcard and mixed and/or",
+			sql:      "select e.x from hr.emp e where e.name like '%x' and e.a = 1 or e.b = 2",
+			typ:      "SELECT",
+			tables:   []TableRef{{Name: "HR.EMP", Alias: "E"}},
+			findings: []string{FindingLeadingWildcard, FindingMixedAndOr},
+		},
+		{
+			name:   "between does not count as and",
+			sql:    "select x from t where d between 1 and 2 or e = 3",
+			typ:    "SELECT",
+			tables: []TableRef{{Name: "T"}},
+		},
+		{
+			name:     "select star and not in subquery",
+			sql:      "select * from t where id not in (select id from u)",
+			typ:      "SELECT",
+			tables:   []TableRef{{Name: "T"}, {Name: "U"}},
+			findings: []string{FindingSelectStar, FindingNotInSubquery},
+		},
+		{
+			name:     "rownum before order by",
+			sql:      "select x from t where rownum <= 10 order by x",
+			typ:      "SELECT",
+			tables:   []TableRef{{Name: "T"}},
+			findings: []string{FindingRownumOrder},
+		},
+		{
+			name:     "update without where",
+			sql:      "update t set x = 1",
+			typ:      "UPDATE",
+			tables:   []TableRef{{Name: "T"}},
+			findings: []string{FindingModifiesData, FindingNoWhere},
+		},
+		{
+			name:     "delete with where",
+			sql:      "delete from t where id = 1",
+			typ:      "DELETE",
+			tables:   []TableRef{{Name: "T"}},
+			findings: []string{FindingModifiesData},
+		},
+		{
+			name:     "multiple statements",
+			sql:      "select 1 from dual; drop table t",
+			typ:      "SELECT",
+			tables:   []TableRef{},
+			findings: []string{FindingMultipleStmts},
+		},
+		{
+			name:   "quoted identifiers keep their case",
+			sql:    `select "Mixed".col from "Mixed"`,
+			typ:    "SELECT",
+			tables: []TableRef{{Name: `"Mixed"`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := Parse(tt.sql)
+			if stmt.Type != tt.typ {
+				t.Errorf("Type = %q, want %q", stmt.Type, tt.typ)
+			}
+			if !reflect.DeepEqual(stmt.Tables, tt.tables) {
+				t.Errorf("Tables = %v, want %v", stmt.Tables, tt.tables)
+			}
+			codes := []string{}
+			for _, f := range stmt.Findings {
+				codes = append(codes, f.Code)
+			}
+			want := tt.findings
+			if want == nil {
+				want = []string{}
+			}
+			if !reflect.DeepEqual(codes, want) {
+				t.Errorf("Findings = %v, want %v", codes, want)
+			}
+		})
+	}
+}
+
+func TestParseColumns(t *testing.T) {
+	stmt := Parse("select a.x, y from a join b on a.id = b.id")
+	want := []ColumnRef{{Qualifier: "A", Name: "X"}, {Name: "Y"}, {Qualifier: "A", Name: "ID"}, {Qualifier: "B", Name: "ID"}}
+	if !reflect.DeepEqual(stmt.Columns, want) {
+		t.Errorf("Columns = %v, want %v", stmt.Columns, want)
+	}
+}
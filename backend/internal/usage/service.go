@@ -0,0 +1,170 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/yourusername/db_asst/config"
+	"github.com/yourusername/db_asst/internal/llm"
+)
+
+// Budget is a spending limit and what has been spent against it.
+type Budget struct {
+	Scope    string  `json:"scope"`  // "user" or "team"
+	Period   string  `json:"period"` // "daily" or "monthly"
+	LimitUSD float64 `json:"limit_usd"`
+	SpentUSD float64 `json:"spent_usd"`
+}
+
+// Exceeded reports whether the budget is set and used up.
+func (b Budget) Exceeded() bool {
+	return b.LimitUSD > 0 && b.SpentUSD >= b.LimitUSD
+}
+
+// Service prices LLM usage, stores it and enforces budgets. It implements llm.Meter.
+type Service struct {
+	store    *Store
+	prices   map[string]config.ModelPrice
+	prefixes []string // price keys, longest first, for prefix matching
+	teams    map[string]string
+	cfg      *config.Config
+	logger   *zap.Logger
+}
+
+// NewService creates the usage service.
+func NewService(store *Store, cfg *config.Config, logger *zap.Logger) *Service {
+	s := &Service{
+		store:  store,
+		prices: cfg.LLMPrices,
+		teams:  cfg.UserTeams,
+		cfg:    cfg,
+		logger: logger,
+	}
+	for model := range s.prices {
+		s.prefixes = append(s.prefixes, model)
+	}
+	sort.Slice(s.prefixes, func(i, j int) bool { return len(s.prefixes[i]) > len(s.prefixes[j]) })
+	return s
+}
+
+// TeamFor returns the configured team for a username.
+func (s *Service) TeamFor(username string) string {
+	return s.teams[strings.ToLower(strings.TrimSpace(username))]
+}
+
+// Cost prices usage for model. Models are matched exactly, then by the
+// longest configured prefix (so "gpt-4o" prices "gpt-4o-2024-08-06").
+// Unpriced models cost 0 and report false.
+func (s *Service) Cost(model string, u llm.Usage) (float64, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	price, ok := s.prices[model]
+	if !ok {
+		for _, prefix := range s.prefixes {
+			if strings.HasPrefix(model, prefix) {
+				price, ok = s.prices[prefix], true
+				break
+			}
+		}
+	}
+	if !ok {
+		return 0, false
+	}
+	return (float64(u.PromptTokens)*price.InputPerMillion + float64(u.CompletionTokens)*price.OutputPerMillion) / 1e6, true
+}
+
+// Budgets returns the configured budgets that apply to the user with their current spend.
+func (s *Service) Budgets(userID, username string) ([]Budget, error) {
+	now := time.Now()
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+
+	type spec struct {
+		scope, period string
+		key           string
+		byTeam        bool
+		limit         float64
+		since         time.Time
+	}
+	specs := []spec{
+		{"user", "daily", userID, false, s.cfg.BudgetUserDailyUSD, dayStart},
+		{"user", "monthly", userID, false, s.cfg.BudgetUserMonthlyUSD, monthStart},
+	}
+	if team := s.TeamFor(username); team != "" {
+		specs = append(specs,
+			spec{"team", "daily", team, true, s.cfg.BudgetTeamDailyUSD, dayStart},
+			spec{"team", "monthly", team, true, s.cfg.BudgetTeamMonthlyUSD, monthStart},
+		)
+	}
+
+	budgets := make([]Budget, 0, len(specs))
+	for _, sp := range specs {
+		if sp.limit <= 0 || sp.key == "" {
+			continue
+		}
+		spent, err := s.store.SpendSince(sp.key, sp.byTeam, sp.since)
+		if err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, Budget{Scope: sp.scope, Period: sp.period, LimitUSD: sp.limit, SpentUSD: spent})
+	}
+	return budgets, nil
+}
+
+// Allow rejects the call when any applicable budget is used up. Calls without
+// a user (background jobs) and lookup failures are let through.
+func (s *Service) Allow(ctx context.Context, scope llm.CallScope) error {
+	if scope.UserID == "" {
+		return nil
+	}
+	budgets, err := s.Budgets(scope.UserID, scope.Username)
+	if err != nil {
+		s.logger.Warn("Failed to check LLM budget", zap.String("user_id", scope.UserID), zap.Error(err))
+		return nil
+	}
+	for _, b := range budgets {
+		if b.Exceeded() {
+			return fmt.Errorf("%w: %s %s budget $%.2f used ($%.2f spent)", llm.ErrBudgetExceeded, b.Scope, b.Period, b.LimitUSD, b.SpentUSD)
+		}
+	}
+	return nil
+}
+
+// Record prices and stores a completed call.
+func (s *Service) Record(ctx context.Context, rec llm.UsageRecord) {
+	cost, priced := s.Cost(rec.Model, rec.Usage)
+	if !priced {
+		s.logger.Debug("No price configured for model", zap.String("model", rec.Model))
+	}
+	err := s.store.Insert(&Record{
+		UserID:           rec.UserID,
+		Username:         rec.Username,
+		Team:             s.TeamFor(rec.Username),
+		SessionID:        rec.SessionID,
+		RequestID:        rec.RequestID,
+		Operation:        rec.Operation,
+		Provider:         rec.Provider,
+		Model:            rec.Model,
+		PromptTokens:     rec.Usage.PromptTokens,
+		CompletionTokens: rec.Usage.CompletionTokens,
+		Estimated:        rec.Usage.Estimated,
+		CostUSD:          cost,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to record LLM usage", zap.String("request_id", rec.RequestID), zap.Error(err))
+	}
+}
+
+// Report aggregates usage per user in [from, to).
+func (s *Service) Report(from, to time.Time) ([]Aggregate, error) {
+	return s.store.Aggregate(from, to)
+}
+
+// RequestUsage returns the accounted calls for one request.
+func (s *Service) RequestUsage(requestID string) ([]Record, error) {
+	return s.store.ListByRequest(requestID)
+}
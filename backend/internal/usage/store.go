@@ -0,0 +1,238 @@
+package usage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Record is one accounted LLM call.
+type Record struct {
+	ID               string    `json:"id"`
+	UserID           string    `json:"user_id"`
+	Username         string    `json:"username"`
+	Team             string    `json:"team,omitempty"`
+	SessionID        string    `json:"session_id,omitempty"`
+	RequestID        string    `json:"request_id,omitempty"`
+	Operation        string    `json:"operation"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	Estimated        bool      `json:"estimated"`
+	CostUSD          float64   `json:"cost_usd"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Aggregate sums usage for one user over a window.
+type Aggregate struct {
+	UserID           string  `json:"user_id"`
+	Username         string  `json:"username"`
+	Team             string  `json:"team,omitempty"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// Store persists usage records in the app database.
+type Store struct {
+	db     *sql.DB
+	driver string
+}
+
+// NewStore creates the usage store and ensures its table exists.
+func NewStore(db *sql.DB, driver string) (*Store, error) {
+	if db == nil {
+		return nil, errors.New("usage store requires db handle")
+	}
+	s := &Store{db: db, driver: driver}
+	if err := s.ensureTable(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) ensureTable() error {
+	switch s.driver {
+	case "mysql":
+		const ddl = `CREATE TABLE IF NOT EXISTS llm_usage (
+            id CHAR(36) PRIMARY KEY,
+            user_id VARCHAR(64),
+            username VARCHAR(128),
+            team VARCHAR(64),
+            session_id VARCHAR(128),
+            request_id VARCHAR(64),
+            operation VARCHAR(64),
+            provider VARCHAR(32),
+            model VARCHAR(128),
+            prompt_tokens INT NOT NULL,
+            completion_tokens INT NOT NULL,
+            estimated TINYINT(1) NOT NULL DEFAULT 0,
+            cost_usd DECIMAL(14,6) NOT NULL DEFAULT 0,
+            created_at DATETIME NOT NULL,
+            KEY idx_usage_user (user_id, created_at),
+            KEY idx_usage_team (team, created_at),
+            KEY idx_usage_request (request_id)
+        ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;`
+		_, err := s.db.Exec(ddl)
+		return err
+	case "oracle":
+		const check = `SELECT COUNT(*) FROM USER_TABLES WHERE TABLE_NAME = 'LLM_USAGE'`
+		var count int
+		if err := s.db.QueryRow(check).Scan(&count); err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		const ddl = `CREATE TABLE LLM_USAGE (
+            ID VARCHAR2(36) PRIMARY KEY,
+            USER_ID VARCHAR2(64),
+            USERNAME VARCHAR2(128),
+            TEAM VARCHAR2(64),
+            SESSION_ID VARCHAR2(128),
+            REQUEST_ID VARCHAR2(64),
+            OPERATION VARCHAR2(64),
+            PROVIDER VARCHAR2(32),
+            MODEL VARCHAR2(128),
+            PROMPT_TOKENS NUMBER NOT NULL,
+            COMPLETION_TOKENS NUMBER NOT NULL,
+            ESTIMATED NUMBER(1) DEFAULT 0 NOT NULL,
+            COST_USD NUMBER(14,6) DEFAULT 0 NOT NULL,
+            CREATED_AT TIMESTAMP NOT NULL
+        )`
+		if _, err := s.db.Exec(ddl); err != nil {
+			return err
+		}
+		for _, idx := range []string{
+			`CREATE INDEX IDX_USAGE_USER ON LLM_USAGE (USER_ID, CREATED_AT)`,
+			`CREATE INDEX IDX_USAGE_TEAM ON LLM_USAGE (TEAM, CREATED_AT)`,
+			`CREATE INDEX IDX_USAGE_REQUEST ON LLM_USAGE (REQUEST_ID)`,
+		} {
+			if _, err := s.db.Exec(idx); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported driver %s", s.driver)
+	}
+}
+
+// Insert stores rec, assigning ID and CreatedAt when empty.
+func (s *Store) Insert(rec *Record) error {
+	if rec.ID == "" {
+		rec.ID = uuid.New().String()
+	}
+	if rec.CreatedAt.IsZero() {
+		rec.CreatedAt = time.Now()
+	}
+	args := []interface{}{rec.ID, rec.UserID, rec.Username, rec.Team, rec.SessionID, rec.RequestID, rec.Operation,
+		rec.Provider, rec.Model, rec.PromptTokens, rec.CompletionTokens, boolToInt(rec.Estimated), rec.CostUSD, rec.CreatedAt}
+	if s.driver == "oracle" {
+		_, err := s.db.Exec(`INSERT INTO LLM_USAGE (ID, USER_ID, USERNAME, TEAM, SESSION_ID, REQUEST_ID, OPERATION,
+            PROVIDER, MODEL, PROMPT_TOKENS, COMPLETION_TOKENS, ESTIMATED, COST_USD, CREATED_AT)
+            VALUES (:1, :2, :3, :4, :5, :6, :7, :8, :9, :10, :11, :12, :13, :14)`, args...)
+		return err
+	}
+	_, err := s.db.Exec(`INSERT INTO llm_usage (id, user_id, username, team, session_id, request_id, operation,
+        provider, model, prompt_tokens, completion_tokens, estimated, cost_usd, created_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, args...)
+	return err
+}
+
+// SpendSince returns the total cost for user_id (or team when byTeam) since the given time.
+func (s *Store) SpendSince(key string, byTeam bool, since time.Time) (float64, error) {
+	column := "user_id"
+	if byTeam {
+		column = "team"
+	}
+	query := `SELECT COALESCE(SUM(cost_usd), 0) FROM llm_usage WHERE ` + column + ` = ? AND created_at >= ?`
+	if s.driver == "oracle" {
+		query = `SELECT COALESCE(SUM(COST_USD), 0) FROM LLM_USAGE WHERE ` + column + ` = :1 AND CREATED_AT >= :2`
+	}
+	var total float64
+	err := s.db.QueryRow(query, key, since).Scan(&total)
+	return total, err
+}
+
+// Aggregate sums usage per user in [from, to), most expensive first.
+func (s *Store) Aggregate(from, to time.Time) ([]Aggregate, error) {
+	query := `SELECT user_id, MAX(username), MAX(team), COUNT(*), SUM(prompt_tokens), SUM(completion_tokens), SUM(cost_usd)
+        FROM llm_usage WHERE created_at >= ? AND created_at < ?
+        GROUP BY user_id ORDER BY SUM(cost_usd) DESC`
+	if s.driver == "oracle" {
+		query = `SELECT USER_ID, MAX(USERNAME), MAX(TEAM), COUNT(*), SUM(PROMPT_TOKENS), SUM(COMPLETION_TOKENS), SUM(COST_USD)
+            FROM LLM_USAGE WHERE CREATED_AT >= :1 AND CREATED_AT < :2
+            GROUP BY USER_ID ORDER BY SUM(COST_USD) DESC`
+	}
+	rows, err := s.db.Query(query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]Aggregate, 0)
+	for rows.Next() {
+		var agg Aggregate
+		var userID, username, team sql.NullString
+		if err := rows.Scan(&userID, &username, &team, &agg.Calls, &agg.PromptTokens, &agg.CompletionTokens, &agg.CostUSD); err != nil {
+			return nil, err
+		}
+		agg.UserID = userID.String
+		agg.Username = username.String
+		agg.Team = team.String
+		result = append(result, agg)
+	}
+	return result, rows.Err()
+}
+
+// ListByRequest returns the records for one request ID.
+func (s *Store) ListByRequest(requestID string) ([]Record, error) {
+	query := `SELECT id, user_id, username, team, session_id, request_id, operation, provider, model,
+        prompt_tokens, completion_tokens, estimated, cost_usd, created_at
+        FROM llm_usage WHERE request_id = ? ORDER BY created_at`
+	if s.driver == "oracle" {
+		query = `SELECT ID, USER_ID, USERNAME, TEAM, SESSION_ID, REQUEST_ID, OPERATION, PROVIDER, MODEL,
+            PROMPT_TOKENS, COMPLETION_TOKENS, ESTIMATED, COST_USD, CREATED_AT
+            FROM LLM_USAGE WHERE REQUEST_ID = :1 ORDER BY CREATED_AT`
+	}
+	rows, err := s.db.Query(query, requestID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]Record, 0)
+	for rows.Next() {
+		var rec Record
+		var userID, username, team, sessionID, reqID, operation, provider, model sql.NullString
+		var estimated int
+		if err := rows.Scan(&rec.ID, &userID, &username, &team, &sessionID, &reqID, &operation, &provider, &model,
+			&rec.PromptTokens, &rec.CompletionTokens, &estimated, &rec.CostUSD, &rec.CreatedAt); err != nil {
+			return nil, err
+		}
+		rec.UserID = userID.String
+		rec.Username = username.String
+		rec.Team = team.String
+		rec.SessionID = sessionID.String
+		rec.RequestID = reqID.String
+		rec.Operation = operation.String
+		rec.Provider = provider.String
+		rec.Model = model.String
+		rec.Estimated = estimated == 1
+		result = append(result, rec)
+	}
+	return result, rows.Err()
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}